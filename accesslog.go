@@ -0,0 +1,157 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// accessLogTimeLayout is the [%t] timestamp layout of the combined format.
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogHook returns a flush hook that writes a classic Apache/Nginx
+// combined log format line for each entry that records a request, next to
+// the canonical line, for legacy tooling that parses access logs:
+//
+//	203.0.113.7 - u_123 [10/Oct/2025:13:55:36 +0000] "GET /orders HTTP/1.1" 200 2326 "-" "curl/8.4.0"
+//
+// The line is built from remote_ip, user_id, method, path, http_proto,
+// status, response_size, referer, and user_agent; missing fields are written
+// as "-", and entries without method and path are skipped. Quoted values are
+// escaped, and whitespace and control characters in the unquoted ones are
+// replaced with "_", so no field can forge a line. The time is when
+// the entry is flushed, matching servers that log at request completion.
+// Lines are written to w, or os.Stdout if w is nil.
+//
+// Example:
+//
+//	access, _ := os.OpenFile("/var/log/app/access.log", os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+//	canonlog.AddFlushHook(canonlog.AccessLogHook(access))
+func AccessLogHook(w io.Writer) FlushHook {
+	if w == nil {
+		w = os.Stdout
+	}
+	var mu sync.Mutex
+	return func(ctx context.Context, e Entry) {
+		line, ok := accessLogLine(e.Fields)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(w, line)
+	}
+}
+
+// accessLogLine formats fields in the combined log format.
+func accessLogLine(fields map[string]any) (string, bool) {
+	method := accessLogField(fields, "method", "http_method")
+	path := accessLogField(fields, "path", "url")
+	if method == "-" || path == "-" {
+		return "", false
+	}
+	proto := accessLogField(fields, "http_proto")
+	if proto == "-" {
+		proto = "HTTP/1.1"
+	}
+
+	var b strings.Builder
+	b.WriteString(accessLogToken(accessLogField(fields, remoteIPKey)))
+	b.WriteString(" - ")
+	b.WriteString(accessLogToken(accessLogField(fields, userIDKey)))
+	b.WriteString(" [")
+	b.WriteString(defaultClock().Now().Format(accessLogTimeLayout))
+	b.WriteString(`] "`)
+	b.WriteString(accessLogEscape(method + " " + path + " " + proto))
+	b.WriteString(`" `)
+	b.WriteString(accessLogToken(accessLogField(fields, "status", "status_code")))
+	b.WriteByte(' ')
+	b.WriteString(accessLogToken(accessLogField(fields, "response_size")))
+	b.WriteString(` "`)
+	b.WriteString(accessLogEscape(accessLogField(fields, "referer")))
+	b.WriteString(`" "`)
+	b.WriteString(accessLogEscape(accessLogField(fields, "user_agent")))
+	b.WriteString("\"\n")
+	return b.String(), true
+}
+
+// accessLogField returns the first of keys present in fields as a string,
+// or "-" if none is.
+func accessLogField(fields map[string]any, keys ...string) string {
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case nil:
+			continue
+		case string:
+			if v != "" {
+				return v
+			}
+		case int:
+			return strconv.Itoa(v)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return "-"
+}
+
+// accessLogToken replaces whitespace, control characters, and invalid UTF-8
+// in an unquoted value with "_", so it stays one space-separated token and
+// cannot break the line.
+func accessLogToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// accessLogEscape backslash-escapes quotes and backslashes and writes control
+// characters and invalid UTF-8 as \xHH, so a quoted value cannot break the
+// line.
+func accessLogEscape(s string) string {
+	clean := true
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' || c < 0x20 || c >= 0x7f {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+			i++
+		case c >= 0x80:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError || r < 0xa0 {
+				for _, x := range []byte(s[i : i+size]) {
+					fmt.Fprintf(&b, `\x%02X`, x)
+				}
+			} else {
+				b.WriteString(s[i : i+size])
+			}
+			i += size
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02X`, c)
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
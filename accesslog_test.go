@@ -0,0 +1,101 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogHook(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	resetFlushHooks(t)
+	SetClock(&fakeClock{now: time.Date(2025, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))})
+	t.Cleanup(func() { SetClock(nil) })
+
+	var access bytes.Buffer
+	AddFlushHook(AccessLogHook(&access))
+
+	l := New()
+	l.InfoAddMany(map[string]any{
+		"remote_ip":     "203.0.113.7",
+		"user_id":       "u_123",
+		"method":        "GET",
+		"path":          "/orders",
+		"http_proto":    "HTTP/2.0",
+		"status":        200,
+		"response_size": int64(2326),
+		"user_agent":    `curl/8.4.0 "x"`,
+	})
+	l.Flush(context.Background())
+
+	want := `203.0.113.7 - u_123 [10/Oct/2025:13:55:36 -0700] "GET /orders HTTP/2.0" 200 2326 "-" "curl/8.4.0 \"x\""` + "\n"
+	if access.String() != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, access.String())
+	}
+
+	// Entries that are not requests are skipped
+	access.Reset()
+	l.InfoAdd("job", "reindex")
+	l.Flush(context.Background())
+	if access.Len() != 0 {
+		t.Errorf("Expected no access line for a non-request entry, got %q", access.String())
+	}
+}
+
+func TestAccessLogLineDefaults(t *testing.T) {
+	line, ok := accessLogLine(map[string]any{"method": "POST", "path": "/login"})
+	if !ok {
+		t.Fatal("Expected a line")
+	}
+	if want := `"POST /login HTTP/1.1" - - "-" "-"`; !bytes.Contains([]byte(line), []byte(want)) {
+		t.Errorf("Expected %q in %q", want, line)
+	}
+	if line[:6] != "- - - " {
+		t.Errorf("Expected missing host and user as -, got %q", line)
+	}
+}
+
+func TestAccessLogLineInjection(t *testing.T) {
+	forged := "\n203.0.113.9 - admin [10/Oct/2025:13:55:36 +0000] \"GET /admin HTTP/1.1\" 200 1 \"-\" \"-\""
+	line, ok := accessLogLine(map[string]any{
+		"method":        "GET",
+		"path":          "/orders",
+		remoteIPKey:     "10.0.0.1" + forged,
+		userIDKey:       "u_1\r\tx" + forged,
+		"status":        "200" + forged,
+		"response_size": "12\x00" + forged,
+	})
+	if !ok {
+		t.Fatal("Expected a line")
+	}
+	if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("Expected a single line, got %q", line)
+	}
+	if fields := strings.Fields(line); fields[0] != "10.0.0.1_203.0.113.9_-_admin_[10/Oct/2025:13:55:36_+0000]_\"GET_/admin_HTTP/1.1\"_200_1_\"-\"_\"-\"" {
+		t.Errorf("Expected remote_ip as one token, got %q", fields[0])
+	}
+	if want := "u_1__x_"; !strings.Contains(line, " - "+want) {
+		t.Errorf("Expected user_id %q in %q", want, line)
+	}
+}
+
+func TestAccessLogEscape(t *testing.T) {
+	tests := map[string]string{
+		"plain":           "plain",
+		`a"b\c`:           `a\"b\\c`,
+		"tab\there":       `tab\x09here`,
+		"newline\n":       `newline\x0A`,
+		"café":            "café",
+		"bad\xffutf8":     `bad\xFFutf8`,
+		"c1\u0085control": `c1\xC2\x85control`,
+	}
+	for in, want := range tests {
+		if got := accessLogEscape(in); got != want {
+			t.Errorf("accessLogEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
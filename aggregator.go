@@ -0,0 +1,279 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRollupSamples bounds the number of durations kept per key and interval.
+// Beyond it, samples are replaced at random so percentiles stay representative.
+const maxRollupSamples = 10000
+
+// aggregator is the globally installed Aggregator, if any.
+var aggregator atomic.Pointer[Aggregator]
+
+// AggregatorConfig configures an Aggregator.
+type AggregatorConfig struct {
+	// Interval is how often rollup lines are emitted by Start. Defaults to one minute.
+	Interval time.Duration
+
+	// KeyField is the field whose value selects entries for aggregation,
+	// for example "route".
+	KeyField string
+
+	// Keys lists the KeyField values to aggregate. Entries with other values
+	// are emitted normally.
+	Keys []string
+
+	// StatusField, if set, names a field whose values are counted in the
+	// rollup's status_counts, for example "status".
+	StatusField string
+
+	// DurationField, if set, names a numeric field used for the rollup's
+	// latency percentiles, for example "duration_ms". time.Duration values
+	// are converted to milliseconds.
+	DurationField string
+}
+
+// Aggregator replaces per-entry lines for designated keys with periodic rollup
+// lines. Entries whose KeyField value is listed in Keys are recorded instead of
+// emitted, except entries at Error level, which are both recorded and emitted
+// so failures stay visible.
+//
+// Each rollup line contains the key field, count, error_count, and, when
+// configured, status_counts and duration_p50/p95/p99/max. It is emitted at the
+// highest level recorded during the interval.
+//
+// Example:
+//
+//	agg := canonlog.NewAggregator(canonlog.AggregatorConfig{
+//		Interval:      30 * time.Second,
+//		KeyField:      "route",
+//		Keys:          []string{"/healthz", "/metrics"},
+//		StatusField:   "status",
+//		DurationField: "duration_ms",
+//	})
+//	canonlog.SetAggregator(agg)
+//	agg.Start()
+//	defer agg.Stop(context.Background())
+type Aggregator struct {
+	cfg  AggregatorConfig
+	keys map[string]struct{}
+
+	mu      sync.Mutex
+	rollups map[string]*rollup
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// rollup accumulates entries for one key during an interval.
+type rollup struct {
+	labels    map[string]any // the key field, encrypted if designated
+	level     slog.Level
+	count     int
+	errors    int
+	statuses  map[string]int
+	durations []float64
+}
+
+// NewAggregator creates an Aggregator from cfg. It records entries once
+// installed with SetAggregator and emits rollups when Start is called or
+// Emit is called directly.
+func NewAggregator(cfg AggregatorConfig) *Aggregator {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	keys := make(map[string]struct{}, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		keys[k] = struct{}{}
+	}
+	return &Aggregator{
+		cfg:     cfg,
+		keys:    keys,
+		rollups: make(map[string]*rollup),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetAggregator installs agg for all loggers. Passing nil removes the aggregator.
+func SetAggregator(agg *Aggregator) {
+	aggregator.Store(agg)
+}
+
+// Start emits rollups every Interval on a background goroutine until Stop is called.
+// Calling Start more than once has no effect.
+func (a *Aggregator) Start() {
+	if !a.started.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.Emit(context.Background())
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background goroutine started by Start, if any, and emits a
+// final rollup for data recorded since the last one.
+func (a *Aggregator) Stop(ctx context.Context) {
+	a.stopOnce.Do(func() {
+		close(a.stop)
+	})
+	if a.started.Load() {
+		select {
+		case <-a.done:
+		case <-ctx.Done():
+		}
+	}
+	a.Emit(ctx)
+}
+
+// record adds an entry to the rollup for its key. It reports whether the entry
+// should be suppressed rather than emitted.
+func (a *Aggregator) record(fields map[string]any, level slog.Level, errCount int) bool {
+	v, ok := fields[a.cfg.KeyField]
+	if !ok {
+		return false
+	}
+	key := fmt.Sprint(v)
+	if _, ok := a.keys[key]; !ok {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.rollups[key]
+	if !ok {
+		// Rollup lines print the key, so an encrypted key field stays sealed
+		labels := map[string]any{a.cfg.KeyField: key}
+		encryptFields(labels)
+		r = &rollup{labels: labels, level: level, statuses: make(map[string]int)}
+		a.rollups[key] = r
+	}
+	r.count++
+	if level > r.level {
+		r.level = level
+	}
+	if errCount > 0 {
+		r.errors++
+	}
+	if a.cfg.StatusField != "" {
+		if status, ok := fields[a.cfg.StatusField]; ok {
+			r.statuses[fmt.Sprint(status)]++
+		}
+	}
+	if a.cfg.DurationField != "" {
+		if d, ok := toFloat(fields[a.cfg.DurationField]); ok {
+			if len(r.durations) < maxRollupSamples {
+				r.durations = append(r.durations, d)
+			} else if i := rand.IntN(r.count); i < maxRollupSamples {
+				r.durations[i] = d
+			}
+		}
+	}
+
+	return level < slog.LevelError
+}
+
+// Emit writes one rollup line per key recorded since the previous call and
+// resets the rollups.
+func (a *Aggregator) Emit(ctx context.Context) {
+	a.mu.Lock()
+	rollups := a.rollups
+	a.rollups = make(map[string]*rollup, len(rollups))
+	a.mu.Unlock()
+
+	for _, r := range rollups {
+		attrs := []slog.Attr{slog.Any(a.cfg.KeyField, r.labels[a.cfg.KeyField])}
+		if dataKey, ok := r.labels[dataKeyKey]; ok {
+			attrs = append(attrs, slog.Any(dataKeyKey, dataKey))
+		}
+		attrs = append(attrs,
+			slog.Int("count", r.count),
+			slog.Int("error_count", r.errors),
+		)
+		if len(r.statuses) > 0 {
+			statuses := make([]slog.Attr, 0, len(r.statuses))
+			for status, n := range r.statuses {
+				statuses = append(statuses, slog.Int(status, n))
+			}
+			attrs = append(attrs, slog.Attr{Key: "status_counts", Value: slog.GroupValue(statuses...)})
+		}
+		if len(r.durations) > 0 {
+			slices.Sort(r.durations)
+			attrs = append(attrs,
+				slog.Float64("duration_p50", percentile(r.durations, 50)),
+				slog.Float64("duration_p95", percentile(r.durations, 95)),
+				slog.Float64("duration_p99", percentile(r.durations, 99)),
+				slog.Float64("duration_max", r.durations[len(r.durations)-1]),
+			)
+		}
+		slog.LogAttrs(ctx, r.level, "", attrs...)
+	}
+}
+
+// percentile returns the p-th percentile of sorted using the nearest-rank method.
+func percentile(sorted []float64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// toFloat converts numeric field values to float64. time.Duration values are
+// converted to milliseconds.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case time.Duration:
+		return float64(n) / float64(time.Millisecond), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
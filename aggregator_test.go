@@ -0,0 +1,106 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	agg := NewAggregator(AggregatorConfig{
+		KeyField:      "route",
+		Keys:          []string{"/healthz"},
+		StatusField:   "status",
+		DurationField: "duration_ms",
+	})
+	SetAggregator(agg)
+	t.Cleanup(func() { SetAggregator(nil) })
+
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		New().InfoAdd("route", "/healthz").InfoAdd("status", 200).InfoAdd("duration_ms", i*10).Flush(ctx)
+	}
+	New().InfoAdd("route", "/healthz").InfoAdd("status", 503).InfoAdd("duration_ms", time.Second).
+		ErrorAdd(errors.New("db down")).Flush(ctx)
+	New().InfoAdd("route", "/users").Flush(ctx)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected error entry and non-aggregated entry only, got %d lines: %s", len(lines), buf.String())
+	}
+
+	buf.Reset()
+	agg.Emit(ctx)
+	entry := decodeEntry(t, buf)
+
+	if entry["route"] != "/healthz" {
+		t.Errorf("Expected route=/healthz, got %v", entry["route"])
+	}
+	if entry["count"] != float64(5) {
+		t.Errorf("Expected count=5, got %v", entry["count"])
+	}
+	if entry["error_count"] != float64(1) {
+		t.Errorf("Expected error_count=1, got %v", entry["error_count"])
+	}
+	if entry["level"] != "ERROR" {
+		t.Errorf("Expected rollup at highest recorded level, got %v", entry["level"])
+	}
+	statuses, ok := entry["status_counts"].(map[string]any)
+	if !ok || statuses["200"] != float64(4) || statuses["503"] != float64(1) {
+		t.Errorf("Expected status_counts {200:4 503:1}, got %v", entry["status_counts"])
+	}
+	if entry["duration_p50"] != float64(30) {
+		t.Errorf("Expected duration_p50=30, got %v", entry["duration_p50"])
+	}
+	if entry["duration_max"] != float64(1000) {
+		t.Errorf("Expected duration_max=1000, got %v", entry["duration_max"])
+	}
+
+	buf.Reset()
+	agg.Emit(ctx)
+	if buf.Len() != 0 {
+		t.Error("Emit should reset rollups")
+	}
+}
+
+func TestAggregatorStartStop(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	agg := NewAggregator(AggregatorConfig{Interval: time.Hour, KeyField: "route", Keys: []string{"/metrics"}})
+	SetAggregator(agg)
+	t.Cleanup(func() { SetAggregator(nil) })
+
+	agg.Start()
+	New().InfoAdd("route", "/metrics").Flush(context.Background())
+	agg.Stop(context.Background())
+
+	if entry := decodeEntry(t, buf); entry["count"] != float64(1) {
+		t.Errorf("Expected final rollup with count=1 on Stop, got %v", entry["count"])
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		p    int
+		want float64
+	}{
+		{50, 5},
+		{95, 10},
+		{99, 10},
+		{10, 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// AddAttrs adds slog attributes as fields at level, for callers that already
+// hold slog.Attr values, such as those from slog-instrumented libraries.
+// Attributes are gated like the other Add methods, and a level of Warn or
+// above escalates the entry to that level. Group attributes are kept as
+// groups; groups with an empty key are inlined, and empty attributes are
+// ignored, as slog handlers do.
+func (l *Logger) AddAttrs(level slog.Level, attrs ...slog.Attr) *Logger {
+	if len(attrs) == 0 || l.gateLevel.Load() > level {
+		return l
+	}
+	l.mu.Lock()
+	l.addAttrsLocked(attrs)
+	if level >= slog.LevelWarn && l.level < level {
+		l.level = level
+	}
+	l.mu.Unlock()
+	return l
+}
+
+// addAttrsLocked stores attrs as fields. Must be called with l.mu held.
+func (l *Logger) addAttrsLocked(attrs []slog.Attr) {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		switch {
+		case v.Kind() == slog.KindGroup && a.Key == "":
+			l.addAttrsLocked(v.Group())
+		case a.Key == "":
+			// slog handlers ignore attributes without a key
+		case v.Kind() == slog.KindGroup:
+			l.setField(a.Key, v)
+		default:
+			l.setField(a.Key, v.Any())
+		}
+	}
+}
+
+// DebugAddAttrs adds slog attributes at debug level to the logger in context.
+// Panics if no logger exists in context.
+func DebugAddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	GetLogger(ctx).AddAttrs(slog.LevelDebug, attrs...)
+}
+
+// InfoAddAttrs adds slog attributes at info level to the logger in context.
+// Panics if no logger exists in context.
+func InfoAddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	GetLogger(ctx).AddAttrs(slog.LevelInfo, attrs...)
+}
+
+// WarnAddAttrs adds slog attributes at warn level to the logger in context,
+// escalating it to Warn. Panics if no logger exists in context.
+func WarnAddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	GetLogger(ctx).AddAttrs(slog.LevelWarn, attrs...)
+}
@@ -0,0 +1,70 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestAddAttrs(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	InfoAddAttrs(ctx,
+		slog.String("user_id", "123"),
+		slog.Int("status", 200),
+		slog.Duration("elapsed", time.Second),
+		slog.Group("db", slog.Int("rows", 3)),
+		slog.Group("", slog.Bool("inlined", true)),
+		slog.Attr{},
+	)
+	DebugAddAttrs(ctx, slog.String("gated", "x"))
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	if entry["user_id"] != "123" || entry["status"] != float64(200) {
+		t.Errorf("Expected attrs as fields, got %v", entry)
+	}
+	if entry["inlined"] != true {
+		t.Errorf("Expected empty-key group to be inlined, got %v", entry)
+	}
+	if db, ok := entry["db"].(map[string]any); !ok || db["rows"] != float64(3) {
+		t.Errorf("Expected db group, got %v", entry["db"])
+	}
+	if _, ok := entry["gated"]; ok {
+		t.Error("Expected debug attrs to be gated")
+	}
+	if entry["level"] != "INFO" {
+		t.Errorf("Expected INFO level, got %v", entry["level"])
+	}
+}
+
+func TestAddAttrsNativeValues(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	l.AddAttrs(slog.LevelInfo, slog.Int("n", 5), slog.Duration("d", time.Millisecond))
+
+	f := l.Fields()
+	if f["n"] != int64(5) {
+		t.Errorf("Expected int64 value, got %T %v", f["n"], f["n"])
+	}
+	if f["d"] != time.Millisecond {
+		t.Errorf("Expected time.Duration value, got %T %v", f["d"], f["d"])
+	}
+}
+
+func TestWarnAddAttrsEscalates(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	WarnAddAttrs(ctx, slog.Bool("degraded", true))
+	Flush(ctx)
+
+	if entry := decodeEntry(t, buf); entry["level"] != "WARN" {
+		t.Errorf("Expected WARN level, got %v", entry["level"])
+	}
+}
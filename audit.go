@@ -0,0 +1,109 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Fields recorded on every audit event.
+const (
+	auditActionKey = "audit_action"
+	auditSeqKey    = "audit_seq"
+	auditHashKey   = "audit_hash"
+)
+
+// auditLogger writes audit events, if configured with SetAuditHandler.
+var auditLogger atomic.Pointer[slog.Logger]
+
+// auditMu serializes audit events so sequence numbers, the hash chain, and
+// output order agree.
+var (
+	auditMu   sync.Mutex
+	auditSeq  uint64
+	auditPrev string
+)
+
+// SetAuditHandler configures where Audit writes events, for example a JSON
+// handler on a dedicated file. Passing nil restores the default, which is the
+// slog default logger.
+//
+// Example:
+//
+//	f, _ := os.OpenFile("audit.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+//	canonlog.SetAuditHandler(slog.NewJSONHandler(f, nil))
+func SetAuditHandler(h slog.Handler) {
+	if h == nil {
+		auditLogger.Store(nil)
+		return
+	}
+	auditLogger.Store(slog.New(h))
+}
+
+// Audit writes an audit event for action to the audit handler, separately from
+// the canonical line of the logger in ctx. Audit events bypass level gating,
+// debug sampling, rate limiting, aggregation, and key normalization, so every
+// event and each of its fields is written as given.
+//
+// Each event carries audit_action, the request_id of the logger in ctx if set,
+// the given fields, audit_seq, and audit_hash. audit_seq increases by one per
+// event within the process, so gaps reveal deleted events. audit_hash is the
+// hex SHA-256 of the previous event's hash followed by the JSON encoding of
+// this event's other fields, chaining events so edits are detectable.
+//
+// Example:
+//
+//	canonlog.Audit(ctx, "user.role_changed", map[string]any{
+//		"target_user": userID,
+//		"role":        "admin",
+//	})
+func Audit(ctx context.Context, action string, fields map[string]any) {
+	event := make(map[string]any, len(fields)+3)
+	maps.Copy(event, fields)
+	event[auditActionKey] = action
+	if l, ok := TryGetLogger(ctx); ok {
+		if id, ok := l.Fields()[requestIDKey]; ok {
+			event[requestIDKey] = id
+		}
+	}
+
+	logger := auditLogger.Load()
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	auditSeq++
+	event[auditSeqKey] = auditSeq
+	hash := auditHash(auditPrev, event)
+	auditPrev = hash
+
+	attrs := make([]slog.Attr, 0, len(event)+1)
+	for _, k := range slices.Sorted(maps.Keys(event)) {
+		attrs = append(attrs, slog.Any(k, event[k]))
+	}
+	attrs = append(attrs, slog.String(auditHashKey, hash))
+	logger.LogAttrs(ctx, slog.LevelInfo, "", attrs...)
+}
+
+// auditHash chains event onto the previous event's hash.
+func auditHash(prev string, event map[string]any) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	b, err := json.Marshal(event)
+	if err != nil {
+		// Values JSON can't encode still contribute to the chain
+		b = []byte(fmt.Sprint(event))
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
+}
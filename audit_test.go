@@ -0,0 +1,70 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	defer setTestLogLevel(slog.LevelError)()
+	appBuf := captureOutput(t)
+	var auditBuf bytes.Buffer
+	SetAuditHandler(slog.NewJSONHandler(&auditBuf, nil))
+	t.Cleanup(func() { SetAuditHandler(nil) })
+
+	ctx := NewContext(context.Background())
+	GetLogger(ctx).Persist("request_id", "req-1")
+
+	Audit(ctx, "user.role_changed", map[string]any{"role": "admin"})
+	Audit(ctx, "user.deleted", nil)
+
+	if appBuf.Len() != 0 {
+		t.Errorf("Audit events should not go to the application log, got %s", appBuf.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(auditBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 audit events, got %d", len(lines))
+	}
+
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first["audit_action"] != "user.role_changed" || first["role"] != "admin" {
+		t.Errorf("Expected action and fields, got %v", first)
+	}
+	if first["request_id"] != "req-1" {
+		t.Errorf("Expected request_id to be attached, got %v", first["request_id"])
+	}
+	if second["audit_seq"].(float64) != first["audit_seq"].(float64)+1 {
+		t.Errorf("Expected consecutive sequence numbers, got %v then %v", first["audit_seq"], second["audit_seq"])
+	}
+	if first["audit_hash"] == second["audit_hash"] {
+		t.Error("Expected distinct hashes")
+	}
+}
+
+func TestAuditHashChain(t *testing.T) {
+	event := map[string]any{"audit_action": "login", "audit_seq": uint64(1)}
+
+	h1 := auditHash("", event)
+	if h1 != auditHash("", event) {
+		t.Error("Expected hash to be deterministic")
+	}
+	if auditHash("other", event) == h1 {
+		t.Error("Expected hash to depend on the previous hash")
+	}
+	event["audit_action"] = "logout"
+	if auditHash("", event) == h1 {
+		t.Error("Expected hash to depend on event fields")
+	}
+}
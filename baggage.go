@@ -0,0 +1,170 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// baggageHeader is the W3C Baggage header used for propagation.
+const baggageHeader = "Baggage"
+
+// baggageKeys holds the field keys propagated to downstream services.
+var baggageKeys atomic.Pointer[[]string]
+
+// SetBaggageKeys configures which fields are propagated to downstream services,
+// for example "request_id", "tenant_id". Calling it again replaces the list;
+// calling it with no keys disables propagation.
+func SetBaggageKeys(keys ...string) {
+	keys = append([]string(nil), keys...)
+	baggageKeys.Store(&keys)
+}
+
+// Baggage returns the propagated fields recorded on the logger in ctx, keyed by
+// field name with values formatted as strings. It returns nil if ctx has no
+// logger or none of the configured keys are set.
+func Baggage(ctx context.Context) map[string]string {
+	return baggageFor(ctx, loadBaggageKeys())
+}
+
+// loadBaggageKeys returns the configured baggage keys, or nil if none are set.
+func loadBaggageKeys() []string {
+	if keys := baggageKeys.Load(); keys != nil {
+		return *keys
+	}
+	return nil
+}
+
+// baggageFor returns the values of keys recorded on the logger in ctx.
+func baggageFor(ctx context.Context, keys []string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return nil
+	}
+
+	fields := l.Fields()
+	var baggage map[string]string
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			if baggage == nil {
+				baggage = make(map[string]string, len(keys))
+			}
+			baggage[k] = fmt.Sprint(v)
+		}
+	}
+	return baggage
+}
+
+// InjectBaggage writes the propagated fields of the logger in ctx to the W3C
+// baggage header of h. Members already present in the header are left as is.
+func InjectBaggage(ctx context.Context, h http.Header) {
+	keys := loadBaggageKeys()
+	baggage := baggageFor(ctx, keys)
+	if len(baggage) == 0 {
+		return
+	}
+
+	existing := parseBaggage(h.Values(baggageHeader))
+	members := make([]string, 0, len(baggage))
+	for _, k := range keys {
+		v, ok := baggage[k]
+		if !ok {
+			continue
+		}
+		if _, exists := existing[k]; exists {
+			continue
+		}
+		members = append(members, k+"="+url.PathEscape(v))
+	}
+	if len(members) == 0 {
+		return
+	}
+	if current := strings.Join(h.Values(baggageHeader), ","); current != "" {
+		members = append([]string{current}, members...)
+	}
+	h.Set(baggageHeader, strings.Join(members, ","))
+}
+
+// ExtractBaggage reads the configured keys from the W3C baggage header of h and
+// records them as persistent fields on the logger in ctx, so an inbound request
+// carries the identity of the request that called it. Call it after NewContext
+// in the server's request handling. It does nothing if ctx has no logger.
+func ExtractBaggage(ctx context.Context, h http.Header) {
+	keys := loadBaggageKeys()
+	if len(keys) == 0 {
+		return
+	}
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return
+	}
+
+	baggage := parseBaggage(h.Values(baggageHeader))
+	for _, k := range keys {
+		if v, ok := baggage[k]; ok {
+			l.Persist(k, v)
+		}
+	}
+}
+
+// parseBaggage decodes W3C baggage header values into a map, ignoring
+// member properties and malformed members.
+func parseBaggage(values []string) map[string]string {
+	baggage := make(map[string]string)
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member, _, _ = strings.Cut(member, ";")
+			k, v, ok := strings.Cut(member, "=")
+			if !ok {
+				continue
+			}
+			k = strings.TrimSpace(k)
+			v, err := url.PathUnescape(strings.TrimSpace(v))
+			if k == "" || err != nil {
+				continue
+			}
+			baggage[k] = v
+		}
+	}
+	return baggage
+}
+
+// transport propagates canonical fields on outbound requests.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base so outbound requests carry the propagated fields of
+// the logger in the request's context in the W3C baggage header, and its trace
+// context, if any, in the traceparent and tracestate headers. If base is nil,
+// http.DefaultTransport is used.
+//
+// Example:
+//
+//	canonlog.SetBaggageKeys("request_id", "tenant_id")
+//	client := &http.Client{Transport: canonlog.NewTransport(nil)}
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	hasTrace := traceContextFrom(ctx) != nil && req.Header.Get(traceparentHeader) == ""
+	if hasTrace || len(Baggage(ctx)) > 0 {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(ctx)
+		InjectBaggage(ctx, req.Header)
+		InjectTraceContext(ctx, req.Header)
+	}
+	return t.base.RoundTrip(req)
+}
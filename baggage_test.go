@@ -0,0 +1,102 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setTestBaggageKeys configures baggage keys and clears them when the test ends.
+func setTestBaggageKeys(t *testing.T, keys ...string) {
+	t.Helper()
+	SetBaggageKeys(keys...)
+	t.Cleanup(func() { baggageKeys.Store(nil) })
+}
+
+func TestBaggage(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	setTestBaggageKeys(t, "request_id", "tenant_id")
+
+	ctx := NewContext(context.Background())
+	InfoAdd(ctx, "request_id", "abc")
+	InfoAdd(ctx, "user_id", 123)
+
+	baggage := Baggage(ctx)
+	if len(baggage) != 1 || baggage["request_id"] != "abc" {
+		t.Errorf("Expected only request_id in baggage, got %v", baggage)
+	}
+
+	if Baggage(context.Background()) != nil {
+		t.Error("Expected nil baggage without a logger")
+	}
+}
+
+func TestInjectExtractBaggage(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	setTestBaggageKeys(t, "request_id", "tenant_id")
+
+	ctx := NewContext(context.Background())
+	SetPersistent(ctx, "request_id", "abc")
+	InfoAdd(ctx, "tenant_id", "acme, inc")
+
+	h := http.Header{}
+	h.Set("Baggage", "tenant_id=other,flag=on")
+	InjectBaggage(ctx, h)
+
+	if got := h.Get("Baggage"); got != "tenant_id=other,flag=on,request_id=abc" {
+		t.Errorf("Unexpected baggage header %q", got)
+	}
+
+	h = http.Header{}
+	InjectBaggage(ctx, h)
+
+	inbound := NewContext(context.Background())
+	ExtractBaggage(inbound, h)
+
+	fields := GetLogger(inbound).Fields()
+	if fields["request_id"] != "abc" || fields["tenant_id"] != "acme, inc" {
+		t.Errorf("Expected propagated fields after round trip, got %v", fields)
+	}
+}
+
+func TestParseBaggage(t *testing.T) {
+	got := parseBaggage([]string{"a=1;prop=x, b = 2", "malformed,c=%20x", "d=%zz"})
+
+	if got["a"] != "1" || got["b"] != "2" || got["c"] != " x" {
+		t.Errorf("Unexpected parse result %v", got)
+	}
+	if _, exists := got["d"]; exists {
+		t.Error("Invalid escapes should be ignored")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	setTestBaggageKeys(t, "request_id")
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Baggage")
+	}))
+	defer srv.Close()
+
+	ctx := NewContext(context.Background())
+	InfoAdd(ctx, "request_id", "abc")
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if received != "request_id=abc" {
+		t.Errorf("Expected downstream to receive request_id, got %q", received)
+	}
+	if req.Header.Get("Baggage") != "" {
+		t.Error("Transport should not modify the caller's request")
+	}
+}
@@ -0,0 +1,208 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Reasons a sink drops entries, reported in DroppedEntries.Reason.
+const (
+	DropQueueFull  = "queue_full"  // the queue was full
+	DropClosed     = "closed"      // the sink was already closed
+	DropSendFailed = "send_failed" // a batch failed after all retries
+)
+
+// DroppedEntries reports entries a sink dropped, to its OnDrop callback and
+// DeadLetter writer.
+type DroppedEntries struct {
+	Sink   string // "loki", "fluent", or "kafka"
+	Reason string // DropQueueFull, DropClosed, or DropSendFailed
+	Count  int
+	Err    error // the last send error, for DropSendFailed
+}
+
+// dropReporter returns the function a sink's batcher reports drops to, which
+// calls onDrop and writes a JSON line to deadLetter, or nil if both are nil.
+func dropReporter(sink string, onDrop func(DroppedEntries), deadLetter io.Writer) func(DroppedEntries) {
+	if onDrop == nil && deadLetter == nil {
+		return nil
+	}
+	var dl *slog.Logger
+	if deadLetter != nil {
+		dl = slog.New(slog.NewJSONHandler(deadLetter, nil))
+	}
+	return func(d DroppedEntries) {
+		d.Sink = sink
+		if dl != nil {
+			attrs := []slog.Attr{
+				slog.String("sink", d.Sink),
+				slog.String("reason", d.Reason),
+				slog.Int("count", d.Count),
+			}
+			if d.Err != nil {
+				attrs = append(attrs, slog.String("error", d.Err.Error()))
+			}
+			dl.LogAttrs(context.Background(), slog.LevelWarn, "canonlog: entries dropped", attrs...)
+		}
+		if onDrop != nil {
+			onDrop(d)
+		}
+	}
+}
+
+// batcher queues items from any goroutine and hands them to flush in batches
+// from one background goroutine, for sinks that ship entries over the network.
+// Adding never blocks: items added while the queue is full or after close are
+// dropped and counted. Drops are passed to report, if set: those from a full
+// queue are summed and reported from the background goroutine after each
+// batch and every wait, so a burst of them costs one call.
+type batcher[T any] struct {
+	queue   chan T
+	size    int
+	wait    time.Duration
+	flush   func([]T)
+	stopped func()               // called once the last batch is flushed, may be nil
+	report  func(DroppedEntries) // may be nil
+	dropped atomic.Uint64
+	full    atomic.Uint64 // queue-full drops not yet reported
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newBatcher starts a batcher that flushes every size items or wait,
+// whichever comes first, and passes dropped items to report.
+func newBatcher[T any](size, queueSize int, wait time.Duration, flush func([]T), stopped func(), report func(DroppedEntries)) *batcher[T] {
+	b := &batcher[T]{
+		queue:   make(chan T, queueSize),
+		size:    size,
+		wait:    wait,
+		flush:   flush,
+		stopped: stopped,
+		report:  report,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues item, dropping it if the queue is full or the batcher closed.
+func (b *batcher[T]) add(item T) {
+	if b.closed.Load() {
+		b.drop(1, DropClosed, nil)
+		return
+	}
+	select {
+	case b.queue <- item:
+	default:
+		b.dropped.Add(1)
+		if b.report != nil {
+			b.full.Add(1)
+		}
+	}
+}
+
+// drop counts n dropped items and reports them.
+func (b *batcher[T]) drop(n int, reason string, err error) {
+	b.dropped.Add(uint64(n))
+	if b.report != nil {
+		b.report(DroppedEntries{Reason: reason, Count: n, Err: err})
+	}
+}
+
+// reportFull reports the queue-full drops since the last call.
+func (b *batcher[T]) reportFull() {
+	if b.report == nil {
+		return
+	}
+	if n := b.full.Swap(0); n > 0 {
+		b.report(DroppedEntries{Reason: DropQueueFull, Count: int(n)})
+	}
+}
+
+// close stops accepting items and waits until those queued are flushed or
+// ctx is done.
+func (b *batcher[T]) close(ctx context.Context) error {
+	if b.closed.CompareAndSwap(false, true) {
+		close(b.stop)
+	}
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *batcher[T]) run() {
+	defer close(b.done)
+	if b.stopped != nil {
+		defer b.stopped()
+	}
+	defer b.reportFull()
+	ticker := time.NewTicker(b.wait)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, b.size)
+	flush := func() {
+		if len(batch) > 0 {
+			b.flush(batch)
+			batch = batch[:0]
+			b.reportFull()
+		}
+	}
+	for {
+		select {
+		case item := <-b.queue:
+			batch = append(batch, item)
+			if len(batch) >= b.size {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			b.reportFull()
+		case <-b.stop:
+			for {
+				select {
+				case item := <-b.queue:
+					batch = append(batch, item)
+					if len(batch) >= b.size {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// permanentError marks a send failure that retrying will not fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// retry calls fn until it succeeds, returns a permanentError, or has been
+// retried maxRetries times, with exponential backoff from 100ms between
+// attempts. It returns fn's last error.
+func retry(maxRetries int, fn func() error) error {
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var perm *permanentError
+		if err == nil || errors.As(err, &perm) || attempt >= maxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,122 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcherFlushesOnSizeAndClose(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	var stopped bool
+	b := newBatcher(2, 10, time.Hour, func(batch []int) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, append([]int(nil), batch...))
+	}, func() { stopped = true }, nil)
+	for i := range 5 {
+		b.add(i)
+	}
+	if err := b.close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	b.add(5)
+
+	if len(batches) != 3 || len(batches[2]) != 1 || batches[2][0] != 4 {
+		t.Errorf("batches = %v, want [[0 1] [2 3] [4]]", batches)
+	}
+	if !stopped {
+		t.Error("stopped not called")
+	}
+	if b.dropped.Load() != 1 {
+		t.Errorf("dropped = %d, want 1", b.dropped.Load())
+	}
+}
+
+func TestRetryStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	err := retry(5, func() error {
+		calls++
+		return &permanentError{errors.New("rejected")}
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("retry = %v after %d calls, want error after 1", err, calls)
+	}
+
+	calls = 0
+	err = retry(1, func() error {
+		calls++
+		return errors.New("unavailable")
+	})
+	if err == nil || calls != 2 {
+		t.Errorf("retry = %v after %d calls, want error after 2", err, calls)
+	}
+}
+
+func TestBatcherReportsDrops(t *testing.T) {
+	var mu sync.Mutex
+	var drops []DroppedEntries
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	b := newBatcher(1, 1, time.Hour, func(batch []int) {
+		started <- struct{}{}
+		<-release
+	}, nil, func(d DroppedEntries) {
+		mu.Lock()
+		defer mu.Unlock()
+		drops = append(drops, d)
+	})
+	b.add(0)
+	<-started
+	// The first item is in flight and one fits in the queue
+	for i := range 4 {
+		b.add(i + 1)
+	}
+	mu.Lock()
+	if len(drops) != 0 {
+		t.Errorf("queue-full drops reported before the batch finished: %v", drops)
+	}
+	mu.Unlock()
+	close(release)
+	b.close(context.Background())
+	b.add(5)
+
+	want := []DroppedEntries{
+		{Reason: DropQueueFull, Count: 3},
+		{Reason: DropClosed, Count: 1},
+	}
+	if !slices.Equal(drops, want) {
+		t.Errorf("drops = %v, want %v", drops, want)
+	}
+	if b.dropped.Load() != 4 {
+		t.Errorf("dropped = %d, want 4", b.dropped.Load())
+	}
+}
+
+func TestDropReporterDeadLetter(t *testing.T) {
+	var buf bytes.Buffer
+	var got DroppedEntries
+	report := dropReporter("loki", func(d DroppedEntries) { got = d }, &buf)
+	report(DroppedEntries{Reason: DropSendFailed, Count: 2, Err: errors.New("unavailable")})
+
+	if got.Sink != "loki" || got.Count != 2 {
+		t.Errorf("OnDrop got %+v", got)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("dead letter line %q: %v", buf.String(), err)
+	}
+	if line["sink"] != "loki" || line["reason"] != DropSendFailed || line["count"] != 2.0 || line["error"] != "unavailable" {
+		t.Errorf("dead letter line = %v", line)
+	}
+
+	if dropReporter("loki", nil, nil) != nil {
+		t.Error("dropReporter without OnDrop or DeadLetter is not nil")
+	}
+}
@@ -3,6 +3,7 @@ package canonlog
 import (
 	"context"
 	"log/slog"
+	"strconv"
 	"testing"
 )
 
@@ -152,3 +153,44 @@ func BenchmarkFullRequestCycle(b *testing.B) {
 		Flush(ctx)
 	}
 }
+
+func BenchmarkFullRequestCycleReleased(b *testing.B) {
+	defer setBenchLogLevel(slog.LevelInfo)()
+
+	SetupGlobalLogger("info", "json")
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ctx := NewContext(context.Background())
+
+		InfoAdd(ctx, "request_id", "test-request-id")
+		InfoAdd(ctx, "method", "GET")
+		InfoAdd(ctx, "path", "/api/users")
+		InfoAddMany(ctx, map[string]any{
+			"user_id":       "123",
+			"status":        200,
+			"response_size": 1024,
+		})
+
+		ReleaseContext(ctx)
+	}
+}
+
+func BenchmarkLoggerFieldCapacity(b *testing.B) {
+	defer setBenchLogLevel(slog.LevelInfo)()
+
+	keys := make([]string, 48)
+	for i := range keys {
+		keys[i] = "field_" + strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		l := New(WithFieldCapacity(len(keys)))
+		for _, k := range keys {
+			l.InfoAdd(k, i)
+		}
+	}
+}
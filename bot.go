@@ -0,0 +1,92 @@
+package canonlog
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// botKey is the field bot traffic is tagged with.
+const botKey = "bot"
+
+// botUserAgentMarkers are lower-case User-Agent substrings of crawlers,
+// monitors, and scripted clients.
+var botUserAgentMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "headless", "monitor",
+	"facebookexternalhit", "curl/", "wget/", "python-requests", "python-urllib",
+	"go-http-client", "java/", "okhttp", "libwww", "httpclient", "axios/",
+}
+
+// BotConfig configures bot classification for ExtractBot.
+type BotConfig struct {
+	// Classify reports whether r comes from a bot. Defaults to
+	// IsBotUserAgent on the User-Agent header.
+	Classify func(r *http.Request) bool
+
+	// SampleRate is the fraction of bot entries emitted, from 0 to 1, so
+	// crawler traffic does not drown out people's requests. Entries at Error
+	// and above, security events, and loggers exempted with an identity
+	// Override are always emitted. Zero emits every entry.
+	SampleRate float64
+}
+
+// botConfig holds the configuration set by SetBotConfig.
+var botConfig atomic.Pointer[BotConfig]
+
+// SetBotConfig replaces the bot classification used by ExtractBot. Passing a
+// zero config restores the User-Agent heuristic without sampling.
+//
+// Example:
+//
+//	canonlog.SetBotConfig(canonlog.BotConfig{SampleRate: 0.05})
+func SetBotConfig(cfg BotConfig) {
+	botConfig.Store(&cfg)
+}
+
+// ExtractBot classifies r and, if it comes from a bot, records bot=true on the
+// logger in ctx and puts the logger in the bot sampling class, whose entries
+// are emitted at BotConfig.SampleRate. It reports whether r is bot traffic.
+//
+// Example:
+//
+//	ctx := canonlog.NewContext(r.Context())
+//	canonlog.ExtractBot(ctx, r)
+func ExtractBot(ctx context.Context, r *http.Request) bool {
+	var cfg BotConfig
+	if c := botConfig.Load(); c != nil {
+		cfg = *c
+	}
+	var bot bool
+	if cfg.Classify != nil {
+		bot = cfg.Classify(r)
+	} else {
+		bot = IsBotUserAgent(r.UserAgent())
+	}
+	if !bot {
+		return false
+	}
+	if l, ok := TryGetLogger(ctx); ok {
+		l.InfoAdd(botKey, true)
+		l.mu.Lock()
+		l.sampleRate = cfg.SampleRate
+		l.mu.Unlock()
+	}
+	return true
+}
+
+// IsBotUserAgent reports whether ua looks like a crawler, uptime monitor, or
+// scripted HTTP client. An empty User-Agent counts as a bot, since browsers
+// always send one.
+func IsBotUserAgent(ua string) bool {
+	if ua == "" {
+		return true
+	}
+	ua = strings.ToLower(ua)
+	for _, m := range botUserAgentMarkers {
+		if strings.Contains(ua, m) {
+			return true
+		}
+	}
+	return false
+}
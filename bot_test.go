@@ -0,0 +1,102 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setBotConfig(t *testing.T, cfg BotConfig) {
+	t.Helper()
+	SetBotConfig(cfg)
+	t.Cleanup(func() { SetBotConfig(BotConfig{}) })
+}
+
+func TestIsBotUserAgent(t *testing.T) {
+	tests := map[string]bool{
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": true,
+		"Mozilla/5.0 (compatible; bingbot/2.0)":                                    true,
+		"curl/8.4.0":                                                               true,
+		"python-requests/2.31.0":                                                   true,
+		"Go-http-client/1.1":                                                       true,
+		"":                                                                         true,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) AppleWebKit/605.1.15 Version/17.1 Safari/605": false,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/119.0 Safari/537.36":   false,
+	}
+	for ua, want := range tests {
+		if got := IsBotUserAgent(ua); got != want {
+			t.Errorf("IsBotUserAgent(%q) = %v, want %v", ua, got, want)
+		}
+	}
+}
+
+func TestExtractBot(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", "Googlebot/2.1")
+	if !ExtractBot(ctx, r) {
+		t.Fatal("Expected Googlebot classified as bot")
+	}
+	GetLogger(ctx).Flush(ctx)
+	if entry := decodeEntry(t, buf); entry[botKey] != true {
+		t.Errorf("Expected bot=true, got %v", entry)
+	}
+
+	buf.Reset()
+	ctx = NewContext(context.Background())
+	r.Header.Set("User-Agent", "Mozilla/5.0 Firefox/120.0")
+	if ExtractBot(ctx, r) {
+		t.Error("Expected Firefox not classified as bot")
+	}
+	GetLogger(ctx).InfoAdd("k", "v")
+	GetLogger(ctx).Flush(ctx)
+	if entry := decodeEntry(t, buf); entry[botKey] != nil {
+		t.Errorf("Expected no bot field, got %v", entry)
+	}
+}
+
+func TestExtractBotSampling(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setBotConfig(t, BotConfig{
+		Classify:   func(r *http.Request) bool { return r.Header.Get("X-Bot") != "" },
+		SampleRate: 0.000001,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", "curl/8.4.0")
+	before := Stats().SampledOut
+
+	// Humans are always logged, even with a bot User-Agent once Classify is set
+	ctx := NewContext(context.Background())
+	ExtractBot(ctx, r)
+	GetLogger(ctx).InfoAdd("human", true)
+	GetLogger(ctx).Flush(ctx)
+
+	// Bots are sampled out, unless the entry is an error
+	r.Header.Set("X-Bot", "1")
+	for range 20 {
+		ctx := NewContext(context.Background())
+		ExtractBot(ctx, r)
+		GetLogger(ctx).Flush(ctx)
+	}
+	ctx = NewContext(context.Background())
+	ExtractBot(ctx, r)
+	GetLogger(ctx).ErrorAdd(errors.New("boom"))
+	GetLogger(ctx).Flush(ctx)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"human":true`) || !strings.Contains(lines[1], "boom") {
+		t.Errorf("Expected the human and error lines only, got %q", buf.String())
+	}
+	if got := Stats().SampledOut - before; got != 20 {
+		t.Errorf("Expected 20 sampled out, got %d", got)
+	}
+}
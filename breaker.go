@@ -0,0 +1,39 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RecordBreaker records a call guarded by the circuit breaker called name on
+// the logger in ctx, with the breaker's state after the call, such as
+// "closed", "half-open", or "open", and whether the breaker short-circuited
+// the call without running it. It records breaker_<name>_state, the last
+// state seen; breaker_<name>_rejected, the number of short-circuited calls;
+// and breaker_<name>_transitions, each change of state seen during the
+// request, such as "closed->open". It works with any breaker library, such
+// as gobreaker or hystrix-go, and does nothing if ctx has no logger.
+//
+// Example:
+//
+//	_, err := cb.Execute(func() (any, error) { return nil, inventory.Reserve(ctx, item) })
+//	rejected := errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+//	canonlog.RecordBreaker(ctx, cb.Name(), cb.State().String(), rejected)
+func RecordBreaker(ctx context.Context, name, state string, rejected bool) {
+	l, ok := TryGetLogger(ctx)
+	if !ok || l.gateLevel.Load() > slog.LevelInfo {
+		return
+	}
+	prefix := "breaker_" + name
+	l.mu.Lock()
+	prev, seen := l.fields[prefix+"_state"].(string)
+	l.storeField(prefix+"_state", state)
+	if rejected {
+		n, _ := l.fields[prefix+"_rejected"].(int)
+		l.storeField(prefix+"_rejected", n+1)
+	}
+	l.mu.Unlock()
+	if seen && prev != state {
+		l.Append(prefix+"_transitions", prev+"->"+state)
+	}
+}
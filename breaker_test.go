@@ -0,0 +1,36 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestRecordBreaker(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	RecordBreaker(ctx, "inventory", "closed", false)
+	RecordBreaker(ctx, "inventory", "open", false)
+	RecordBreaker(ctx, "inventory", "open", true)
+	RecordBreaker(ctx, "inventory", "open", true)
+	RecordBreaker(ctx, "inventory", "half-open", false)
+	RecordBreaker(ctx, "search", "closed", false)
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	if entry["breaker_inventory_state"] != "half-open" || entry["breaker_inventory_rejected"] != float64(2) {
+		t.Errorf("Expected state=half-open rejected=2, got %v", entry)
+	}
+	transitions, _ := entry["breaker_inventory_transitions"].([]any)
+	if len(transitions) != 2 || transitions[0] != "closed->open" || transitions[1] != "open->half-open" {
+		t.Errorf("Expected two transitions, got %v", entry["breaker_inventory_transitions"])
+	}
+	if entry["breaker_search_state"] != "closed" || entry["breaker_search_rejected"] != nil || entry["breaker_search_transitions"] != nil {
+		t.Errorf("Expected only the state for an untroubled breaker, got %v", entry)
+	}
+
+	// Without a logger it is a no-op
+	RecordBreaker(context.Background(), "inventory", "open", true)
+}
@@ -0,0 +1,70 @@
+package canonlog
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// deployEnvPrefix marks environment variables recorded by WithBuildInfo.
+const deployEnvPrefix = "DEPLOY_"
+
+// WithBuildInfo adds build and deployment metadata to every line the logger
+// emits, so each canonical line is attributable to an exact build:
+//
+//   - build_version, the main module version
+//   - build_revision, build_time, and build_dirty, from the VCS stamp
+//   - go_version
+//   - every DEPLOY_* environment variable, lowercased, for example
+//     DEPLOY_ENV=prod as deploy_env=prod
+//
+// The metadata is read once per process. Fields recorded on the logger under
+// the same keys take precedence, and a logger with nothing else to log still
+// emits nothing.
+func WithBuildInfo() Option {
+	return func(l *Logger) {
+		l.buildInfo = true
+	}
+}
+
+// buildInfoFields returns the metadata recorded by WithBuildInfo.
+var buildInfoFields = sync.OnceValue(readBuildInfo)
+
+// readBuildInfo collects build metadata and DEPLOY_* environment variables.
+func readBuildInfo() map[string]any {
+	fields := map[string]any{"go_version": runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			fields["build_version"] = v
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				fields["build_revision"] = s.Value
+			case "vcs.time":
+				fields["build_time"] = s.Value
+			case "vcs.modified":
+				fields["build_dirty"] = s.Value == "true"
+			}
+		}
+	}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, deployEnvPrefix) && len(k) > len(deployEnvPrefix) && v != "" {
+			fields[strings.ToLower(k)] = v
+		}
+	}
+	return fields
+}
+
+// addBuildInfoFields writes the build metadata to fields without replacing
+// fields already present.
+func addBuildInfoFields(fields map[string]any) {
+	for k, v := range buildInfoFields() {
+		if _, exists := fields[k]; !exists {
+			fields[k] = v
+		}
+	}
+}
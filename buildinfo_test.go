@@ -0,0 +1,52 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+func TestWithBuildInfo(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithBuildInfo())
+	l.Flush(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output from a logger with only build info, got %s", buf.String())
+	}
+
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if entry["go_version"] != runtime.Version() {
+		t.Errorf("Expected go_version=%s, got %v", runtime.Version(), entry["go_version"])
+	}
+
+	buf.Reset()
+	l.InfoAdd("go_version", "override")
+	l.Flush(context.Background())
+	entry = decodeEntry(t, buf)
+	if entry["go_version"] != "override" {
+		t.Errorf("Expected logger fields to take precedence, got %v", entry["go_version"])
+	}
+}
+
+func TestReadBuildInfoDeployEnv(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "prod")
+	t.Setenv("DEPLOY_REGION", "eu-west-1")
+	t.Setenv("DEPLOY_", "ignored")
+	t.Setenv("DEPLOYMENT", "ignored")
+
+	fields := readBuildInfo()
+	if fields["deploy_env"] != "prod" || fields["deploy_region"] != "eu-west-1" {
+		t.Errorf("Expected deploy_env and deploy_region, got %v", fields)
+	}
+	if _, ok := fields["deploy_"]; ok {
+		t.Error("Expected a bare DEPLOY_ variable to be ignored")
+	}
+	if _, ok := fields["deployment"]; ok {
+		t.Error("Expected DEPLOYMENT to be ignored")
+	}
+}
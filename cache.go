@@ -0,0 +1,78 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Fields recorded by CacheGet and CacheSet.
+const (
+	cacheHitsKey     = "cache_hits"
+	cacheMissesKey   = "cache_misses"
+	cacheSetsKey     = "cache_sets"
+	cacheHitRatioKey = "cache_hit_ratio"
+	cacheTimeKey     = "cache_time_ms"
+)
+
+// CacheGet records one cache lookup at info level: cache_hits or cache_misses
+// is incremented, cache_hit_ratio updated, and elapsed added to
+// cache_time_ms, in fractional milliseconds. Call it after each lookup in a
+// wrapper around the cache client, such as memcache or groupcache.
+//
+// Example:
+//
+//	start := time.Now()
+//	item, err := mc.Get(key)
+//	log.CacheGet(err == nil, time.Since(start))
+func (l *Logger) CacheGet(hit bool, elapsed time.Duration) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hits, _ := l.fields[cacheHitsKey].(int)
+	misses, _ := l.fields[cacheMissesKey].(int)
+	if hit {
+		hits++
+		l.storeField(cacheHitsKey, hits)
+	} else {
+		misses++
+		l.storeField(cacheMissesKey, misses)
+	}
+	l.storeField(cacheHitRatioKey, float64(hits)/float64(hits+misses))
+	l.addCacheTimeLocked(elapsed)
+	return l
+}
+
+// CacheSet records one cache write at info level: cache_sets is incremented
+// and elapsed added to cache_time_ms.
+func (l *Logger) CacheSet(elapsed time.Duration) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sets, _ := l.fields[cacheSetsKey].(int)
+	l.storeField(cacheSetsKey, sets+1)
+	l.addCacheTimeLocked(elapsed)
+	return l
+}
+
+// addCacheTimeLocked adds elapsed to cache_time_ms. Must be called with l.mu held.
+func (l *Logger) addCacheTimeLocked(elapsed time.Duration) {
+	total, _ := l.fields[cacheTimeKey].(float64)
+	l.storeField(cacheTimeKey, total+float64(elapsed)/float64(time.Millisecond))
+}
+
+// CacheGet records a cache lookup in the logger in context.
+// Panics if no logger exists in context.
+func CacheGet(ctx context.Context, hit bool, elapsed time.Duration) {
+	GetLogger(ctx).CacheGet(hit, elapsed)
+}
+
+// CacheSet records a cache write in the logger in context.
+// Panics if no logger exists in context.
+func CacheSet(ctx context.Context, elapsed time.Duration) {
+	GetLogger(ctx).CacheSet(elapsed)
+}
@@ -0,0 +1,56 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestCacheGetSet(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	CacheGet(ctx, true, 2*time.Millisecond)
+	CacheGet(ctx, false, 500*time.Microsecond)
+	CacheGet(ctx, true, time.Millisecond)
+	CacheSet(ctx, 1500*time.Microsecond)
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	want := map[string]any{
+		cacheHitsKey:   float64(2),
+		cacheMissesKey: float64(1),
+		cacheSetsKey:   float64(1),
+		cacheTimeKey:   float64(5),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+	if ratio, _ := entry[cacheHitRatioKey].(float64); ratio < 0.66 || ratio > 0.67 {
+		t.Errorf("Expected cache_hit_ratio 2/3, got %v", entry[cacheHitRatioKey])
+	}
+
+	// Counts start over after Flush
+	buf.Reset()
+	CacheGet(ctx, false, 0)
+	GetLogger(ctx).Flush(ctx)
+	entry = decodeEntry(t, buf)
+	if entry[cacheMissesKey] != float64(1) || entry[cacheHitRatioKey] != float64(0) || entry[cacheHitsKey] != nil {
+		t.Errorf("Expected a fresh miss count, got %v", entry)
+	}
+}
+
+func TestCacheGetGated(t *testing.T) {
+	defer setTestLogLevel(slog.LevelWarn)()
+	captureOutput(t)
+
+	l := New()
+	l.CacheGet(true, time.Millisecond).CacheSet(time.Millisecond)
+	if l.Len() != 0 {
+		t.Errorf("Expected no fields below the gate level, got %d", l.Len())
+	}
+}
@@ -0,0 +1,168 @@
+// Package canonlogtest provides helpers for testing code that logs with canonlog.
+package canonlogtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nhalm/canonlog"
+)
+
+// updateFlag rewrites golden files instead of comparing against them. It is
+// namespaced so it cannot clash with an -update flag of the importing package.
+var updateFlag = flag.Bool("canonlogtest.update", false, "update canonlogtest golden files")
+
+// updating reports whether golden files should be rewritten, set by
+// -canonlogtest.update or CANONLOGTEST_UPDATE=1.
+func updating() bool {
+	return *updateFlag || os.Getenv("CANONLOGTEST_UPDATE") == "1"
+}
+
+// scrubbed replaces values that vary between runs.
+const scrubbed = "<scrubbed>"
+
+// captureKeyType marks the context of a SnapshotFlush call.
+type captureKeyType struct{}
+
+// capture receives the entry flushed by one SnapshotFlush call.
+type capture struct {
+	entry *canonlog.Entry
+}
+
+// installHook registers the flush hook that routes entries to SnapshotFlush.
+var installHook = sync.OnceFunc(func() {
+	canonlog.AddFlushHook(func(ctx context.Context, e canonlog.Entry) {
+		if c, ok := ctx.Value(captureKeyType{}).(*capture); ok {
+			c.entry = &e
+		}
+	})
+})
+
+// SnapshotFlush flushes the logger in ctx and compares the entry against the
+// golden file testdata/<test name>.golden. The entry is written in a
+// deterministic form: JSON with sorted keys, holding the level, fields, error
+// and warning messages, and security events. Values that vary between runs are
+// replaced with "<scrubbed>": time.Time and time.Duration values, fields whose
+// keys end in _ms, _ns, _at, _time, or duration, and fields named in scrub.
+//
+// Run the tests with -canonlogtest.update, or with CANONLOGTEST_UPDATE=1 in the
+// environment, to write the golden files. The environment variable also works
+// with go test ./..., where packages that do not import canonlogtest would
+// reject the flag.
+//
+// Example:
+//
+//	func TestCheckout(t *testing.T) {
+//		ctx := canonlog.NewContext(context.Background())
+//		handleCheckout(ctx, order)
+//		canonlogtest.SnapshotFlush(t, ctx, "order_id")
+//	}
+func SnapshotFlush(t testing.TB, ctx context.Context, scrub ...string) {
+	t.Helper()
+	installHook()
+
+	c := &capture{}
+	canonlog.GetLogger(ctx).Flush(context.WithValue(ctx, captureKeyType{}, c))
+	if c.entry == nil {
+		t.Fatal("canonlogtest: Flush emitted no entry")
+	}
+
+	got, err := normalize(*c.entry, scrub)
+	if err != nil {
+		t.Fatalf("canonlogtest: %v", err)
+	}
+
+	path := filepath.Join("testdata", strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("canonlogtest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("canonlogtest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("canonlogtest: %v (run with -canonlogtest.update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("canonlogtest: entry does not match %s (run with -canonlogtest.update to accept)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// normalize renders e in the deterministic golden file form.
+func normalize(e canonlog.Entry, scrub []string) ([]byte, error) {
+	fields := make(map[string]any, len(e.Fields))
+	for k, v := range e.Fields {
+		fields[k] = normalizeValue(k, v, scrub)
+	}
+	out := map[string]any{
+		"level":  e.Level.String(),
+		"fields": fields,
+	}
+	if len(e.Errors) > 0 {
+		out["errors"] = messages(e.Errors)
+	}
+	if len(e.Warnings) > 0 {
+		out["warnings"] = messages(e.Warnings)
+	}
+	if len(e.Security) > 0 {
+		out["security"] = e.Security
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("encoding entry: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeValue scrubs a field value that varies between runs and converts
+// values encoding/json cannot represent faithfully.
+func normalizeValue(key string, v any, scrub []string) any {
+	for _, k := range scrub {
+		if k == key {
+			return scrubbed
+		}
+	}
+	for _, suffix := range []string{"_ms", "_ns", "_at", "_time", "duration"} {
+		if strings.HasSuffix(key, suffix) {
+			return scrubbed
+		}
+	}
+	switch v := v.(type) {
+	case time.Time, time.Duration:
+		return scrubbed
+	case slog.Value:
+		return v.String()
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// messages returns the messages of errs.
+func messages(errs []error) []string {
+	out := make([]string, len(errs))
+	for i, err := range errs {
+		out[i] = err.Error()
+	}
+	return out
+}
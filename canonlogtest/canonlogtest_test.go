@@ -0,0 +1,108 @@
+package canonlogtest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nhalm/canonlog"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+func TestSnapshotFlush(t *testing.T) {
+	ctx := canonlog.NewContext(context.Background())
+	canonlog.InfoAdd(ctx, "route", "/checkout")
+	canonlog.InfoAdd(ctx, "status", 402)
+	canonlog.InfoAdd(ctx, "duration_ms", 37)
+	canonlog.InfoAdd(ctx, "started", time.Now())
+	canonlog.InfoAdd(ctx, "order_id", "ord_8f2a")
+	canonlog.ErrorAdd(ctx, errors.New("card declined"))
+
+	SnapshotFlush(t, ctx, "order_id")
+}
+
+func TestNormalize(t *testing.T) {
+	e := canonlog.Entry{
+		Level: slog.LevelWarn,
+		Fields: map[string]any{
+			"b":          2,
+			"a":          time.Second,
+			"created_at": "2024-01-01",
+			"id":         "x",
+		},
+		Warnings: []error{errors.New("slow")},
+	}
+	got, err := normalize(e, []string{"id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "fields": {
+    "a": "<scrubbed>",
+    "b": 2,
+    "created_at": "<scrubbed>",
+    "id": "<scrubbed>"
+  },
+  "level": "WARN",
+  "warnings": [
+    "slow"
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// An importing package's own -update flag must not clash with canonlogtest's;
+// registering it panics at init if it does.
+var _ = flag.Bool("update", false, "update this package's golden files")
+
+func TestSnapshotFlushMismatch(t *testing.T) {
+	if updating() {
+		t.Skip("updating golden files would accept the mismatch")
+	}
+	// The golden file lives in a temporary directory, so update runs of the
+	// other tests never rewrite it to match
+	t.Chdir(t.TempDir())
+	golden := "{\n  \"fields\": {\n    \"status\": 200\n  },\n  \"level\": \"INFO\"\n}\n"
+	if err := os.MkdirAll("testdata", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("testdata", "TestSnapshotFlushMismatch.golden"), []byte(golden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := canonlog.NewContext(context.Background())
+	canonlog.InfoAdd(ctx, "status", 500)
+
+	rec := &recorder{TB: t}
+	SnapshotFlush(rec, ctx)
+	if !rec.failed || !strings.Contains(rec.msg, filepath.Join("testdata", "TestSnapshotFlushMismatch.golden")) {
+		t.Errorf("Expected a mismatch against the golden file, got failed=%v msg=%q", rec.failed, rec.msg)
+	}
+}
+
+// recorder captures failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.msg = fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,120 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// claimsKey is the group auth claims are recorded under.
+const claimsKey = "auth"
+
+// defaultClaims are the claims recorded when ClaimsConfig.Claims is not set.
+var defaultClaims = []string{"sub", "scope", "client_id", "iss"}
+
+// defaultRedactedClaims are the claims redacted when ClaimsConfig.Redact is
+// not set: the standard OpenID Connect claims that carry personal data.
+var defaultRedactedClaims = []string{"email", "phone_number", "name", "given_name", "family_name", "address"}
+
+// ClaimsConfig configures ExtractClaims.
+type ClaimsConfig struct {
+	// Extractor returns the verified claims of the request, or nil if it is
+	// unauthenticated. It is typically a thin wrapper around the JWT or
+	// session library's context accessor. ExtractClaims does nothing without it.
+	Extractor func(r *http.Request) map[string]any
+
+	// Claims lists the claims to record. Defaults to sub, scope, client_id,
+	// and iss.
+	Claims []string
+
+	// Redact lists claims that are recorded with their value replaced by
+	// "[REDACTED]", so their presence is visible without the value. Defaults to
+	// email, phone_number, name, given_name, family_name, and address.
+	Redact []string
+}
+
+// claimsConfig holds the configuration set by SetClaimsConfig.
+var claimsConfig atomic.Pointer[ClaimsConfig]
+
+// SetClaimsConfig configures how ExtractClaims finds and records auth claims.
+//
+// Example:
+//
+//	canonlog.SetClaimsConfig(canonlog.ClaimsConfig{
+//		Extractor: func(r *http.Request) map[string]any {
+//			token, ok := jwtauth.FromContext(r.Context())
+//			if !ok {
+//				return nil
+//			}
+//			return token.PrivateClaims()
+//		},
+//		Claims: []string{"sub", "scope", "client_id", "iss", "email"},
+//	})
+//	// records auth.sub=u_123 auth.scope="read write" auth.email=[REDACTED] ...
+func SetClaimsConfig(cfg ClaimsConfig) {
+	claimsConfig.Store(&cfg)
+}
+
+// ExtractClaims records the selected claims of r's authenticated principal as
+// an "auth" group on the logger in ctx, so auth context is logged the same way
+// across services. List claims are joined with spaces, as in an OAuth scope.
+// Nothing is recorded if no extractor is configured, the request carries no
+// claims, or none of the selected claims are present.
+func ExtractClaims(ctx context.Context, r *http.Request) {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return
+	}
+	cfg := claimsConfig.Load()
+	if cfg == nil || cfg.Extractor == nil {
+		return
+	}
+	claims := cfg.Extractor(r)
+	if len(claims) == 0 {
+		return
+	}
+	selected := cfg.Claims
+	if selected == nil {
+		selected = defaultClaims
+	}
+	redact := cfg.Redact
+	if redact == nil {
+		redact = defaultRedactedClaims
+	}
+
+	attrs := make([]slog.Attr, 0, len(selected))
+	for _, name := range selected {
+		v, ok := claims[name]
+		if !ok {
+			continue
+		}
+		if slices.Contains(redact, name) {
+			attrs = append(attrs, slog.String(name, redactedMark))
+			continue
+		}
+		attrs = append(attrs, slog.Any(name, claimValue(v)))
+	}
+	if len(attrs) > 0 {
+		l.InfoAdd(claimsKey, slog.GroupValue(attrs...))
+	}
+}
+
+// claimValue flattens list claims, such as scopes and audiences, into a
+// space-separated string.
+func claimValue(v any) any {
+	switch v := v.(type) {
+	case []string:
+		return strings.Join(v, " ")
+	case []any:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = fmt.Sprint(p)
+		}
+		return strings.Join(parts, " ")
+	}
+	return v
+}
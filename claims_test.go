@@ -0,0 +1,69 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setClaimsConfig(t *testing.T, cfg ClaimsConfig) {
+	t.Helper()
+	SetClaimsConfig(cfg)
+	t.Cleanup(func() { claimsConfig.Store(nil) })
+}
+
+func TestExtractClaims(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setClaimsConfig(t, ClaimsConfig{
+		Extractor: func(r *http.Request) map[string]any {
+			return map[string]any{
+				"sub":   "u_123",
+				"scope": []any{"read", "write"},
+				"iss":   "https://auth.example.com",
+				"email": "jane@example.com",
+				"aud":   "api",
+			}
+		},
+		Claims: []string{"sub", "scope", "client_id", "iss", "email"},
+	})
+
+	ctx := NewContext(context.Background())
+	ExtractClaims(ctx, httptest.NewRequest("GET", "/", nil))
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	auth, _ := entry["auth"].(map[string]any)
+	want := map[string]any{
+		"sub":   "u_123",
+		"scope": "read write",
+		"iss":   "https://auth.example.com",
+		"email": "[REDACTED]",
+	}
+	if len(auth) != len(want) {
+		t.Fatalf("Expected auth group %v, got %v", want, entry["auth"])
+	}
+	for k, v := range want {
+		if auth[k] != v {
+			t.Errorf("Expected auth.%s=%v, got %v", k, v, auth[k])
+		}
+	}
+}
+
+func TestExtractClaimsUnauthenticated(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	ExtractClaims(ctx, httptest.NewRequest("GET", "/", nil))
+	if GetLogger(ctx).HasField("auth") {
+		t.Error("Expected no claims without an extractor")
+	}
+
+	setClaimsConfig(t, ClaimsConfig{Extractor: func(*http.Request) map[string]any { return nil }})
+	ExtractClaims(ctx, httptest.NewRequest("GET", "/", nil))
+	if GetLogger(ctx).HasField("auth") {
+		t.Error("Expected no claims for an unauthenticated request")
+	}
+}
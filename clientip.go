@@ -0,0 +1,129 @@
+package canonlog
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+// remoteIPKey is the field the client IP is recorded under.
+const remoteIPKey = "remote_ip"
+
+// ClientIPConfig configures ExtractClientIP.
+type ClientIPConfig struct {
+	// TrustedProxies lists the networks of proxies and load balancers allowed
+	// to report the client address in forwarding headers. Headers are ignored
+	// for requests whose immediate peer is not in one of these networks.
+	TrustedProxies []netip.Prefix
+
+	// Headers lists the forwarding headers to consult, in priority order.
+	// Defaults to X-Forwarded-For, then X-Real-IP. Other useful values are
+	// CF-Connecting-IP and True-Client-IP.
+	Headers []string
+}
+
+// clientIPConfig holds the configuration set by SetClientIPConfig.
+var clientIPConfig atomic.Pointer[ClientIPConfig]
+
+// SetClientIPConfig configures how ExtractClientIP resolves the client address.
+//
+// Example:
+//
+//	canonlog.SetClientIPConfig(canonlog.ClientIPConfig{
+//		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//		Headers:        []string{"CF-Connecting-IP", "X-Forwarded-For"},
+//	})
+func SetClientIPConfig(cfg ClientIPConfig) {
+	clientIPConfig.Store(&cfg)
+}
+
+// ExtractClientIP resolves the client address of r, records it as the remote_ip
+// field on the logger in ctx, and returns it. Forwarding headers are honored only
+// when the immediate peer is a trusted proxy; otherwise the peer address from
+// r.RemoteAddr is used. For X-Forwarded-For, the rightmost address that is not a
+// trusted proxy is taken, since entries to its left can be forged by the client.
+func ExtractClientIP(ctx context.Context, r *http.Request) string {
+	ip := clientIP(r)
+	if l, ok := TryGetLogger(ctx); ok && ip != "" {
+		l.InfoAdd(remoteIPKey, ip)
+	}
+	return ip
+}
+
+// clientIP resolves the client address of r using the configured proxies.
+func clientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	cfg := clientIPConfig.Load()
+	if cfg == nil || len(cfg.TrustedProxies) == 0 {
+		return peer
+	}
+	peerAddr, err := netip.ParseAddr(peer)
+	if err != nil || !cfg.trusted(peerAddr) {
+		return peer
+	}
+
+	headers := cfg.Headers
+	if len(headers) == 0 {
+		headers = []string{"X-Forwarded-For", "X-Real-IP"}
+	}
+	for _, name := range headers {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if http.CanonicalHeaderKey(name) == "X-Forwarded-For" {
+			if ip, ok := cfg.forwardedFor(values); ok {
+				return ip
+			}
+			continue
+		}
+		if addr, err := netip.ParseAddr(strings.TrimSpace(values[0])); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return peer
+}
+
+// forwardedFor returns the rightmost untrusted address in X-Forwarded-For, or
+// the leftmost valid address if every hop is trusted.
+func (cfg *ClientIPConfig) forwardedFor(values []string) (string, bool) {
+	var hops []netip.Addr
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			addr, err := netip.ParseAddr(strings.TrimSpace(part))
+			if err != nil {
+				// A malformed hop means nothing to its left can be trusted
+				hops = hops[:0]
+				continue
+			}
+			hops = append(hops, addr.Unmap())
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !cfg.trusted(hops[i]) {
+			return hops[i].String(), true
+		}
+	}
+	if len(hops) > 0 {
+		return hops[0].String(), true
+	}
+	return "", false
+}
+
+// trusted reports whether addr belongs to a trusted proxy network.
+func (cfg *ClientIPConfig) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range cfg.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestExtractClientIP(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	t.Cleanup(func() { clientIPConfig.Store(nil) })
+
+	SetClientIPConfig(ClientIPConfig{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.7:1234", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "203.0.113.7"},
+		{"trusted peer uses forwarded for", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.2"}, "198.51.100.2"},
+		{"rightmost untrusted hop wins", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.2, 10.0.0.5"}, "198.51.100.2"},
+		{"all hops trusted", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.5"}, "10.1.1.1"},
+		{"falls back to x-real-ip", "10.0.0.1:1234", map[string]string{"X-Real-IP": "198.51.100.9"}, "198.51.100.9"},
+		{"invalid header uses peer", "10.0.0.1:1234", map[string]string{"X-Real-IP": "not-an-ip"}, "10.0.0.1"},
+		{"mapped ipv4", "[::ffff:10.0.0.1]:1234", map[string]string{"X-Forwarded-For": "198.51.100.2"}, "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			ctx := NewContext(context.Background())
+			if got := ExtractClientIP(ctx, r); got != tt.want {
+				t.Errorf("ExtractClientIP = %q, want %q", got, tt.want)
+			}
+			if GetLogger(ctx).Fields()[remoteIPKey] != tt.want {
+				t.Errorf("Expected remote_ip=%s on logger", tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractClientIPHeaderPriority(t *testing.T) {
+	t.Cleanup(func() { clientIPConfig.Store(nil) })
+
+	SetClientIPConfig(ClientIPConfig{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Headers:        []string{"CF-Connecting-IP", "X-Forwarded-For"},
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.2")
+	r.Header.Set("CF-Connecting-IP", "2001:db8::1")
+
+	if got := ExtractClientIP(context.Background(), r); got != "2001:db8::1" {
+		t.Errorf("Expected CF-Connecting-IP to take priority, got %q", got)
+	}
+}
+
+func TestExtractClientIPUnconfigured(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.2")
+
+	if got := ExtractClientIP(context.Background(), r); got != "10.0.0.1" {
+		t.Errorf("Expected peer address without trusted proxies, got %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package canonlog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock supplies the current time for durations that canonlog measures.
+// Tests and simulations can substitute a fake or virtual clock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                  { return time.Now() }
+func (systemClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// clockBox lets an interface be stored in an atomic.Pointer.
+type clockBox struct{ Clock }
+
+// globalClock holds the clock set by SetClock.
+var globalClock atomic.Pointer[clockBox]
+
+// SetClock sets the clock used by loggers without WithClock and by Cron.
+// Passing nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		globalClock.Store(nil)
+		return
+	}
+	globalClock.Store(&clockBox{c})
+}
+
+// defaultClock returns the clock set by SetClock, or the system clock.
+func defaultClock() Clock {
+	if b := globalClock.Load(); b != nil {
+		return b.Clock
+	}
+	return systemClock{}
+}
+
+// WithClock sets the clock the logger uses for slow request timing. Code that
+// records its own durations can read it back with Clock, so tests can assert
+// exact duration values.
+//
+// Example:
+//
+//	log := canonlog.New(canonlog.WithClock(fake))
+//	start := log.Clock().Now()
+//	fake.Advance(250 * time.Millisecond)
+//	log.InfoAdd("duration_ms", log.Clock().Since(start).Milliseconds()) // 250
+func WithClock(c Clock) Option {
+	return func(l *Logger) {
+		l.clock = c
+	}
+}
+
+// Clock returns the logger's clock: the one set with WithClock, the one set
+// with SetClock, or the system clock.
+func (l *Logger) Clock() Clock {
+	if l.clock != nil {
+		return l.clock
+	}
+	return defaultClock()
+}
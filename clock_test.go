@@ -0,0 +1,74 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Since(t time.Time) time.Duration { return c.Now().Sub(t) }
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestWithClock(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setSlowRequestConfig(t, SlowRequestConfig{Threshold: 100 * time.Millisecond})
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	l := New(WithClock(clock))
+	if l.Clock() != clock {
+		t.Fatal("Expected Clock to return the WithClock clock")
+	}
+
+	clock.Advance(250 * time.Millisecond)
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["slow_elapsed_ms"] != float64(250) {
+		t.Errorf("Expected slow_elapsed_ms=250, got %v", entry["slow_elapsed_ms"])
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	SetClock(clock)
+	t.Cleanup(func() { SetClock(nil) })
+
+	Cron("tick", func(ctx context.Context) error {
+		clock.Advance(42 * time.Millisecond)
+		return nil
+	})()
+
+	entry := decodeEntry(t, buf)
+	if entry["duration_ms"] != float64(42) {
+		t.Errorf("Expected duration_ms=42, got %v", entry["duration_ms"])
+	}
+
+	SetClock(nil)
+	if _, ok := New().Clock().(systemClock); !ok {
+		t.Error("Expected SetClock(nil) to restore the system clock")
+	}
+}
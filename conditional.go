@@ -0,0 +1,98 @@
+package canonlog
+
+import (
+	"context"
+	"reflect"
+)
+
+// isZero reports whether v is nil, the zero value of its type, or an empty
+// slice or map.
+func isZero(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// DebugAddIf adds a field at debug level if cond is true.
+func (l *Logger) DebugAddIf(cond bool, key string, value any) *Logger {
+	if cond {
+		l.DebugAdd(key, value)
+	}
+	return l
+}
+
+// InfoAddIf adds a field at info level if cond is true.
+func (l *Logger) InfoAddIf(cond bool, key string, value any) *Logger {
+	if cond {
+		l.InfoAdd(key, value)
+	}
+	return l
+}
+
+// WarnAddIf adds a field at warn level and escalates if cond is true.
+func (l *Logger) WarnAddIf(cond bool, key string, value any) *Logger {
+	if cond {
+		l.WarnAdd(key, value)
+	}
+	return l
+}
+
+// DebugAddNonZero adds a field at debug level unless value is nil, the zero
+// value of its type, or an empty slice or map.
+func (l *Logger) DebugAddNonZero(key string, value any) *Logger {
+	return l.DebugAddIf(!isZero(value), key, value)
+}
+
+// InfoAddNonZero adds a field at info level unless value is nil, the zero
+// value of its type, or an empty slice or map.
+func (l *Logger) InfoAddNonZero(key string, value any) *Logger {
+	return l.InfoAddIf(!isZero(value), key, value)
+}
+
+// WarnAddNonZero adds a field at warn level and escalates unless value is nil,
+// the zero value of its type, or an empty slice or map.
+func (l *Logger) WarnAddNonZero(key string, value any) *Logger {
+	return l.WarnAddIf(!isZero(value), key, value)
+}
+
+// DebugAddIf adds a field at debug level to the logger in context if cond is true.
+// Panics if no logger exists in context.
+func DebugAddIf(ctx context.Context, cond bool, key string, value any) {
+	GetLogger(ctx).DebugAddIf(cond, key, value)
+}
+
+// InfoAddIf adds a field at info level to the logger in context if cond is true.
+// Panics if no logger exists in context.
+func InfoAddIf(ctx context.Context, cond bool, key string, value any) {
+	GetLogger(ctx).InfoAddIf(cond, key, value)
+}
+
+// WarnAddIf adds a field at warn level to the logger in context if cond is true.
+// Panics if no logger exists in context.
+func WarnAddIf(ctx context.Context, cond bool, key string, value any) {
+	GetLogger(ctx).WarnAddIf(cond, key, value)
+}
+
+// DebugAddNonZero adds a non-zero field at debug level to the logger in context.
+// Panics if no logger exists in context.
+func DebugAddNonZero(ctx context.Context, key string, value any) {
+	GetLogger(ctx).DebugAddNonZero(key, value)
+}
+
+// InfoAddNonZero adds a non-zero field at info level to the logger in context.
+// Panics if no logger exists in context.
+func InfoAddNonZero(ctx context.Context, key string, value any) {
+	GetLogger(ctx).InfoAddNonZero(key, value)
+}
+
+// WarnAddNonZero adds a non-zero field at warn level to the logger in context.
+// Panics if no logger exists in context.
+func WarnAddNonZero(ctx context.Context, key string, value any) {
+	GetLogger(ctx).WarnAddNonZero(key, value)
+}
@@ -0,0 +1,66 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestIsZero(t *testing.T) {
+	var nilPtr *int
+	zero := []any{nil, 0, "", false, 0.0, time.Duration(0), time.Time{}, nilPtr, []string{}, map[string]int{}, struct{}{}}
+	for _, v := range zero {
+		if !isZero(v) {
+			t.Errorf("Expected %#v to be zero", v)
+		}
+	}
+	nonZero := []any{1, "x", true, time.Second, []string{"a"}, map[string]int{"a": 1}, new(int)}
+	for _, v := range nonZero {
+		if isZero(v) {
+			t.Errorf("Expected %#v to be non-zero", v)
+		}
+	}
+}
+
+func TestAddIf(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	InfoAddIf(ctx, true, "cache", "hit")
+	InfoAddIf(ctx, false, "skipped", true)
+	WarnAddIf(ctx, false, "slow", true)
+
+	l := GetLogger(ctx)
+	if !l.HasField("cache") || l.HasField("skipped") || l.HasField("slow") {
+		t.Errorf("Unexpected fields: %v", l.Fields())
+	}
+	if l.Level() != slog.LevelInfo {
+		t.Errorf("Expected false WarnAddIf not to escalate, got %v", l.Level())
+	}
+
+	WarnAddIf(ctx, true, "slow", true)
+	if l.Level() != slog.LevelWarn {
+		t.Errorf("Expected WarnAddIf to escalate, got %v", l.Level())
+	}
+}
+
+func TestAddNonZero(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	InfoAddNonZero(ctx, "user_id", "")
+	InfoAddNonZero(ctx, "retries", 0)
+	InfoAddNonZero(ctx, "tags", []string(nil))
+	InfoAddNonZero(ctx, "status", 200)
+	DebugAddNonZero(ctx, "gated", 1)
+	WarnAddNonZero(ctx, "warning", "")
+
+	f := GetLogger(ctx).Fields()
+	if len(f) != 1 || f["status"] != 200 {
+		t.Errorf("Expected only status, got %v", f)
+	}
+	if GetLogger(ctx).Level() != slog.LevelInfo {
+		t.Error("Expected skipped WarnAddNonZero not to escalate")
+	}
+}
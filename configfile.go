@@ -0,0 +1,258 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileConfig is the JSON configuration file read by SetupFromFile.
+// The file is authoritative for every setting it covers: settings it omits
+// return to their defaults on each load.
+type FileConfig struct {
+	// Level and Format are as in Config.
+	Level  string `json:"level"`
+	Format string `json:"format"`
+
+	// Output is "stdout" (the default), "stderr", or a file path, which is
+	// opened for appending.
+	Output string `json:"output"`
+
+	// AddSource and TimeFormat are as in Config.
+	AddSource  bool   `json:"add_source"`
+	TimeFormat string `json:"time_format"`
+
+	// LevelMapper names a built-in LevelMapper: "gcp", "syslog", or
+	// "numeric". Defaults to slog's level names.
+	LevelMapper string `json:"level_mapper"`
+
+	// TimeZone is an IANA time zone name, such as "UTC" or "Europe/Berlin",
+	// for the timestamp. Defaults to local time.
+	TimeZone string `json:"time_zone"`
+
+	// DebugSampleRate is the fraction of NewContext loggers, from 0 to 1,
+	// that capture Debug fields, as with SetDebugSampler. Zero disables
+	// sampling.
+	DebugSampleRate float64 `json:"debug_sample_rate"`
+
+	// GCP configures the "gcp" format, with keys "project_id" and "labels".
+	GCP GCPConfig `json:"gcp"`
+
+	// Query configures ExtractQuery, with keys "allow", "deny", and
+	// "sensitive".
+	Query QueryConfig `json:"query"`
+
+	// Sinks, if set, writes to several outputs as Config.Sinks does. Each
+	// has its own level, format, output, and sample rate, and shares the
+	// formatting settings above. Level, Format, and Output must then be
+	// empty. Sinks wrapping a Handler, such as NewLokiHandler, cannot be
+	// described in a file, so do not combine them with SetupFromFile: each
+	// load replaces them.
+	Sinks []FileSink `json:"sinks"`
+}
+
+// FileSink is one output in FileConfig's sinks.
+type FileSink struct {
+	// Level, Format, and Output are as in FileConfig.
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	Output string `json:"output"`
+
+	// SampleRate is as in Sink.
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// configOutputs are the files opened for the current file configuration.
+var (
+	configOutputMu sync.Mutex
+	configOutputs  []*os.File
+)
+
+// configOutputGrace is how long replaced output files stay open, so entries
+// still being flushed through the previous handler are written instead of
+// lost.
+const configOutputGrace = 10 * time.Second
+
+// SetupFromFile loads the JSON configuration file at path and applies it with
+// Setup, SetDebugSampler, and SetQueryConfig. Unknown keys and invalid values
+// are reported as errors, leaving the current configuration unchanged. It may
+// be called again to reload; use ReloadOnSIGHUP to reload on signal. A
+// replaced output file is closed ten seconds after the reload, once flushes
+// through the previous handler have finished.
+//
+// Example file:
+//
+//	{
+//		"level": "info",
+//		"format": "json",
+//		"output": "/var/log/app/canonical.log",
+//		"debug_sample_rate": 0.01,
+//		"query": {"allow": ["page", "filter"], "sensitive": ["token"]}
+//	}
+//
+// With several outputs:
+//
+//	{
+//		"sinks": [
+//			{"level": "warn", "format": "text", "output": "stderr"},
+//			{"level": "debug", "output": "/var/log/app/debug.log", "sample_rate": 0.1}
+//		]
+//	}
+func SetupFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("canonlog: reading config: %w", err)
+	}
+	var fc FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return fmt.Errorf("canonlog: parsing config %s: %w", path, err)
+	}
+	if fc.DebugSampleRate < 0 || fc.DebugSampleRate > 1 {
+		return fmt.Errorf("canonlog: debug_sample_rate %v is not between 0 and 1", fc.DebugSampleRate)
+	}
+	if len(fc.Sinks) > 0 && (fc.Level != "" || fc.Format != "" || fc.Output != "") {
+		return fmt.Errorf("canonlog: level, format, and output are set per sink when sinks is used")
+	}
+	return fc.apply()
+}
+
+// apply installs fc.
+func (fc FileConfig) apply() error {
+	configOutputMu.Lock()
+	defer configOutputMu.Unlock()
+
+	var files []*os.File
+	open := func(output string) (io.Writer, error) {
+		w, file, err := openOutput(output)
+		if file != nil {
+			files = append(files, file)
+		}
+		return w, err
+	}
+
+	cfg := Config{Level: fc.Level, Format: fc.Format, AddSource: fc.AddSource, TimeFormat: fc.TimeFormat, GCP: fc.GCP}
+	err := fc.loadTimeZone(&cfg)
+	if err == nil {
+		cfg.LevelMapper, err = levelMapperNamed(fc.LevelMapper)
+	}
+	if err == nil && len(fc.Sinks) == 0 {
+		cfg.Output, err = open(fc.Output)
+	}
+	base := cfg
+	for _, fs := range fc.Sinks {
+		if err != nil {
+			break
+		}
+		sink := Sink{Config: base, SampleRate: fs.SampleRate}
+		sink.Level, sink.Format = fs.Level, fs.Format
+		sink.Output, err = open(fs.Output)
+		cfg.Sinks = append(cfg.Sinks, sink)
+	}
+	if err == nil {
+		err = Setup(cfg)
+	}
+	if err != nil {
+		for _, f := range files {
+			f.Close()
+		}
+		return err
+	}
+	if old := configOutputs; len(old) > 0 {
+		time.AfterFunc(configOutputGrace, func() {
+			for _, f := range old {
+				f.Close()
+			}
+		})
+	}
+	configOutputs = files
+
+	if rate := fc.DebugSampleRate; rate > 0 {
+		SetDebugSampler(func(context.Context) bool { return rand.Float64() < rate })
+	} else {
+		SetDebugSampler(nil)
+	}
+	SetQueryConfig(fc.Query)
+	return nil
+}
+
+// openOutput returns the writer for an output setting, and the file if it
+// opened one.
+func openOutput(output string) (io.Writer, *os.File, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nil, nil
+	case "stderr":
+		return os.Stderr, nil, nil
+	}
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("canonlog: opening output: %w", err)
+	}
+	return f, f, nil
+}
+
+// loadTimeZone sets cfg.TimeLocation from fc.TimeZone.
+func (fc FileConfig) loadTimeZone(cfg *Config) error {
+	if fc.TimeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(fc.TimeZone)
+	if err != nil {
+		return fmt.Errorf("canonlog: time_zone: %w", err)
+	}
+	cfg.TimeLocation = loc
+	return nil
+}
+
+// levelMapperNamed returns the built-in LevelMapper called name, or nil for "".
+func levelMapperNamed(name string) (LevelMapper, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "gcp":
+		return GCPSeverity, nil
+	case "syslog":
+		return SyslogSeverity, nil
+	case "numeric":
+		return NumericLevel, nil
+	}
+	return nil, fmt.Errorf("canonlog: unknown level_mapper %q", name)
+}
+
+// ReloadOnSIGHUP reloads the configuration file at path with SetupFromFile
+// each time the process receives SIGHUP, until ctx is done. Reload errors are
+// passed to onError, if set, and the previous configuration stays in effect.
+//
+// Example:
+//
+//	if err := canonlog.SetupFromFile(path); err != nil {
+//		log.Fatal(err)
+//	}
+//	go canonlog.ReloadOnSIGHUP(ctx, path, func(err error) {
+//		slog.Error("reloading log config", "error", err)
+//	})
+func ReloadOnSIGHUP(ctx context.Context, path string, onError func(error)) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			if err := SetupFromFile(path); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetFileConfig restores the settings SetupFromFile changes when the test ends.
+func resetFileConfig(t *testing.T) {
+	t.Helper()
+	captureOutput(t)
+	t.Cleanup(func() {
+		SetDebugSampler(nil)
+		queryConfig.Store(nil)
+		configOutputMu.Lock()
+		for _, f := range configOutputs {
+			f.Close()
+		}
+		configOutputs = nil
+		configOutputMu.Unlock()
+	})
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "canonlog.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetupFromFile(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	resetFileConfig(t)
+
+	out := filepath.Join(t.TempDir(), "out.log")
+	path := writeConfigFile(t, `{
+		"level": "warn",
+		"format": "json",
+		"level_mapper": "gcp",
+		"output": "`+filepath.ToSlash(out)+`",
+		"debug_sample_rate": 1,
+		"query": {"allow": ["page"]}
+	}`)
+	if err := SetupFromFile(path); err != nil {
+		t.Fatalf("SetupFromFile failed: %v", err)
+	}
+	if getLogLevel() != slog.LevelWarn {
+		t.Errorf("Expected level WARN, got %v", getLogLevel())
+	}
+
+	ctx := NewContext(context.Background())
+	if GetLogger(ctx).gateLevel.Load() != slog.LevelDebug {
+		t.Error("Expected debug_sample_rate=1 to capture debug fields")
+	}
+	ExtractQuery(ctx, url.Values{"page": {"2"}, "q": {"x"}})
+	WarnAdd(ctx, "k", "v")
+	Flush(ctx)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Expected a JSON line in the output file, got %q", data)
+	}
+	query, _ := entry["query"].(map[string]any)
+	if entry["level"] != "WARNING" {
+		t.Errorf("Expected the gcp level mapper, got level %v", entry["level"])
+	}
+	if entry["k"] != "v" || query["page"] != "2" || query["q"] != nil {
+		t.Errorf("Expected the configured query allowlist, got %v", entry)
+	}
+
+	// Reloading with a smaller file resets the omitted settings
+	if err := os.WriteFile(path, []byte(`{"level": "debug", "output": "stderr"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupFromFile(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if getLogLevel() != slog.LevelDebug || debugSampler.Load() != nil || queryConfig.Load().Allow != nil {
+		t.Error("Expected reload to apply the new file and reset omitted settings")
+	}
+}
+
+func TestSetupFromFileReloadKeepsOldOutputOpen(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	resetFileConfig(t)
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	path := writeConfigFile(t, `{"format": "json", "output": "`+filepath.ToSlash(first)+`"}`)
+	if err := SetupFromFile(path); err != nil {
+		t.Fatalf("SetupFromFile failed: %v", err)
+	}
+	previous := slog.Default()
+
+	if err := os.WriteFile(path, []byte(`{"format": "json", "output": "`+filepath.ToSlash(second)+`"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupFromFile(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	// A flush that picked up the previous handler before the reload still lands
+	previous.Info("late")
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"msg":"late"`) {
+		t.Errorf("Expected the late line in the previous output, got %q", data)
+	}
+}
+
+func TestSetupFromFileSinks(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	resetFileConfig(t)
+
+	dir := t.TempDir()
+	warn, debug := filepath.Join(dir, "warn.log"), filepath.Join(dir, "debug.log")
+	path := writeConfigFile(t, `{
+		"level_mapper": "numeric",
+		"sinks": [
+			{"level": "warn", "format": "text", "output": "`+filepath.ToSlash(warn)+`"},
+			{"level": "debug", "format": "json", "output": "`+filepath.ToSlash(debug)+`"}
+		]
+	}`)
+	if err := SetupFromFile(path); err != nil {
+		t.Fatalf("SetupFromFile failed: %v", err)
+	}
+	if getLogLevel() != slog.LevelDebug {
+		t.Errorf("Expected accumulation at the lowest sink level, got %v", getLogLevel())
+	}
+
+	l := New()
+	l.DebugAdd("cache", "miss")
+	l.Flush(context.Background())
+	l.WarnAdd("slow", true)
+	l.Flush(context.Background())
+
+	warnData, _ := os.ReadFile(warn)
+	debugData, _ := os.ReadFile(debug)
+	if got := string(warnData); strings.Count(got, "\n") != 1 || !strings.Contains(got, "slow=true") {
+		t.Errorf("Expected only the warn entry in the text sink, got %q", got)
+	}
+	if got := string(debugData); strings.Count(got, "\n") != 2 || !strings.Contains(got, `"level":4`) {
+		t.Errorf("Expected both entries with numeric levels in the JSON sink, got %q", got)
+	}
+	if len(configOutputs) != 2 {
+		t.Errorf("Expected both sink files tracked for reload, got %d", len(configOutputs))
+	}
+}
+
+func TestSetupFromFileInvalid(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	resetFileConfig(t)
+
+	tests := map[string]string{
+		"unknown key":      `{"levle": "debug"}`,
+		"bad level":        `{"level": "loud"}`,
+		"bad format":       `{"format": "xml"}`,
+		"bad rate":         `{"debug_sample_rate": 2}`,
+		"bad zone":         `{"time_zone": "Mars/Olympus"}`,
+		"bad mapper":       `{"level_mapper": "loud"}`,
+		"sinks and output": `{"output": "stderr", "sinks": [{"level": "info"}]}`,
+		"bad sink rate":    `{"sinks": [{"sample_rate": 2}]}`,
+		"bad sink level":   `{"sinks": [{"level": "loud"}]}`,
+		"not json":         `level: debug`,
+		"missing file":     "",
+	}
+	for name, content := range tests {
+		path := filepath.Join(t.TempDir(), "missing.json")
+		if content != "" {
+			path = writeConfigFile(t, content)
+		}
+		err := SetupFromFile(path)
+		if err == nil || !strings.HasPrefix(err.Error(), "canonlog: ") {
+			t.Errorf("%s: expected a canonlog error, got %v", name, err)
+		}
+	}
+	if getLogLevel() != slog.LevelInfo {
+		t.Error("Expected invalid files to leave the level unchanged")
+	}
+}
@@ -4,7 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // attrPool reduces allocations in Flush by reusing attribute slices.
@@ -18,6 +25,26 @@ var attrPool = sync.Pool{
 // maxErrors limits the number of errors stored to prevent unbounded memory growth.
 const maxErrors = 10
 
+// Keys added to checkpoint lines.
+const (
+	checkpointKey = "checkpoint"
+	seqKey        = "seq"
+)
+
+// fieldsPool recycles the fields maps of loggers returned with Release. The
+// loggers themselves are not reused, so a late write through a released
+// logger can never reach another unit of work's entry.
+var fieldsPool = sync.Pool{
+	New: func() any {
+		statPoolMisses.Add(1)
+		return make(map[string]any, 16)
+	},
+}
+
+// releasedGate is the gate level of a released logger, above every level, so
+// later writes are dropped before taking the lock.
+const releasedGate = slog.Level(math.MaxInt32)
+
 type loggerKeyType struct{}
 
 var loggerKey = &loggerKeyType{}
@@ -30,8 +57,49 @@ type Option func(*Logger)
 // or exceeds this gate level. The output level can still escalate via WarnAdd/ErrorAdd.
 func WithLevel(level slog.Level) Option {
 	return func(l *Logger) {
-		l.gateLevel = level
+		l.gateLevel.Store(level)
 		l.level = level
+		l.baseLevel = level
+	}
+}
+
+// KeyConflictPolicy controls what happens when a field is added under a key
+// that has already been recorded on the logger.
+type KeyConflictPolicy int
+
+const (
+	// KeyOverwrite replaces the existing value with the new one. This is the default.
+	KeyOverwrite KeyConflictPolicy = iota
+
+	// KeyKeepFirst keeps the existing value and discards the new one.
+	KeyKeepFirst
+
+	// KeySuffix keeps the existing value and stores the new one under the first
+	// free suffixed key: "key_2", "key_3", and so on.
+	KeySuffix
+
+	// KeyError keeps the existing value and records a duplicate key error, which
+	// escalates the entry to Error. Intended for development and test environments
+	// where silent overwrites should be caught early.
+	KeyError
+)
+
+// WithKeyConflictPolicy sets how the logger handles fields added under a key
+// that already exists. The default is KeyOverwrite.
+func WithKeyConflictPolicy(policy KeyConflictPolicy) Option {
+	return func(l *Logger) {
+		l.keyPolicy = policy
+	}
+}
+
+// WithFieldCapacity pre-sizes the logger's fields map for n fields, avoiding
+// rehashing as fields are added when a unit of work is known to record many.
+// The default capacity is 16. A value of 16 or less has no effect.
+func WithFieldCapacity(n int) Option {
+	return func(l *Logger) {
+		if n > 16 {
+			l.fields = make(map[string]any, n)
+		}
 	}
 }
 
@@ -49,35 +117,79 @@ func WithLevel(level slog.Level) Option {
 //	log.InfoAdd("user_id", "123")
 //	defer log.Flush(ctx)
 type Logger struct {
-	mu            sync.Mutex
-	fields        map[string]any
-	errors        []error
-	errorsDropped int        // count of errors dropped due to maxErrors limit
-	gateLevel     slog.Level // controls what gets accumulated
-	level         slog.Level // output level, can escalate
+	mu              sync.Mutex
+	fields          map[string]any
+	errors          []error
+	errorSources    []errorSource // call sites of errors, recorded when errorSource is set
+	errorsDropped   int           // count of errors dropped due to maxErrors limit
+	warnings        []error
+	warningsDropped int         // count of warnings dropped due to maxErrors limit
+	gateLevel       atomicLevel // controls what gets accumulated; changed by identity policies while in use
+	level           slog.Level  // output level, can escalate
+	baseLevel       slog.Level  // output level restored on reset
+	keyPolicy       KeyConflictPolicy
+	maxFields       int
+	maxValueLen     int
+	maxEntrySize    int
+	entrySize       int                   // approximate size of accumulated fields, tracked when maxEntrySize is set
+	splitSize       int                   // set by WithSplitEntrySize
+	fieldsDropped   int                   // count of fields dropped due to field or size limits
+	truncated       bool                  // set when a value was shortened or a field was dropped
+	structured      bool                  // render maps and structs as nested groups
+	sanitize        bool                  // run string values through Sanitize
+	injectionPolicy InjectionPolicy       // set by WithInjectionPolicy
+	errorSource     bool                  // record the call site of ErrorAdd
+	fingerprint     bool                  // emit error_fingerprint
+	buildInfo       bool                  // emit build and deployment metadata
+	slogger         *slog.Logger          // emits lines, slog.Default if nil
+	clock           Clock                 // set by WithClock, the default clock if nil
+	fieldOrder      FieldOrder            // set by WithFieldOrder
+	order           []string              // keys in the order first recorded, tracked for OrderInsertion
+	seq             uint64                // checkpoint sequence number, never reset
+	persistent      map[string]any        // fields that survive Flush reset
+	trace           *traceContext         // set by ExtractTraceContext
+	histograms      map[string]*histogram // values recorded with Observe
+	unsampled       bool                  // exempt from the Limiter, Aggregator, and sampling
+	sampleRate      float64               // fraction of entries emitted, all if zero; set by ExtractBot or an identity policy
+	flags           map[string]any        // feature flag variants recorded with Flag
+	security        map[string]any        // fields of security events, nil if none
+	securityEvents  []string              // names of security events
+	start           time.Time             // start of the unit of work, set when slow request detection is enabled
+	message         string                // line message, set by RecordHTTPStatus; empty by default
+	released        bool                  // set by Release; writes and flushes are ignored afterwards
 }
 
+// atomicLevel is a slog.Level that may be read and changed concurrently.
+type atomicLevel struct{ v atomic.Int64 }
+
+func (a *atomicLevel) Load() slog.Level       { return slog.Level(a.v.Load()) }
+func (a *atomicLevel) Store(level slog.Level) { a.v.Store(int64(level)) }
+
 // New creates a new logger with default settings.
 // The logger starts at the globally configured log level unless overridden with options.
 func New(opts ...Option) *Logger {
 	lvl := getLogLevel()
+	statLoggers.Add(1)
 	l := &Logger{
-		fields:    make(map[string]any, 16),
-		errors:    make([]error, 0, 2),
-		gateLevel: lvl,
+		fields:    fieldsPool.Get().(map[string]any),
 		level:     lvl,
+		baseLevel: lvl,
 	}
+	l.gateLevel.Store(lvl)
 	for _, opt := range opts {
 		opt(l)
 	}
+	if slowTimingEnabled() {
+		l.start = l.Clock().Now()
+	}
 	return l
 }
 
 // DebugAdd adds a field if debug level is enabled.
 func (l *Logger) DebugAdd(key string, value any) *Logger {
-	if l.gateLevel <= slog.LevelDebug {
+	if l.gateLevel.Load() <= slog.LevelDebug {
 		l.mu.Lock()
-		l.fields[key] = value
+		l.setField(key, value)
 		l.mu.Unlock()
 	}
 	return l
@@ -85,10 +197,10 @@ func (l *Logger) DebugAdd(key string, value any) *Logger {
 
 // DebugAddMany adds multiple fields if debug level is enabled.
 func (l *Logger) DebugAddMany(fields map[string]any) *Logger {
-	if len(fields) > 0 && l.gateLevel <= slog.LevelDebug {
+	if len(fields) > 0 && l.gateLevel.Load() <= slog.LevelDebug {
 		l.mu.Lock()
 		for k, v := range fields {
-			l.fields[k] = v
+			l.setField(k, v)
 		}
 		l.mu.Unlock()
 	}
@@ -97,9 +209,9 @@ func (l *Logger) DebugAddMany(fields map[string]any) *Logger {
 
 // InfoAdd adds a field if info level is enabled.
 func (l *Logger) InfoAdd(key string, value any) *Logger {
-	if l.gateLevel <= slog.LevelInfo {
+	if l.gateLevel.Load() <= slog.LevelInfo {
 		l.mu.Lock()
-		l.fields[key] = value
+		l.setField(key, value)
 		l.mu.Unlock()
 	}
 	return l
@@ -107,10 +219,10 @@ func (l *Logger) InfoAdd(key string, value any) *Logger {
 
 // InfoAddMany adds multiple fields if info level is enabled.
 func (l *Logger) InfoAddMany(fields map[string]any) *Logger {
-	if len(fields) > 0 && l.gateLevel <= slog.LevelInfo {
+	if len(fields) > 0 && l.gateLevel.Load() <= slog.LevelInfo {
 		l.mu.Lock()
 		for k, v := range fields {
-			l.fields[k] = v
+			l.setField(k, v)
 		}
 		l.mu.Unlock()
 	}
@@ -119,9 +231,9 @@ func (l *Logger) InfoAddMany(fields map[string]any) *Logger {
 
 // WarnAdd adds a field if warn level is enabled and sets level to at least Warn.
 func (l *Logger) WarnAdd(key string, value any) *Logger {
-	if l.gateLevel <= slog.LevelWarn {
+	if l.gateLevel.Load() <= slog.LevelWarn {
 		l.mu.Lock()
-		l.fields[key] = value
+		l.setField(key, value)
 		if l.level < slog.LevelWarn {
 			l.level = slog.LevelWarn
 		}
@@ -132,10 +244,10 @@ func (l *Logger) WarnAdd(key string, value any) *Logger {
 
 // WarnAddMany adds multiple fields if warn level is enabled and sets level to at least Warn.
 func (l *Logger) WarnAddMany(fields map[string]any) *Logger {
-	if len(fields) > 0 && l.gateLevel <= slog.LevelWarn {
+	if len(fields) > 0 && l.gateLevel.Load() <= slog.LevelWarn {
 		l.mu.Lock()
 		for k, v := range fields {
-			l.fields[k] = v
+			l.setField(k, v)
 		}
 		if l.level < slog.LevelWarn {
 			l.level = slog.LevelWarn
@@ -149,27 +261,171 @@ func (l *Logger) WarnAddMany(fields map[string]any) *Logger {
 // All errors are output as an "errors" array in the final log entry.
 // A maximum of 10 errors are stored to prevent unbounded memory growth;
 // if exceeded, "...and N more" is appended to the errors array.
+// Errors wrapping an *Error additionally emit their metadata in "error_details".
 func (l *Logger) ErrorAdd(err error) *Logger {
-	if err != nil && l.gateLevel <= slog.LevelError {
+	return l.errorAdd(err)
+}
+
+// errorAdd implements ErrorAdd. It must be called directly by the exported
+// ErrorAdd functions so the caller's source location is found at a fixed depth.
+func (l *Logger) errorAdd(err error) *Logger {
+	if ce, ok := err.(*Error); ok && ce == nil {
+		return l
+	}
+	if err != nil && l.gateLevel.Load() <= slog.LevelError {
+		var src errorSource
+		if l.errorSource {
+			src = callerSource()
+		}
+		l.mu.Lock()
+		l.addError(err, src)
+		l.mu.Unlock()
+	}
+	return l
+}
+
+// WarnError appends a non-fatal error to the warnings slice and sets level to at
+// least Warn. Use it for expected or recovered failures that should be recorded
+// without escalating the entry to Error. Warnings are output as a "warnings" array,
+// separate from "errors", and are subject to the same 10 item limit.
+func (l *Logger) WarnError(err error) *Logger {
+	if ce, ok := err.(*Error); ok && ce == nil {
+		return l
+	}
+	if err != nil && l.gateLevel.Load() <= slog.LevelWarn {
 		l.mu.Lock()
-		if len(l.errors) < maxErrors {
-			l.errors = append(l.errors, err)
+		if len(l.warnings) < maxErrors {
+			l.warnings = append(l.warnings, err)
 		} else {
-			l.errorsDropped++
+			l.warningsDropped++
 		}
-		if l.level < slog.LevelError {
-			l.level = slog.LevelError
+		if l.level < slog.LevelWarn {
+			l.level = slog.LevelWarn
 		}
 		l.mu.Unlock()
 	}
 	return l
 }
 
+// Persist adds a field that survives the reset after Flush, so it is included
+// in every line the logger emits. Use it for identity fields such as request_id,
+// tenant_id, or a job name on loggers that are flushed more than once.
+// Persistent fields are recorded regardless of the gate level; a regular field
+// added under the same key takes precedence until the next Flush.
+func (l *Logger) Persist(key string, value any) *Logger {
+	l.mu.Lock()
+	if l.persistent == nil {
+		l.persistent = make(map[string]any, 4)
+	}
+	if v, ok := value.(string); ok && l.sanitize {
+		value = Sanitize(v)
+	}
+	if l.injectionPolicy != InjectionAllow {
+		var ok bool
+		if key, value, ok = l.checkInjection(key, value); !ok {
+			l.mu.Unlock()
+			return l
+		}
+	}
+	l.persistent[key] = value
+	l.mu.Unlock()
+	return l
+}
+
+// Fields returns a copy of the fields accumulated so far, including persistent fields.
+// Errors and warnings are not included.
+func (l *Logger) Fields() map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mergedFieldsLocked()
+}
+
+// Len returns the number of fields accumulated so far, including persistent fields.
+func (l *Logger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := len(l.fields)
+	for k := range l.persistent {
+		if _, exists := l.fields[k]; !exists {
+			n++
+		}
+	}
+	return n
+}
+
+// Level returns the level the entry would be emitted at if flushed now.
+func (l *Logger) Level() slog.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// HasField reports whether a field has been recorded under key.
+func (l *Logger) HasField(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.fields[key]; exists {
+		return true
+	}
+	_, exists := l.persistent[key]
+	return exists
+}
+
+// mergedFieldsLocked returns a copy of the persistent fields overlaid with the
+// accumulated fields. Must be called with l.mu held.
+func (l *Logger) mergedFieldsLocked() map[string]any {
+	fields := make(map[string]any, len(l.fields)+len(l.persistent))
+	for k, v := range l.persistent {
+		fields[k] = v
+	}
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// setField stores value under key, applying the logger's key conflict policy.
+// Must be called with l.mu held.
+func (l *Logger) setField(key string, value any) {
+	if _, exists := l.fields[key]; !exists || l.keyPolicy == KeyOverwrite {
+		l.storeField(key, value)
+		return
+	}
+	switch l.keyPolicy {
+	case KeySuffix:
+		for i := 2; ; i++ {
+			suffixed := key + "_" + strconv.Itoa(i)
+			if _, exists := l.fields[suffixed]; !exists {
+				l.storeField(suffixed, value)
+				return
+			}
+		}
+	case KeyError:
+		l.addError(fmt.Errorf("canonlog: duplicate key %q", key), errorSource{})
+	}
+}
+
+// addError appends err, honoring the maxErrors limit, and escalates the output
+// level to Error. Must be called with l.mu held.
+func (l *Logger) addError(err error, src errorSource) {
+	if len(l.errors) < maxErrors {
+		l.errors = append(l.errors, err)
+		if l.errorSource {
+			l.errorSources = append(l.errorSources, src)
+		}
+	} else {
+		l.errorsDropped++
+	}
+	if l.level < slog.LevelError {
+		l.level = slog.LevelError
+	}
+}
+
 // Flush outputs the accumulated data in a single structured log line and resets
 // the logger for reuse.
 //
 // After Flush, the logger is reset: fields and errors are cleared, and the output
-// level returns to the gate level. This allows multiple Flush calls for batch
+// level returns to its initial level. This allows multiple Flush calls for batch
 // processing or long-running operations.
 //
 // Flush should be called once per logical unit of work (e.g., once per HTTP request
@@ -183,38 +439,283 @@ func (l *Logger) Flush(ctx context.Context) {
 	l.mu.Lock()
 
 	// Skip if nothing to log (handles concurrent/duplicate Flush calls)
-	if len(l.fields) == 0 && len(l.errors) == 0 && l.errorsDropped == 0 {
+	if l.released || l.emptyLocked() {
 		l.mu.Unlock()
 		return
 	}
 
-	outputLevel := l.level
-	fieldsCopy := make(map[string]any, len(l.fields))
-	for k, v := range l.fields {
-		fieldsCopy[k] = v
+	snap := l.takeSnapshotLocked()
+	start := l.start
+	l.resetLocked()
+	l.mu.Unlock()
+
+	if !start.IsZero() {
+		markSlow(&snap, l.Clock().Since(start))
+	}
+	if snap.level >= slog.LevelError && errorRuntimeSnapshot.Load() {
+		addRuntimeDiagnostics(snap.fields)
+	}
+	runEnrichers(ctx, snap.fields)
+	if !l.emit(ctx, snap) {
+		l.recycleFields(snap.fields)
+	}
+}
+
+// recycleFields gives an emitted fields map back to the logger for reuse, if
+// the logger has not started a new one. Only maps no flush hook has seen are
+// recycled, since hooks may retain Entry.Fields.
+func (l *Logger) recycleFields(fields map[string]any) {
+	if len(fields) > 100 {
+		return
 	}
-	var errorsCopy []error
+	clear(fields)
+	l.mu.Lock()
+	if l.fields == nil && !l.released {
+		l.fields = fields
+	}
+	l.mu.Unlock()
+}
+
+// Checkpoint emits an intermediate line with everything accumulated so far,
+// without resetting the logger. The line carries a "checkpoint" field set to
+// label and a "seq" field that increases monotonically over the logger's
+// lifetime, so progress lines from long-running operations can be ordered and
+// still carry identity fields such as request_id.
+//
+// Example:
+//
+//	for i, chunk := range chunks {
+//		process(chunk)
+//		canonlog.GetLogger(ctx).InfoAdd("chunks_done", i+1).Checkpoint(ctx, "chunk")
+//	}
+func (l *Logger) Checkpoint(ctx context.Context, label string) {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return
+	}
+	l.seq++
+	seq := l.seq
+	snap := l.snapshotLocked()
+	l.mu.Unlock()
+
+	snap.fields[checkpointKey] = label
+	snap.fields[seqKey] = seq
+	snap.checkpoint = true
+	l.emit(ctx, snap)
+}
+
+// Release returns the logger's fields map to an internal pool so a later New
+// can reuse it, removing the per-request map allocation. Anything not yet
+// flushed is discarded. The logger must not be used after Release, including
+// through a context that holds it; later writes and flushes are ignored. Use
+// ReleaseContext at the end of a request to flush and release in one step.
+func (l *Logger) Release() {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return
+	}
+	l.released = true
+	l.gateLevel.Store(releasedGate)
+	fields := l.fields
+	l.fields = nil
+	l.persistent = nil
+	l.mu.Unlock()
+
+	if fields != nil && len(fields) <= 100 {
+		clear(fields)
+		fieldsPool.Put(fields)
+	}
+}
+
+// snapshot is a copy of a logger's accumulated state taken for emission.
+type snapshot struct {
+	level           slog.Level
+	fields          map[string]any
+	errors          []error
+	errorSources    []errorSource
+	errorsDropped   int
+	warnings        []error
+	warningsDropped int
+	truncated       bool
+	fieldsDropped   int
+	security        map[string]any
+	securityEvents  []string
+	unsampled       bool
+	sampleRate      float64
+	order           []string
+	message         string
+	checkpoint      bool // emitted by Checkpoint, which does not run flush hooks
+}
+
+// emptyLocked reports whether there is nothing to emit.
+// Must be called with l.mu held.
+func (l *Logger) emptyLocked() bool {
+	return len(l.fields) == 0 && len(l.errors) == 0 && l.errorsDropped == 0 &&
+		len(l.warnings) == 0 && l.warningsDropped == 0 && !l.truncated && l.fieldsDropped == 0 &&
+		len(l.securityEvents) == 0 && len(l.histograms) == 0 &&
+		len(l.flags) == 0
+}
+
+// snapshotLocked copies the accumulated state. Must be called with l.mu held.
+func (l *Logger) snapshotLocked() snapshot {
+	snap := snapshot{
+		level:           l.level,
+		fields:          l.mergedFieldsLocked(),
+		errorsDropped:   l.errorsDropped,
+		warningsDropped: l.warningsDropped,
+		truncated:       l.truncated || l.fieldsDropped > 0,
+		fieldsDropped:   l.fieldsDropped,
+		unsampled:       l.unsampled,
+		sampleRate:      l.sampleRate,
+		order:           slices.Clone(l.order),
+		message:         l.message,
+	}
+	l.addComputedFieldsLocked(snap.fields)
 	if len(l.errors) > 0 {
-		errorsCopy = make([]error, len(l.errors))
-		copy(errorsCopy, l.errors)
+		snap.errors = make([]error, len(l.errors))
+		copy(snap.errors, l.errors)
+		snap.errorSources = slices.Clone(l.errorSources)
+	}
+	if len(l.warnings) > 0 {
+		snap.warnings = make([]error, len(l.warnings))
+		copy(snap.warnings, l.warnings)
+	}
+	if len(l.securityEvents) > 0 {
+		snap.security = maps.Clone(l.security)
+		snap.securityEvents = slices.Clone(l.securityEvents)
+	}
+	return snap
+}
+
+// addComputedFieldsLocked writes fields derived from Observe and Flag calls,
+// and build metadata, to fields. Must be called with l.mu held.
+func (l *Logger) addComputedFieldsLocked(fields map[string]any) {
+	l.addHistogramFields(fields)
+	l.addFlagsField(fields)
+	if l.buildInfo {
+		addBuildInfoFields(fields)
 	}
-	dropped := l.errorsDropped
+}
+
+// takeSnapshotLocked is snapshotLocked for a logger about to be reset. It
+// moves the accumulated fields and errors into the snapshot instead of copying
+// them, so a Flush allocates no copy of the fields map.
+// Must be called with l.mu held.
+func (l *Logger) takeSnapshotLocked() snapshot {
+	if len(l.persistent) > 0 {
+		return l.snapshotLocked()
+	}
+	snap := snapshot{
+		level:           l.level,
+		fields:          l.fields,
+		errors:          l.errors,
+		errorSources:    l.errorSources,
+		errorsDropped:   l.errorsDropped,
+		warnings:        l.warnings,
+		warningsDropped: l.warningsDropped,
+		truncated:       l.truncated || l.fieldsDropped > 0,
+		fieldsDropped:   l.fieldsDropped,
+		security:        l.security,
+		securityEvents:  l.securityEvents,
+		unsampled:       l.unsampled,
+		sampleRate:      l.sampleRate,
+		order:           l.order,
+		message:         l.message,
+	}
+	if snap.fields == nil {
+		snap.fields = make(map[string]any) // Checkpoint and limiter fields are added at emit
+	}
+	l.addComputedFieldsLocked(snap.fields)
+	l.fields = nil
+	l.errors = nil
+	l.errorSources = nil
+	l.warnings = nil
+	l.security = nil
+	l.securityEvents = nil
+	l.order = nil
+	return snap
+}
 
-	// Reset logger state for reuse (replace map if it grew too large)
+// resetLocked clears accumulated state for reuse. Must be called with l.mu held.
+func (l *Logger) resetLocked() {
+	// Replace map if it grew too large
 	if len(l.fields) > 100 {
-		l.fields = make(map[string]any, 16)
+		l.fields = nil
 	} else {
 		clear(l.fields)
 	}
-	l.errors = make([]error, 0, 2)
+	l.errors = l.errors[:0]
+	l.errorSources = l.errorSources[:0]
 	l.errorsDropped = 0
-	l.level = l.gateLevel
-	l.mu.Unlock()
+	l.warnings = nil
+	l.warningsDropped = 0
+	l.entrySize = 0
+	l.fieldsDropped = 0
+	l.truncated = false
+	l.security = nil
+	l.securityEvents = nil
+	l.histograms = nil
+	l.flags = nil
+	l.order = l.order[:0]
+	l.message = ""
+	l.level = l.baseLevel
+	if !l.start.IsZero() {
+		l.start = l.Clock().Now()
+	}
+}
+
+// emit writes snap as a log line, split if it exceeds the logger's split
+// size, and runs flush hooks unless snap is a checkpoint. It reports
+// whether any hook ran and so may have retained snap.fields.
+func (l *Logger) emit(ctx context.Context, snap snapshot) (retained bool) {
+	snap.fields = normalizeKeys(snap.fields)
+	hashFields(snap.fields)
+
+	// Security events and unsampled loggers are never aggregated or rate limited
+	security := len(snap.securityEvents) > 0
+	exempt := security || snap.unsampled
+
+	// Drop entries outside the sample of their sampling class
+	if snap.sampleRate > 0 && !exempt && snap.level < slog.LevelError && rand.Float64() >= snap.sampleRate {
+		statSampledOut.Add(1)
+		return false
+	}
+
+	// Fold designated entries into rollups if an aggregator is installed
+	if agg := aggregator.Load(); agg != nil && !exempt && agg.record(snap.fields, snap.level, len(snap.errors)) {
+		statAggregated.Add(1)
+		return false
+	}
+
+	// Drop repeated entries if a limiter is installed
+	if lim := limiter.Load(); lim != nil && !exempt {
+		ok, suppressed := lim.allow(snap.fields, snap.level, l.Clock().Now())
+		if !ok {
+			statSuppressed.Add(1)
+			return false
+		}
+		if suppressed > 0 {
+			snap.fields[suppressedCountKey] = suppressed
+		}
+	}
+
+	encryptFields(snap.fields)
 
 	// Pre-calculate capacity to avoid reallocation
-	neededCap := len(fieldsCopy)
-	if len(errorsCopy) > 0 {
-		neededCap++ // for errors array
+	neededCap := len(snap.fields)
+	if len(snap.errors) > 0 {
+		neededCap += 4 // for errors, error_details, error_fingerprint, and error_fingerprints
+	}
+	if len(snap.warnings) > 0 {
+		neededCap++ // for warnings array
+	}
+	if snap.truncated {
+		neededCap += 2 // for truncation indicators
+	}
+	if security {
+		neededCap++ // for security group
 	}
 
 	// Build attrs outside lock
@@ -226,35 +727,98 @@ func (l *Logger) Flush(ctx context.Context) {
 		attrs = attrs[:0]
 	}
 
-	for k, v := range fieldsCopy {
-		attrs = append(attrs, slog.Any(k, v))
+	if l.fieldOrder == OrderUnordered {
+		for k, v := range snap.fields {
+			attrs = l.appendField(attrs, k, v)
+		}
+	} else {
+		for _, k := range orderedKeys(snap.fields, l.fieldOrder, snap.order) {
+			attrs = l.appendField(attrs, k, snap.fields[k])
+		}
 	}
 
-	if len(errorsCopy) > 0 {
-		errStrings := make([]string, len(errorsCopy))
-		for i, err := range errorsCopy {
-			errStrings[i] = err.Error()
+	if len(snap.errors) > 0 {
+		attrs = append(attrs, slog.Any("errors", errorStrings(snap.errors, snap.errorsDropped)))
+		if details := errorDetails(snap.errors, snap.errorSources); details != nil {
+			attrs = append(attrs, slog.Any(errorDetailsKey, details))
 		}
-		if dropped > 0 {
-			errStrings = append(errStrings, fmt.Sprintf("...and %d more", dropped))
+		if l.fingerprint {
+			fps := errorFingerprints(snap.errors, snap.errorSources)
+			attrs = append(attrs, slog.String(errorFingerprintKey, fps[0]), slog.Any(errorFingerprintsKey, fps))
 		}
-		attrs = append(attrs, slog.Any("errors", errStrings))
 	}
 
-	slog.LogAttrs(ctx, outputLevel, "", attrs...)
+	if len(snap.warnings) > 0 {
+		attrs = append(attrs, slog.Any("warnings", errorStrings(snap.warnings, snap.warningsDropped)))
+	}
+
+	if security {
+		attrs = append(attrs, securityGroup(snap.securityEvents, snap.security))
+	}
+
+	if snap.truncated {
+		attrs = append(attrs, slog.Bool(truncatedKey, true))
+		if snap.fieldsDropped > 0 {
+			attrs = append(attrs, slog.Int(fieldsDroppedKey, snap.fieldsDropped))
+		}
+	}
+
+	if exempt {
+		// Sinks sample independently, so tell them this line is exempt too
+		ctx = context.WithValue(ctx, unsampledKey, true)
+	}
+	l.writeLines(ctx, snap.level, snap.message, attrs)
 
 	// Return slice to pool unless it grew too large
 	if cap(attrs) <= 128 {
 		*attrsPtr = attrs
 		attrPool.Put(attrsPtr)
 	}
+
+	if snap.checkpoint {
+		return false
+	}
+	return runFlushHooks(ctx, Entry{
+		Level:    snap.level,
+		Fields:   snap.fields,
+		Errors:   snap.errors,
+		Warnings: snap.warnings,
+		Security: snap.securityEvents,
+	})
+}
+
+// appendField appends the attr for one field to attrs.
+func (l *Logger) appendField(attrs []slog.Attr, key string, value any) []slog.Attr {
+	if l.structured {
+		return append(attrs, slog.Attr{Key: key, Value: structuredValue(value)})
+	}
+	return append(attrs, slog.Any(key, value))
+}
+
+// errorStrings converts errs to their messages, appending "...and N more" when
+// dropped is non-zero.
+func errorStrings(errs []error, dropped int) []string {
+	strs := make([]string, len(errs), len(errs)+1)
+	for i, err := range errs {
+		strs[i] = err.Error()
+	}
+	if dropped > 0 {
+		strs = append(strs, fmt.Sprintf("...and %d more", dropped))
+	}
+	return strs
 }
 
 // NewContext creates a new context with a logger attached.
 // This is typically called by middleware at the start of a request.
+// Options are passed through to New. If a debug sampler is installed and selects
+// ctx, the logger also captures Debug fields (see SetDebugSampler).
 // Note: This always creates a new logger, replacing any existing logger in the context.
-func NewContext(ctx context.Context) context.Context {
-	return context.WithValue(ctx, loggerKey, New())
+func NewContext(ctx context.Context, opts ...Option) context.Context {
+	l := New(opts...)
+	if captureDebug(ctx) {
+		l.enableDebugCapture()
+	}
+	return context.WithValue(ctx, loggerKey, l)
 }
 
 // GetLogger retrieves the logger from context or panics if none exists.
@@ -322,7 +886,40 @@ func WarnAddMany(ctx context.Context, fields map[string]any) {
 // ErrorAdd appends an error to the logger in context and sets level to Error.
 // Panics if no logger exists in context.
 func ErrorAdd(ctx context.Context, err error) {
-	GetLogger(ctx).ErrorAdd(err)
+	GetLogger(ctx).errorAdd(err)
+}
+
+// WarnError appends a non-fatal error to the logger in context and sets level to at least Warn.
+// Panics if no logger exists in context.
+func WarnError(ctx context.Context, err error) {
+	GetLogger(ctx).WarnError(err)
+}
+
+// SetPersistent adds a field to the logger in context that survives Flush reset.
+// Panics if no logger exists in context.
+func SetPersistent(ctx context.Context, key string, value any) {
+	GetLogger(ctx).Persist(key, value)
+}
+
+// Checkpoint emits an intermediate line from the logger in context without resetting it.
+// Panics if no logger exists in context.
+func Checkpoint(ctx context.Context, label string) {
+	GetLogger(ctx).Checkpoint(ctx, label)
+}
+
+// ReleaseContext flushes the logger in context and returns it to the pool for
+// reuse. Neither ctx nor contexts derived from it may be used for logging
+// afterwards. It does nothing if ctx has no logger.
+//
+// Example:
+//
+//	ctx := canonlog.NewContext(r.Context())
+//	defer canonlog.ReleaseContext(ctx)
+func ReleaseContext(ctx context.Context) {
+	if l, ok := TryGetLogger(ctx); ok {
+		l.Flush(ctx)
+		l.Release()
+	}
 }
 
 // Flush logs the accumulated data from the logger stored in context.
@@ -1,7 +1,9 @@
 package canonlog
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -16,6 +18,27 @@ func setTestLogLevel(level slog.Level) func() {
 	return func() { logLevel.Store(old) }
 }
 
+// captureOutput redirects the default slog logger to a JSON buffer at debug level
+// and restores the previous default when the test ends.
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+// decodeEntry parses a single JSON log line from buf.
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
 func TestNew(t *testing.T) {
 	defer setTestLogLevel(slog.LevelInfo)()
 
@@ -29,8 +52,8 @@ func TestNew(t *testing.T) {
 		t.Error("fields map not initialized")
 	}
 
-	if l.gateLevel != slog.LevelInfo {
-		t.Errorf("Expected default gateLevel Info, got %v", l.gateLevel)
+	if l.gateLevel.Load() != slog.LevelInfo {
+		t.Errorf("Expected default gateLevel Info, got %v", l.gateLevel.Load())
 	}
 
 	if l.level != slog.LevelInfo {
@@ -41,8 +64,8 @@ func TestNew(t *testing.T) {
 func TestNewWithLevel(t *testing.T) {
 	l := New(WithLevel(slog.LevelError))
 
-	if l.gateLevel != slog.LevelError {
-		t.Errorf("Expected gateLevel Error, got %v", l.gateLevel)
+	if l.gateLevel.Load() != slog.LevelError {
+		t.Errorf("Expected gateLevel Error, got %v", l.gateLevel.Load())
 	}
 
 	if l.level != slog.LevelError {
@@ -368,3 +391,377 @@ func TestConcurrentFieldAddition(t *testing.T) {
 		t.Errorf("Expected 100 fields, got %d", len(l.fields))
 	}
 }
+
+func TestKeyConflictPolicy(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	t.Run("overwrite", func(t *testing.T) {
+		l := New()
+		l.InfoAdd("key", "first").InfoAdd("key", "second")
+
+		if l.fields["key"] != "second" {
+			t.Errorf("Expected key=second, got %v", l.fields["key"])
+		}
+	})
+
+	t.Run("keep first", func(t *testing.T) {
+		l := New(WithKeyConflictPolicy(KeyKeepFirst))
+		l.InfoAdd("key", "first").InfoAdd("key", "second")
+
+		if l.fields["key"] != "first" {
+			t.Errorf("Expected key=first, got %v", l.fields["key"])
+		}
+	})
+
+	t.Run("suffix", func(t *testing.T) {
+		l := New(WithKeyConflictPolicy(KeySuffix))
+		l.InfoAdd("key", "first").InfoAdd("key", "second").InfoAdd("key", "third")
+
+		if l.fields["key"] != "first" {
+			t.Errorf("Expected key=first, got %v", l.fields["key"])
+		}
+		if l.fields["key_2"] != "second" {
+			t.Errorf("Expected key_2=second, got %v", l.fields["key_2"])
+		}
+		if l.fields["key_3"] != "third" {
+			t.Errorf("Expected key_3=third, got %v", l.fields["key_3"])
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		l := New(WithKeyConflictPolicy(KeyError))
+		l.InfoAdd("key", "first").InfoAdd("key", "second")
+
+		if l.fields["key"] != "first" {
+			t.Errorf("Expected key=first, got %v", l.fields["key"])
+		}
+		if len(l.errors) != 1 {
+			t.Fatalf("Expected 1 duplicate key error, got %d", len(l.errors))
+		}
+		if l.level != slog.LevelError {
+			t.Errorf("Expected level Error after duplicate key, got %v", l.level)
+		}
+	})
+}
+
+func TestNewContextWithOptions(t *testing.T) {
+	ctx := NewContext(context.Background(), WithLevel(slog.LevelWarn))
+
+	if l := GetLogger(ctx); l.gateLevel.Load() != slog.LevelWarn {
+		t.Errorf("Expected gateLevel Warn, got %v", l.gateLevel.Load())
+	}
+}
+
+func TestLoggerWarnError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.WarnError(errors.New("cache unavailable")).WarnError(nil)
+
+	if len(l.warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(l.warnings))
+	}
+	if len(l.errors) != 0 {
+		t.Errorf("WarnError should not add to errors, got %d", len(l.errors))
+	}
+	if l.level != slog.LevelWarn {
+		t.Errorf("Expected level Warn after WarnError, got %v", l.level)
+	}
+
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry["level"] != "WARN" {
+		t.Errorf("Expected WARN entry, got %v", entry["level"])
+	}
+	warnings, ok := entry["warnings"].([]any)
+	if !ok || len(warnings) != 1 || warnings[0] != "cache unavailable" {
+		t.Errorf("Expected warnings [cache unavailable], got %v", entry["warnings"])
+	}
+	if _, exists := entry["errors"]; exists {
+		t.Error("Expected no errors array")
+	}
+}
+
+func TestWarnErrorMaxWarnings(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	for i := 0; i < 12; i++ {
+		l.WarnError(errors.New("warning"))
+	}
+
+	if len(l.warnings) != maxErrors {
+		t.Errorf("Expected exactly %d warnings, got %d", maxErrors, len(l.warnings))
+	}
+	if l.warningsDropped != 2 {
+		t.Errorf("Expected 2 warnings dropped, got %d", l.warningsDropped)
+	}
+}
+
+func TestWarnErrorIgnoredAboveWarn(t *testing.T) {
+	l := New(WithLevel(slog.LevelError))
+	l.WarnError(errors.New("warning"))
+
+	if len(l.warnings) != 0 {
+		t.Error("WarnError should be ignored when gate level is Error")
+	}
+}
+
+func TestWarnError_ContextHelper(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	WarnError(ctx, errors.New("retry succeeded"))
+
+	if l := GetLogger(ctx); len(l.warnings) != 1 || l.level != slog.LevelWarn {
+		t.Errorf("Expected 1 warning at Warn level, got %d at %v", len(l.warnings), l.level)
+	}
+}
+
+func TestLoggerReadAccess(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	l.InfoAdd("tenant_id", "acme").InfoAdd("user_id", "123")
+
+	if l.Len() != 2 {
+		t.Errorf("Expected Len 2, got %d", l.Len())
+	}
+	if !l.HasField("tenant_id") {
+		t.Error("Expected HasField(tenant_id) to be true")
+	}
+	if l.HasField("missing") {
+		t.Error("Expected HasField(missing) to be false")
+	}
+	if l.Level() != slog.LevelInfo {
+		t.Errorf("Expected Level Info, got %v", l.Level())
+	}
+
+	fields := l.Fields()
+	if fields["user_id"] != "123" {
+		t.Errorf("Expected user_id=123 in Fields, got %v", fields["user_id"])
+	}
+
+	fields["injected"] = true
+	if l.HasField("injected") {
+		t.Error("Modifying the Fields copy should not affect the logger")
+	}
+
+	l.WarnAdd("slow", true)
+	if l.Level() != slog.LevelWarn {
+		t.Errorf("Expected Level Warn after WarnAdd, got %v", l.Level())
+	}
+}
+
+func TestLoggerCheckpoint(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	ctx := context.Background()
+
+	l := New()
+	l.InfoAdd("job_id", "nightly")
+
+	l.InfoAdd("records", 100).Checkpoint(ctx, "batch")
+	first := decodeEntry(t, buf)
+	buf.Reset()
+
+	l.InfoAdd("records", 200).Checkpoint(ctx, "batch")
+	second := decodeEntry(t, buf)
+	buf.Reset()
+
+	if first[checkpointKey] != "batch" || first[seqKey] != float64(1) {
+		t.Errorf("Expected first checkpoint batch/1, got %v/%v", first[checkpointKey], first[seqKey])
+	}
+	if second[seqKey] != float64(2) {
+		t.Errorf("Expected second checkpoint seq=2, got %v", second[seqKey])
+	}
+	if second["job_id"] != "nightly" || second["records"] != float64(200) {
+		t.Errorf("Expected checkpoint to keep accumulated fields, got %v", second)
+	}
+
+	if !l.HasField("job_id") {
+		t.Error("Checkpoint should not reset the logger")
+	}
+	if l.HasField(checkpointKey) || l.HasField(seqKey) {
+		t.Error("Checkpoint fields should not be stored on the logger")
+	}
+
+	l.Flush(ctx)
+	final := decodeEntry(t, buf)
+	if _, exists := final[checkpointKey]; exists {
+		t.Error("Flush line should not carry the checkpoint field")
+	}
+}
+
+func TestCheckpoint_ContextHelper(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	InfoAdd(ctx, "request_id", "abc")
+	Checkpoint(ctx, "started")
+
+	if entry := decodeEntry(t, buf); entry[checkpointKey] != "started" || entry["request_id"] != "abc" {
+		t.Errorf("Expected checkpoint line with request_id, got %v", entry)
+	}
+}
+
+func TestLoggerPersist(t *testing.T) {
+	defer setTestLogLevel(slog.LevelError)()
+	buf := captureOutput(t)
+	ctx := context.Background()
+
+	l := New()
+	l.Persist("request_id", "abc").Persist("tenant_id", "acme")
+
+	if !l.HasField("request_id") || l.Len() != 2 {
+		t.Errorf("Expected persistent fields to be visible, got Len %d", l.Len())
+	}
+
+	l.Flush(ctx)
+	if buf.Len() != 0 {
+		t.Error("Persistent fields alone should not produce a line")
+	}
+
+	l.ErrorAdd(errors.New("first"))
+	l.Flush(ctx)
+	first := decodeEntry(t, buf)
+	buf.Reset()
+
+	l.ErrorAdd(errors.New("second"))
+	l.Flush(ctx)
+	second := decodeEntry(t, buf)
+
+	for _, entry := range []map[string]any{first, second} {
+		if entry["request_id"] != "abc" || entry["tenant_id"] != "acme" {
+			t.Errorf("Expected persistent fields on every flush, got %v", entry)
+		}
+	}
+}
+
+func TestLoggerPersistOverride(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.Persist("stage", "default").InfoAdd("stage", "override")
+
+	if l.Fields()["stage"] != "override" {
+		t.Errorf("Expected regular field to take precedence, got %v", l.Fields()["stage"])
+	}
+	if l.Len() != 1 {
+		t.Errorf("Expected Len 1 for overlapping keys, got %d", l.Len())
+	}
+
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["stage"] != "override" {
+		t.Errorf("Expected stage=override, got %v", entry["stage"])
+	}
+	if l.Fields()["stage"] != "default" {
+		t.Errorf("Expected persistent value after Flush, got %v", l.Fields()["stage"])
+	}
+}
+
+func TestSetPersistent_ContextHelper(t *testing.T) {
+	ctx := NewContext(context.Background())
+	SetPersistent(ctx, "job", "reindex")
+
+	if GetLogger(ctx).Fields()["job"] != "reindex" {
+		t.Error("Expected persistent field on context logger")
+	}
+}
+
+func TestReleaseContext(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background(), WithLevel(slog.LevelDebug), WithMaxFields(1))
+	l := GetLogger(ctx)
+	l.Persist("tenant", "acme")
+	l.WarnAdd("slow", true)
+	ReleaseContext(ctx)
+
+	if entry := decodeEntry(t, buf); entry["slow"] != true {
+		t.Errorf("Expected ReleaseContext to flush, got %v", entry)
+	}
+
+	// Whether or not the pool hands back the same logger, new loggers start clean
+	for range 10 {
+		n := New()
+		if n.Len() != 0 || n.Level() != slog.LevelInfo || n.gateLevel.Load() != slog.LevelInfo || n.maxFields != 0 {
+			t.Fatalf("Expected reused logger to be reset, got fields=%v level=%v gate=%v", n.Fields(), n.Level(), n.gateLevel.Load())
+		}
+		n.Release()
+	}
+}
+
+func TestReleaseIgnoresLateWrites(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	old := NewContext(context.Background())
+	InfoAdd(old, "tenant_id", "acme")
+	ReleaseContext(old)
+	buf.Reset()
+
+	next := New()
+	next.InfoAdd("tenant_id", "globex")
+
+	InfoAdd(old, "late", true)
+	ErrorAdd(old, errors.New("late error"))
+	GetLogger(old).SecurityEvent("late_event", nil)
+	Flush(old)
+	GetLogger(old).Checkpoint(old, "late")
+	GetLogger(old).Release()
+	if buf.Len() != 0 {
+		t.Errorf("Expected a released logger to emit nothing, got %s", buf.String())
+	}
+
+	next.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if entry["late"] != nil || entry["errors"] != nil || entry["tenant_id"] != "globex" {
+		t.Errorf("Expected late writes not to reach another logger, got %v", entry)
+	}
+}
+
+func TestReleaseContextWithoutLogger(t *testing.T) {
+	ReleaseContext(context.Background()) // must not panic
+}
+
+func TestFlushRecyclesFields(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.InfoAdd("a", 1)
+	l.Flush(context.Background())
+	if l.fields == nil {
+		t.Fatal("Expected fields map to be recycled after Flush")
+	}
+
+	buf.Reset()
+	l.InfoAdd("b", 2)
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if _, ok := entry["a"]; ok || entry["b"] != float64(2) {
+		t.Errorf("Expected only new fields after recycling, got %v", entry)
+	}
+}
+
+func TestWithFieldCapacity(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New(WithFieldCapacity(64))
+	for i := range 40 {
+		l.InfoAdd(fmt.Sprintf("k%d", i), i)
+	}
+	if l.Len() != 40 {
+		t.Errorf("Expected 40 fields, got %d", l.Len())
+	}
+
+	if l := New(WithFieldCapacity(0)); l.fields == nil {
+		t.Error("Expected default fields map for non-positive capacity")
+	}
+}
@@ -0,0 +1,106 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Fields recorded for each run of a job wrapped by Cron.
+const (
+	cronJobKey      = "cron_job"
+	cronStatusKey   = "cron_status"
+	cronDurationKey = "duration_ms"
+)
+
+// Values of the cron_status field.
+const (
+	cronStatusOK      = "ok"
+	cronStatusError   = "error"
+	cronStatusSkipped = "skipped"
+)
+
+// Cron wraps a periodic job so every run produces one canonical line with
+// cron_job, cron_status (ok, error, or skipped), and duration_ms. fn receives
+// a context carrying the run's logger, so it can add its own fields, and
+// any error it returns is recorded with ErrorAdd. If fn panics, the run is
+// recorded as cron_status=error with the panic value as its error, and the
+// line is emitted before the panic continues. The cron fields are recorded
+// whatever the global level, so skipped and failed runs always name their job;
+// at Warn or above, a successful run logs nothing unless fn recorded something.
+//
+// A run that starts while the previous one is still in progress is skipped,
+// and a Warn line with cron_status=skipped is emitted, so overlapping
+// schedules and stuck jobs are visible instead of piling up.
+//
+// The returned function matches robfig/cron's FuncJob and works just as well
+// with a time.Ticker loop.
+//
+// Example:
+//
+//	job := canonlog.Cron("cleanup_sessions", func(ctx context.Context) error {
+//		n, err := store.DeleteExpired(ctx)
+//		canonlog.InfoAdd(ctx, "deleted", n)
+//		return err
+//	})
+//	c.AddFunc("@every 5m", job)
+func Cron(name string, fn func(ctx context.Context) error) func() {
+	var running atomic.Bool
+	return func() {
+		ctx := NewContext(context.Background())
+		l := GetLogger(ctx)
+
+		if !running.CompareAndSwap(false, true) {
+			recordCron(l, slog.LevelWarn, slog.String(cronJobKey, name), slog.String(cronStatusKey, cronStatusSkipped))
+			l.Flush(ctx)
+			return
+		}
+		defer running.Store(false)
+
+		clock := l.Clock()
+		start := clock.Now()
+		var err error
+		defer func() {
+			r := recover()
+			if r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			status, level := cronStatusOK, slog.LevelInfo
+			if err != nil {
+				status, level = cronStatusError, slog.LevelError
+			}
+			recordCron(l, level,
+				slog.String(cronJobKey, name),
+				slog.String(cronStatusKey, status),
+				slog.Int64(cronDurationKey, clock.Since(start).Milliseconds()))
+			if err != nil {
+				l.ErrorAdd(err)
+			}
+			l.Flush(ctx)
+			if r != nil {
+				panic(r)
+			}
+		}()
+
+		err = fn(ctx)
+	}
+}
+
+// recordCron stores Cron's fields and raises the level to at least level.
+// Like SecurityEvent it bypasses the gate, so skipped and failed runs name
+// their job whatever the global level. A successful run that recorded nothing
+// else is left empty when Info is disabled, so it stays silent.
+func recordCron(l *Logger, level slog.Level, fields ...slog.Attr) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level <= slog.LevelInfo && l.gateLevel.Load() > slog.LevelInfo && l.emptyLocked() {
+		return
+	}
+	for _, f := range fields {
+		l.storeField(f.Key, f.Value.Any())
+	}
+	if l.level < level {
+		l.level = level
+	}
+}
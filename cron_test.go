@@ -0,0 +1,171 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCron(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	job := Cron("cleanup", func(ctx context.Context) error {
+		InfoAdd(ctx, "deleted", 3)
+		return nil
+	})
+	job()
+
+	entry := decodeEntry(t, buf)
+	if entry["cron_job"] != "cleanup" {
+		t.Errorf("Expected cron_job=cleanup, got %v", entry["cron_job"])
+	}
+	if entry["cron_status"] != "ok" {
+		t.Errorf("Expected cron_status=ok, got %v", entry["cron_status"])
+	}
+	if entry["deleted"] != float64(3) {
+		t.Errorf("Expected job fields to be recorded, got %v", entry)
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Error("Expected duration_ms to be recorded")
+	}
+	if entry["level"] != "INFO" {
+		t.Errorf("Expected INFO level, got %v", entry["level"])
+	}
+}
+
+func TestCronError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	Cron("sync", func(ctx context.Context) error {
+		return errors.New("upstream unavailable")
+	})()
+
+	entry := decodeEntry(t, buf)
+	if entry["cron_status"] != "error" {
+		t.Errorf("Expected cron_status=error, got %v", entry["cron_status"])
+	}
+	if entry["level"] != "ERROR" {
+		t.Errorf("Expected ERROR level, got %v", entry["level"])
+	}
+	errs, _ := entry["errors"].([]any)
+	if len(errs) != 1 || errs[0] != "upstream unavailable" {
+		t.Errorf("Expected recorded error, got %v", entry["errors"])
+	}
+}
+
+func TestCronOverlap(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := Cron("report", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		job()
+		close(done)
+	}()
+	<-started
+	job()
+	close(release)
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"cron_status":"skipped"`) || !strings.Contains(lines[0], `"level":"WARN"`) {
+		t.Errorf("Expected overlapping run to be skipped at WARN, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"cron_status":"ok"`) {
+		t.Errorf("Expected first run to complete, got %s", lines[1])
+	}
+}
+
+func TestCronPanic(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	job := Cron("reindex", func(ctx context.Context) error {
+		InfoAdd(ctx, "batch", 7)
+		panic("index corrupted")
+	})
+	func() {
+		defer func() {
+			if r := recover(); r != "index corrupted" {
+				t.Errorf("Expected the panic to continue, got %v", r)
+			}
+		}()
+		job()
+	}()
+
+	entry := decodeEntry(t, buf)
+	if entry["cron_status"] != "error" || entry["level"] != "ERROR" || entry["batch"] != float64(7) {
+		t.Errorf("Expected an error line with the job's fields, got %v", entry)
+	}
+	errs, _ := entry["errors"].([]any)
+	if len(errs) != 1 || errs[0] != "panic: index corrupted" {
+		t.Errorf("Expected the panic recorded as an error, got %v", entry["errors"])
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Error("Expected duration_ms to be recorded")
+	}
+
+	// The job can run again after a panic
+	buf.Reset()
+	func() {
+		defer func() { recover() }()
+		job()
+	}()
+	if strings.Contains(buf.String(), "skipped") {
+		t.Error("Expected the running flag to be cleared after a panic")
+	}
+}
+
+func TestCronWarnLevel(t *testing.T) {
+	defer setTestLogLevel(slog.LevelWarn)()
+	buf := captureOutput(t)
+
+	Cron("sync", func(ctx context.Context) error {
+		return errors.New("upstream unavailable")
+	})()
+	entry := decodeEntry(t, buf)
+	if entry["cron_job"] != "sync" || entry["cron_status"] != "error" || entry["duration_ms"] == nil {
+		t.Errorf("Expected the failed run's cron fields at Warn level, got %v", entry)
+	}
+
+	buf.Reset()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := Cron("report", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	done := make(chan struct{})
+	go func() {
+		job()
+		close(done)
+	}()
+	<-started
+	job()
+	close(release)
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected only the skipped line, got %d: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"cron_job":"report"`) || !strings.Contains(lines[0], `"cron_status":"skipped"`) {
+		t.Errorf("Expected the skipped line to name the job, got %s", lines[0])
+	}
+}
@@ -0,0 +1,76 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// debugSampler decides per context whether NewContext loggers capture debug fields.
+var debugSampler atomic.Pointer[func(context.Context) bool]
+
+// SetDebugSampler installs a function that NewContext consults for every new
+// logger. When it returns true, the logger accumulates Debug fields regardless
+// of its gate level, while the entry is still emitted at the logger's normal
+// output level so it passes the handler's level filter. This gives detailed
+// lines for a subset of traffic without lowering the global level.
+//
+// The decision is typically derived from tracing state or a propagated flag.
+// For example, with OpenTelemetry:
+//
+//	canonlog.SetDebugSampler(func(ctx context.Context) bool {
+//		return trace.SpanContextFromContext(ctx).IsSampled()
+//	})
+//
+// Passing nil removes the sampler.
+func SetDebugSampler(fn func(ctx context.Context) bool) {
+	if fn == nil {
+		debugSampler.Store(nil)
+		return
+	}
+	debugSampler.Store(&fn)
+}
+
+type forceDebugKeyType struct{}
+
+var forceDebugKey = &forceDebugKeyType{}
+
+// ForceDebug returns a context marked so that loggers created from it with
+// NewContext capture Debug fields, as if selected by the debug sampler.
+// Use it to troubleshoot a single request in production, for example when an
+// authenticated operator sets a debug header:
+//
+//	if r.Header.Get("X-Canonlog-Debug") == "1" && isOperator(r) {
+//		ctx = canonlog.ForceDebug(ctx)
+//	}
+//	ctx = canonlog.NewContext(ctx)
+//
+// ForceDebug does not affect a logger already stored in ctx.
+func ForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceDebugKey, true)
+}
+
+// captureDebug reports whether a logger created for ctx should accumulate debug fields.
+func captureDebug(ctx context.Context) bool {
+	if forced, _ := ctx.Value(forceDebugKey).(bool); forced {
+		statDebugCaptured.Add(1)
+		return true
+	}
+	fn := debugSampler.Load()
+	if fn == nil {
+		return false
+	}
+	if (*fn)(ctx) {
+		statDebugCaptured.Add(1)
+		return true
+	}
+	statDebugSkipped.Add(1)
+	return false
+}
+
+// enableDebugCapture lowers the gate to Debug without changing the output level.
+func (l *Logger) enableDebugCapture() {
+	if l.gateLevel.Load() > slog.LevelDebug {
+		l.gateLevel.Store(slog.LevelDebug)
+	}
+}
@@ -0,0 +1,94 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type debugFlagKey struct{}
+
+func TestSetDebugSampler(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetDebugSampler(nil) })
+
+	SetDebugSampler(func(ctx context.Context) bool {
+		return ctx.Value(debugFlagKey{}) != nil
+	})
+
+	sampled := NewContext(context.WithValue(context.Background(), debugFlagKey{}, true))
+	DebugAdd(sampled, "cache", "hit")
+
+	l := GetLogger(sampled)
+	if l.fields["cache"] != "hit" {
+		t.Error("Expected debug field to be captured for sampled context")
+	}
+	if l.level != slog.LevelInfo {
+		t.Errorf("Expected output level to stay Info, got %v", l.level)
+	}
+
+	Flush(sampled)
+	if entry := decodeEntry(t, buf); entry["level"] != "INFO" {
+		t.Errorf("Expected entry at INFO, got %v", entry["level"])
+	}
+
+	unsampled := NewContext(context.Background())
+	DebugAdd(unsampled, "cache", "hit")
+	if GetLogger(unsampled).HasField("cache") {
+		t.Error("Expected debug field to be ignored for unsampled context")
+	}
+}
+
+func TestSetDebugSamplerNil(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	SetDebugSampler(func(context.Context) bool { return true })
+	SetDebugSampler(nil)
+
+	ctx := NewContext(context.Background())
+	if l := GetLogger(ctx); l.gateLevel.Load() != slog.LevelInfo {
+		t.Errorf("Expected gate level Info after removing sampler, got %v", l.gateLevel.Load())
+	}
+}
+
+func TestForceDebug(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(ForceDebug(context.Background()))
+	DebugAdd(ctx, "sql", "SELECT 1")
+
+	l := GetLogger(ctx)
+	if !l.HasField("sql") {
+		t.Error("Expected debug field to be captured for forced context")
+	}
+	if l.Level() != slog.LevelInfo {
+		t.Errorf("Expected output level to stay Info, got %v", l.Level())
+	}
+}
+
+func TestForceDebugExistingLogger(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := ForceDebug(NewContext(context.Background()))
+	DebugAdd(ctx, "sql", "SELECT 1")
+
+	if GetLogger(ctx).HasField("sql") {
+		t.Error("ForceDebug should not affect a logger created before it")
+	}
+}
+
+func TestForceDebugOutputLevelAfterFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(ForceDebug(context.Background()))
+	for i := range 2 {
+		buf.Reset()
+		DebugAdd(ctx, "batch", i)
+		Flush(ctx)
+		if entry := decodeEntry(t, buf); entry["level"] != "INFO" {
+			t.Errorf("Expected entry %d at INFO, got %v", i, entry["level"])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+)
+
+// EMFConfig configures EMFHook.
+type EMFConfig struct {
+	// Namespace is the CloudWatch metric namespace, for example "checkout".
+	Namespace string
+
+	// Metrics maps the numeric fields to publish to their CloudWatch unit,
+	// such as "Milliseconds", "Bytes", or "Count". An empty unit is "None".
+	// time.Duration values are published in milliseconds.
+	Metrics map[string]string
+
+	// Dimensions lists fields whose values become metric dimensions, for
+	// example "route" and "status". Fields absent from an entry are left out
+	// of its dimension set.
+	Dimensions []string
+
+	// Output is where EMF documents are written. Defaults to os.Stdout, which
+	// the CloudWatch agent and Lambda read.
+	Output io.Writer
+}
+
+// emfMetadata is the _aws block of a CloudWatch Embedded Metric Format event.
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// EMFHook returns a flush hook that writes the configured numeric fields of
+// each entry as a CloudWatch Embedded Metric Format document, alongside the
+// canonical line, so CloudWatch extracts metrics from logs on Lambda and ECS
+// without a metrics agent. Entries without any of the metric fields are
+// skipped.
+//
+// Example:
+//
+//	canonlog.AddFlushHook(canonlog.EMFHook(canonlog.EMFConfig{
+//		Namespace:  "checkout",
+//		Metrics:    map[string]string{"duration_ms": "Milliseconds", "response_size": "Bytes", "db_queries": "Count"},
+//		Dimensions: []string{"route"},
+//	}))
+func EMFHook(cfg EMFConfig) FlushHook {
+	w := cfg.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	names := make([]string, 0, len(cfg.Metrics))
+	for name := range cfg.Metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var mu sync.Mutex
+	return func(ctx context.Context, e Entry) {
+		doc, ok := emfEvent(cfg, names, e)
+		if !ok {
+			return
+		}
+		line, err := json.Marshal(doc)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+// emfEvent builds the EMF document for e from the metric fields named in
+// names, in order.
+func emfEvent(cfg EMFConfig, names []string, e Entry) (map[string]any, bool) {
+	doc := make(map[string]any, len(names)+len(cfg.Dimensions)+1)
+	directive := emfDirective{Namespace: cfg.Namespace, Dimensions: [][]string{{}}}
+	for _, name := range names {
+		v, ok := toFloat(e.Fields[name])
+		if !ok {
+			continue
+		}
+		unit := cfg.Metrics[name]
+		if unit == "" {
+			unit = "None"
+		}
+		doc[name] = v
+		directive.Metrics = append(directive.Metrics, emfMetric{Name: name, Unit: unit})
+	}
+	if len(directive.Metrics) == 0 {
+		return nil, false
+	}
+	for _, dim := range cfg.Dimensions {
+		v, ok := e.Fields[dim]
+		if !ok {
+			continue
+		}
+		doc[dim] = fmt.Sprint(v)
+		directive.Dimensions[0] = append(directive.Dimensions[0], dim)
+	}
+	doc["_aws"] = emfMetadata{
+		Timestamp:         defaultClock().Now().UnixMilli(),
+		CloudWatchMetrics: []emfDirective{directive},
+	}
+	return doc, true
+}
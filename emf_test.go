@@ -0,0 +1,64 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestEMFHook(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	resetFlushHooks(t)
+
+	var buf bytes.Buffer
+	AddFlushHook(EMFHook(EMFConfig{
+		Namespace:  "checkout",
+		Metrics:    map[string]string{"duration_ms": "Milliseconds", "response_size": "Bytes", "db_queries": ""},
+		Dimensions: []string{"route", "region"},
+		Output:     &buf,
+	}))
+
+	New().InfoAddMany(map[string]any{
+		"route":       "/pay",
+		"duration_ms": 250 * time.Millisecond,
+		"db_queries":  3,
+		"user_id":     "u_1",
+	}).Flush(context.Background())
+	New().InfoAdd("route", "/healthz").Flush(context.Background())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("Expected one EMF document, got %d: %s", len(lines), buf.String())
+	}
+	var doc struct {
+		AWS struct {
+			Timestamp         int64
+			CloudWatchMetrics []emfDirective
+		} `json:"_aws"`
+		Route      string  `json:"route"`
+		DurationMS float64 `json:"duration_ms"`
+		DBQueries  float64 `json:"db_queries"`
+		UserID     *string `json:"user_id"`
+	}
+	if err := json.Unmarshal(lines[0], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Route != "/pay" || doc.DurationMS != 250 || doc.DBQueries != 3 || doc.UserID != nil {
+		t.Errorf("Expected metric and dimension values only, got %s", lines[0])
+	}
+	if doc.AWS.Timestamp == 0 || len(doc.AWS.CloudWatchMetrics) != 1 {
+		t.Fatalf("Expected an _aws directive, got %s", lines[0])
+	}
+	d := doc.AWS.CloudWatchMetrics[0]
+	if d.Namespace != "checkout" || len(d.Dimensions) != 1 || len(d.Dimensions[0]) != 1 || d.Dimensions[0][0] != "route" {
+		t.Errorf("Expected namespace checkout with dimension route, got %+v", d)
+	}
+	want := []emfMetric{{"db_queries", "None"}, {"duration_ms", "Milliseconds"}}
+	if len(d.Metrics) != len(want) || d.Metrics[0] != want[0] || d.Metrics[1] != want[1] {
+		t.Errorf("Expected metrics %v, got %v", want, d.Metrics)
+	}
+}
@@ -0,0 +1,158 @@
+package canonlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a log record passed to an Encoder.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+
+	// Attrs holds the record's attributes, including those added with
+	// slog.Logger.With, with groups already nested. Canonical lines put
+	// every field here.
+	Attrs []slog.Attr
+}
+
+// Encoder turns a record into bytes for output, for proprietary formats such as
+// internal schemas or protobuf-encoded logs. The returned bytes are written as
+// is, so the encoder adds any delimiter, such as a trailing newline or a length
+// prefix. Encode may be called concurrently.
+type Encoder interface {
+	Encode(r Record) ([]byte, error)
+}
+
+// EncoderFunc adapts a function to an Encoder.
+type EncoderFunc func(r Record) ([]byte, error)
+
+// Encode implements Encoder.
+func (f EncoderFunc) Encode(r Record) ([]byte, error) {
+	return f(r)
+}
+
+// encoders holds encoders registered by format name.
+var (
+	encodersMu sync.RWMutex
+	encoders   = make(map[string]Encoder)
+)
+
+// RegisterEncoder makes enc available to SetupGlobalLogger under name, which
+// is matched case-insensitively. The built-in "json" and "text" formats
+// cannot be replaced. Passing a nil enc removes the registration.
+//
+// Example:
+//
+//	canonlog.RegisterEncoder("proto", canonlog.EncoderFunc(encodeProto))
+//	canonlog.SetupGlobalLogger("info", "proto")
+func RegisterEncoder(name string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if enc == nil {
+		delete(encoders, strings.ToLower(name))
+		return
+	}
+	encoders[strings.ToLower(name)] = enc
+}
+
+// lookupEncoder returns the encoder registered under name, if any.
+func lookupEncoder(name string) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	enc, ok := encoders[strings.ToLower(name)]
+	return enc, ok
+}
+
+// encoderHandler is a slog.Handler that writes records with an Encoder.
+type encoderHandler struct {
+	enc   Encoder
+	level slog.Leveler
+	mu    *sync.Mutex
+	w     io.Writer
+	chain []groupOrAttrs
+}
+
+// groupOrAttrs is one WithGroup or WithAttrs call on a handler.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewEncoderHandler returns a slog.Handler that encodes records with enc and
+// writes them to w, discarding records below level. A nil level means Info.
+func NewEncoderHandler(w io.Writer, enc Encoder, level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &encoderHandler{enc: enc, level: level, mu: &sync.Mutex{}, w: w}
+}
+
+// Enabled implements slog.Handler.
+func (h *encoderHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle implements slog.Handler.
+func (h *encoderHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := recordAttrs(r, h.chain)
+	b, err := h.enc.Encode(Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: attrs})
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.w.Write(b)
+	return err
+}
+
+// WithAttrs implements slog.Handler.
+func (h *encoderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup implements slog.Handler.
+func (h *encoderHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+// recordAttrs returns r's attributes, resolved, with the attributes and groups
+// of a handler's WithAttrs and WithGroup calls applied.
+func recordAttrs(r slog.Record, chain []groupOrAttrs) []slog.Attr {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		a.Value = a.Value.Resolve()
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// Apply WithGroup and WithAttrs from the innermost call outwards
+	for i := len(chain) - 1; i >= 0; i-- {
+		goa := chain[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			}
+			continue
+		}
+		attrs = append(append([]slog.Attr(nil), goa.attrs...), attrs...)
+	}
+	return attrs
+}
+
+func (h *encoderHandler) with(goa groupOrAttrs) *encoderHandler {
+	h2 := *h
+	h2.chain = append(h.chain[:len(h.chain):len(h.chain)], goa)
+	return &h2
+}
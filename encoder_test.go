@@ -0,0 +1,76 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// kvEncoder writes records as "LEVEL k=v k=v" lines, flattening groups with dots.
+var kvEncoder = EncoderFunc(func(r Record) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	var write func(prefix string, attrs []slog.Attr)
+	write = func(prefix string, attrs []slog.Attr) {
+		for _, a := range attrs {
+			if a.Value.Kind() == slog.KindGroup {
+				write(prefix+a.Key+".", a.Value.Group())
+				continue
+			}
+			fmt.Fprintf(&b, " %s%s=%v", prefix, a.Key, a.Value)
+		}
+	}
+	write("", r.Attrs)
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+})
+
+func TestEncoderHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewEncoderHandler(&buf, kvEncoder, slog.LevelInfo))
+
+	logger.Debug("", "skipped", true)
+	logger.With("service", "api").WithGroup("req").With("id", "1").Info("", "status", 200)
+	logger.WithGroup("empty").Warn("")
+
+	want := "INFO service=api req.id=1 req.status=200\nWARN\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestEncoderHandlerWithCanonicalLine(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(NewEncoderHandler(&buf, kvEncoder, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	l := New()
+	l.InfoAdd("user_id", "123")
+	l.Flush(context.Background())
+
+	if got := buf.String(); got != "INFO user_id=123\n" {
+		t.Errorf("Expected encoded canonical line, got %q", got)
+	}
+}
+
+func TestSetupGlobalLoggerRegisteredEncoder(t *testing.T) {
+	old := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(old)
+		resetSetupOnce()
+	})
+	RegisterEncoder("KV", kvEncoder)
+	t.Cleanup(func() { RegisterEncoder("kv", nil) })
+
+	resetSetupOnce()
+	SetupGlobalLogger("info", "kv")
+
+	if _, ok := slog.Default().Handler().(*encoderHandler); !ok {
+		t.Errorf("Expected registered encoder to be used, got %T", slog.Default().Handler())
+	}
+}
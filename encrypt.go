@@ -0,0 +1,240 @@
+package canonlog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Fields and markers written by field encryption.
+const (
+	dataKeyKey        = "canonlog_data_key"
+	encryptedPrefix   = "enc:v1:"
+	encryptFailedMark = "[ENCRYPTION FAILED]"
+)
+
+// EncryptionConfig configures field encryption.
+type EncryptionConfig struct {
+	// Fields lists the keys whose values are encrypted, as emitted after key
+	// normalization.
+	Fields []string
+
+	// WrapKey encrypts a generated 256-bit data key with your key encryption
+	// key, typically with a KMS Encrypt call. The wrapped key is emitted as
+	// canonlog_data_key on every entry with encrypted fields, so decryption
+	// tooling can unwrap it and call DecryptField.
+	WrapKey func(dataKey []byte) ([]byte, error)
+
+	// KeyRotation is how long a data key is used before a new one is
+	// generated and wrapped, which bounds the number of WrapKey calls.
+	// Defaults to one hour.
+	KeyRotation time.Duration
+}
+
+// encryptRetryDelay is how long a WrapKey failure is reused before WrapKey
+// is called again, so a KMS outage costs one call per delay rather than one
+// per entry.
+const encryptRetryDelay = 5 * time.Second
+
+// encryption holds the encryptor installed by SetEncryptionConfig.
+var encryption atomic.Pointer[encryptor]
+
+// encryptor encrypts field values with a periodically rotated data key.
+type encryptor struct {
+	cfg EncryptionConfig
+
+	mu       sync.Mutex
+	aead     cipher.AEAD
+	wrapped  string
+	expires  time.Time
+	rotating bool      // a replacement key is being wrapped in the background
+	err      error     // the last WrapKey failure
+	retryAt  time.Time // when WrapKey may be called again after err
+}
+
+// SetEncryptionConfig enables envelope encryption of designated fields, so
+// values that are sensitive but needed, such as account numbers, can be
+// logged and later read by authorized tooling. At emit, each value is encoded
+// as JSON and sealed with AES-256-GCM under a data key, bound to its field
+// key, and replaced by "enc:v1:" and the base64 nonce and ciphertext. If the
+// data key cannot be wrapped, the values are replaced with
+// "[ENCRYPTION FAILED]" rather than emitted in the clear, and wrapping is
+// retried after five seconds. Keys are replaced in the background during the
+// last tenth of KeyRotation, so entries do not wait on WrapKey while one is
+// in use. Designated fields
+// that are Limiter keys or the Aggregator's KeyField are sealed the same way
+// in summary and rollup lines. Passing a zero config disables encryption.
+//
+// Example:
+//
+//	canonlog.SetEncryptionConfig(canonlog.EncryptionConfig{
+//		Fields: []string{"account_number", "email"},
+//		WrapKey: func(dataKey []byte) ([]byte, error) {
+//			out, err := kmsClient.Encrypt(ctx, &kms.EncryptInput{KeyId: &keyID, Plaintext: dataKey})
+//			if err != nil {
+//				return nil, err
+//			}
+//			return out.CiphertextBlob, nil
+//		},
+//	})
+func SetEncryptionConfig(cfg EncryptionConfig) {
+	if len(cfg.Fields) == 0 || cfg.WrapKey == nil {
+		encryption.Store(nil)
+		return
+	}
+	if cfg.KeyRotation <= 0 {
+		cfg.KeyRotation = time.Hour
+	}
+	cfg.Fields = slices.Clone(cfg.Fields)
+	encryption.Store(&encryptor{cfg: cfg})
+}
+
+// encryptFields replaces designated values in fields with their ciphertext.
+func encryptFields(fields map[string]any) {
+	e := encryption.Load()
+	if e == nil {
+		return
+	}
+	var found bool
+	for _, k := range e.cfg.Fields {
+		if _, ok := fields[k]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	aead, wrapped, err := e.key()
+	for _, k := range e.cfg.Fields {
+		v, ok := fields[k]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			fields[k] = encryptFailedMark
+			continue
+		}
+		plaintext, merr := json.Marshal(v)
+		if merr != nil {
+			plaintext, _ = json.Marshal(fmt.Sprint(v))
+		}
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+		rand.Read(nonce)
+		fields[k] = encryptedPrefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, []byte(k)))
+	}
+	if err == nil {
+		fields[dataKeyKey] = wrapped
+	}
+}
+
+// key returns the current data key's cipher and wrapped form. Without a
+// usable key, it generates and wraps one, holding the lock so concurrent
+// entries wait for that call instead of making their own, unless the last
+// attempt failed within encryptRetryDelay. Near expiry, the key is replaced
+// in the background.
+func (e *encryptor) key() (cipher.AEAD, string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := defaultClock().Now()
+	if e.aead != nil && now.Before(e.expires) {
+		if !e.rotating && !now.Before(e.expires.Add(-e.cfg.KeyRotation/10)) && !now.Before(e.retryAt) {
+			e.rotating = true
+			go e.rotate()
+		}
+		return e.aead, e.wrapped, nil
+	}
+	if e.err != nil && now.Before(e.retryAt) {
+		return nil, "", e.err
+	}
+	aead, wrapped, err := e.newKey()
+	e.install(aead, wrapped, err, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return aead, wrapped, nil
+}
+
+// rotate replaces the current key before it expires. On failure the current
+// key stays in use until it expires.
+func (e *encryptor) rotate() {
+	aead, wrapped, err := e.newKey()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rotating = false
+	e.install(aead, wrapped, err, defaultClock().Now())
+}
+
+// install records the result of newKey at now. Must be called with e.mu held.
+func (e *encryptor) install(aead cipher.AEAD, wrapped string, err error, now time.Time) {
+	if err != nil {
+		e.err, e.retryAt = err, now.Add(encryptRetryDelay)
+		return
+	}
+	e.aead, e.wrapped, e.expires = aead, wrapped, now.Add(e.cfg.KeyRotation)
+	e.err, e.retryAt = nil, time.Time{}
+}
+
+// newKey generates a data key and wraps it with WrapKey.
+func (e *encryptor) newKey() (cipher.AEAD, string, error) {
+	dataKey := make([]byte, 32)
+	rand.Read(dataKey)
+	wrapped, err := e.cfg.WrapKey(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return aead, base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var errNotEncrypted = errors.New("canonlog: value is not an encrypted field")
+
+// DecryptField returns the JSON encoding of a value encrypted under key, given
+// the entry's data key already unwrapped from canonlog_data_key, for
+// decryption tooling.
+//
+// Example:
+//
+//	wrapped, _ := base64.StdEncoding.DecodeString(entry["canonlog_data_key"])
+//	dataKey := kmsDecrypt(wrapped)
+//	plaintext, err := canonlog.DecryptField("email", entry["email"], dataKey)
+func DecryptField(key, value string, dataKey []byte) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return nil, errNotEncrypted
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errNotEncrypted
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errNotEncrypted
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(key))
+}
@@ -0,0 +1,302 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setEncryptionConfig(t *testing.T, cfg EncryptionConfig) {
+	t.Helper()
+	SetEncryptionConfig(cfg)
+	t.Cleanup(func() { SetEncryptionConfig(EncryptionConfig{}) })
+}
+
+// xorWrap is a stand-in for a KMS that wraps keys by XOR with a fixed byte.
+func xorWrap(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out
+}
+
+func TestEncryptFields(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	wraps := 0
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields: []string{"email", "account"},
+		WrapKey: func(dataKey []byte) ([]byte, error) {
+			wraps++
+			return xorWrap(dataKey), nil
+		},
+	})
+
+	l := New()
+	l.InfoAdd("email", "a@example.com")
+	l.InfoAdd("account", 12345)
+	l.InfoAdd("status", 200)
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry["status"] != float64(200) {
+		t.Errorf("Expected status in the clear, got %v", entry["status"])
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(entry[dataKeyKey].(string))
+	if err != nil {
+		t.Fatalf("Expected base64 data key, got %v", entry[dataKeyKey])
+	}
+	dataKey := xorWrap(wrapped)
+	for key, want := range map[string]string{"email": `"a@example.com"`, "account": "12345"} {
+		value, _ := entry[key].(string)
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			t.Fatalf("Expected %s encrypted, got %v", key, entry[key])
+		}
+		plaintext, err := DecryptField(key, value, dataKey)
+		if err != nil {
+			t.Fatalf("DecryptField(%s): %v", key, err)
+		}
+		if string(plaintext) != want {
+			t.Errorf("Expected %s to decrypt to %s, got %s", key, want, plaintext)
+		}
+	}
+	if _, err := DecryptField("account", entry["email"].(string), dataKey); err == nil {
+		t.Error("Expected ciphertext to be bound to its field key")
+	}
+
+	// The data key is reused until it rotates, and only entries with
+	// designated fields carry it
+	buf.Reset()
+	l.InfoAdd("email", "b@example.com")
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry[dataKeyKey] != base64.StdEncoding.EncodeToString(wrapped) {
+		t.Errorf("Expected data key reused, got %v", entry[dataKeyKey])
+	}
+	buf.Reset()
+	l.InfoAdd("status", 200)
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry[dataKeyKey] != nil {
+		t.Errorf("Expected no data key without encrypted fields, got %v", entry[dataKeyKey])
+	}
+	if wraps != 1 {
+		t.Errorf("Expected 1 WrapKey call, got %d", wraps)
+	}
+}
+
+func TestEncryptFieldsRotation(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	wraps := 0
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields:      []string{"email"},
+		KeyRotation: time.Nanosecond,
+		WrapKey: func(dataKey []byte) ([]byte, error) {
+			wraps++
+			return xorWrap(dataKey), nil
+		},
+	})
+
+	l := New()
+	for range 2 {
+		l.InfoAdd("email", "a@example.com")
+		l.Flush(context.Background())
+		time.Sleep(time.Millisecond)
+	}
+	if wraps != 2 {
+		t.Errorf("Expected a new data key per entry, got %d WrapKey calls", wraps)
+	}
+}
+
+func TestEncryptFieldsWrapFailure(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields:  []string{"email"},
+		WrapKey: func([]byte) ([]byte, error) { return nil, errors.New("kms unavailable") },
+	})
+
+	l := New()
+	l.InfoAdd("email", "a@example.com")
+	l.Flush(context.Background())
+	if bytes.Contains(buf.Bytes(), []byte("a@example.com")) {
+		t.Fatalf("Expected value not emitted in the clear: %s", buf)
+	}
+	entry := decodeEntry(t, buf)
+	if entry["email"] != encryptFailedMark || entry[dataKeyKey] != nil {
+		t.Errorf("Expected %s without data key, got %v", encryptFailedMark, entry)
+	}
+}
+
+func TestEncryptFieldsWrapFailureBackoff(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	SetClock(clock)
+	t.Cleanup(func() { SetClock(nil) })
+	var wraps atomic.Int32
+	fail := true
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields: []string{"email"},
+		WrapKey: func(dataKey []byte) ([]byte, error) {
+			wraps.Add(1)
+			if fail {
+				return nil, errors.New("kms unavailable")
+			}
+			return xorWrap(dataKey), nil
+		},
+	})
+
+	for range 3 {
+		New().InfoAdd("email", "a@example.com").Flush(context.Background())
+	}
+	if wraps.Load() != 1 {
+		t.Errorf("Expected the failure reused within the retry delay, got %d WrapKey calls", wraps.Load())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("a@example.com")) {
+		t.Fatalf("Expected values not emitted in the clear: %s", buf)
+	}
+
+	fail = false
+	clock.Advance(encryptRetryDelay)
+	buf.Reset()
+	New().InfoAdd("email", "a@example.com").Flush(context.Background())
+	if wraps.Load() != 2 {
+		t.Errorf("Expected WrapKey retried after the delay, got %d calls", wraps.Load())
+	}
+	assertDecrypts(t, decodeEntry(t, buf), "email", `"a@example.com"`)
+}
+
+func TestEncryptFieldsRotatesAhead(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	SetClock(clock)
+	t.Cleanup(func() { SetClock(nil) })
+	var wraps atomic.Int32
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields:      []string{"email"},
+		KeyRotation: time.Hour,
+		WrapKey: func(dataKey []byte) ([]byte, error) {
+			wraps.Add(1)
+			return xorWrap(dataKey), nil
+		},
+	})
+	flush := func() any {
+		buf.Reset()
+		New().InfoAdd("email", "a@example.com").Flush(context.Background())
+		return decodeEntry(t, buf)[dataKeyKey]
+	}
+
+	first := flush()
+	clock.Advance(55 * time.Minute)
+	if got := flush(); got != first {
+		t.Errorf("Expected the current key used while its replacement is wrapped, got %v", got)
+	}
+	for wraps.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	e := encryption.Load()
+	for {
+		e.mu.Lock()
+		rotating := e.rotating
+		e.mu.Unlock()
+		if !rotating {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := flush(); got == first {
+		t.Error("Expected the replacement key after rotation")
+	}
+	if wraps.Load() != 2 {
+		t.Errorf("Expected 2 WrapKey calls, got %d", wraps.Load())
+	}
+}
+
+func TestEncryptFieldsLimiterSummary(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields:  []string{"email"},
+		WrapKey: func(dataKey []byte) ([]byte, error) { return xorWrap(dataKey), nil },
+	})
+	lim := NewLimiter(time.Hour, "email")
+	SetLimiter(lim)
+	t.Cleanup(func() { SetLimiter(nil) })
+
+	ctx := context.Background()
+	for range 3 {
+		New().InfoAdd("email", "a@example.com").Flush(ctx)
+	}
+	buf.Reset()
+	lim.FlushSuppressed(ctx)
+	if bytes.Contains(buf.Bytes(), []byte("a@example.com")) {
+		t.Fatalf("Expected summary without the value in the clear: %s", buf)
+	}
+	entry := decodeEntry(t, buf)
+	assertDecrypts(t, entry, "email", `"a@example.com"`)
+	if entry[suppressedCountKey] != float64(2) {
+		t.Errorf("Expected %s=2, got %v", suppressedCountKey, entry[suppressedCountKey])
+	}
+}
+
+func TestEncryptFieldsAggregatorRollup(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setEncryptionConfig(t, EncryptionConfig{
+		Fields:  []string{"tenant"},
+		WrapKey: func(dataKey []byte) ([]byte, error) { return xorWrap(dataKey), nil },
+	})
+	agg := NewAggregator(AggregatorConfig{KeyField: "tenant", Keys: []string{"acme"}})
+	SetAggregator(agg)
+	t.Cleanup(func() { SetAggregator(nil) })
+
+	ctx := context.Background()
+	for range 2 {
+		New().InfoAdd("tenant", "acme").Flush(ctx)
+	}
+	agg.Emit(ctx)
+	if bytes.Contains(buf.Bytes(), []byte("acme")) {
+		t.Fatalf("Expected rollup without the key in the clear: %s", buf)
+	}
+	entry := decodeEntry(t, buf)
+	assertDecrypts(t, entry, "tenant", `"acme"`)
+	if entry["count"] != float64(2) {
+		t.Errorf("Expected count=2, got %v", entry["count"])
+	}
+}
+
+// assertDecrypts checks that entry's key field decrypts to want under the
+// entry's data key.
+func assertDecrypts(t *testing.T, entry map[string]any, key, want string) {
+	t.Helper()
+	wrapped, err := base64.StdEncoding.DecodeString(fmt.Sprint(entry[dataKeyKey]))
+	if err != nil || entry[dataKeyKey] == nil {
+		t.Fatalf("Expected base64 data key, got %v", entry[dataKeyKey])
+	}
+	value, _ := entry[key].(string)
+	plaintext, err := DecryptField(key, value, xorWrap(wrapped))
+	if err != nil {
+		t.Fatalf("DecryptField(%s, %v): %v", key, entry[key], err)
+	}
+	if string(plaintext) != want {
+		t.Errorf("Expected %s to decrypt to %s, got %s", key, want, plaintext)
+	}
+}
+
+func TestDecryptFieldInvalid(t *testing.T) {
+	key := make([]byte, 32)
+	for _, value := range []string{"plain", "enc:v1:!!", "enc:v1:AAAA"} {
+		if _, err := DecryptField("k", value, key); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
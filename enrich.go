@@ -0,0 +1,58 @@
+package canonlog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Enricher adds derived fields to an entry at Flush, before it is emitted,
+// such as the location of the client IP. It may read fields and add to them,
+// but must not retain the map. The context is the one passed to Flush.
+type Enricher func(ctx context.Context, fields map[string]any)
+
+// enrichers holds the registered enrichers. It is replaced wholesale on
+// registration so Flush can read it without locking.
+var (
+	enrichers   atomic.Pointer[[]Enricher]
+	enrichersMu sync.Mutex
+)
+
+// AddEnricher registers an enricher that runs on every Flush that emits an
+// entry. Enrichers run synchronously, in registration order, on the goroutine
+// calling Flush and outside the logger's lock, so they should be fast; cache
+// anything that needs network calls. Fields they add are not subject to the
+// logger's limits.
+//
+// Example:
+//
+//	canonlog.AddEnricher(func(ctx context.Context, fields map[string]any) {
+//		if tenant, ok := fields["tenant_id"].(string); ok {
+//			fields["tenant_plan"] = plans.Lookup(tenant)
+//		}
+//	})
+func AddEnricher(e Enricher) {
+	if e == nil {
+		return
+	}
+	enrichersMu.Lock()
+	defer enrichersMu.Unlock()
+
+	var list []Enricher
+	if current := enrichers.Load(); current != nil {
+		list = append(list, *current...)
+	}
+	list = append(list, e)
+	enrichers.Store(&list)
+}
+
+// runEnrichers passes fields to every registered enricher.
+func runEnrichers(ctx context.Context, fields map[string]any) {
+	list := enrichers.Load()
+	if list == nil {
+		return
+	}
+	for _, e := range *list {
+		e(ctx, fields)
+	}
+}
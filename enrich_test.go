@@ -0,0 +1,48 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func resetEnrichers(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { enrichers.Store(nil) })
+}
+
+func TestAddEnricher(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	resetEnrichers(t)
+
+	type ctxKey struct{}
+	AddEnricher(func(ctx context.Context, fields map[string]any) {
+		if tenant, ok := fields["tenant_id"].(string); ok {
+			fields["tenant_plan"] = tenant + "-pro"
+		}
+	})
+	AddEnricher(func(ctx context.Context, fields map[string]any) {
+		fields["region"] = ctx.Value(ctxKey{})
+		if _, ok := fields["tenant_plan"]; !ok {
+			t.Error("Expected enrichers to run in registration order")
+		}
+	})
+	AddEnricher(nil)
+
+	l := New()
+	l.InfoAdd("tenant_id", "acme")
+	l.Flush(context.WithValue(context.Background(), ctxKey{}, "eu"))
+	entry := decodeEntry(t, buf)
+
+	if entry["tenant_plan"] != "acme-pro" || entry["region"] != "eu" {
+		t.Errorf("Expected enriched fields, got %v", entry)
+	}
+
+	// Nothing accumulated means nothing to enrich
+	buf.Reset()
+	l.Flush(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for an empty logger, got %q", buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package canonlog
+
+import "errors"
+
+// errorDetailsKey is the key under which structured error metadata is emitted.
+const errorDetailsKey = "error_details"
+
+// Error wraps an error with metadata that is emitted in structured form at Flush.
+// ErrorAdd detects an *Error anywhere in the wrapped chain, so it may itself be
+// wrapped with fmt.Errorf and %w.
+//
+// Each *Error recorded on a logger produces an object in the "error_details"
+// array alongside the plain "errors" array:
+//
+//	err := canonlog.NewError(err).
+//		WithCode("PAYMENT_DECLINED").
+//		WithCategory("upstream").
+//		Retryable(true)
+//	canonlog.ErrorAdd(ctx, err)
+//	// error_details=[{"message":"...","code":"PAYMENT_DECLINED","category":"upstream","retryable":true}]
+type Error struct {
+	err          error
+	code         string
+	category     string
+	retryable    bool
+	retryableSet bool
+}
+
+// NewError wraps err so metadata can be attached to it.
+// Returns nil if err is nil.
+//
+// The nil is a typed *Error, so the With methods can still be chained on it.
+// Stored in an error interface it is not == nil, so check err before wrapping
+// it in a function that returns error. ErrorAdd and WarnError ignore a nil
+// *Error, and its methods are safe to call.
+func NewError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{err: err}
+}
+
+// WithCode sets a machine-readable error code, such as "PAYMENT_DECLINED".
+func (e *Error) WithCode(code string) *Error {
+	if e != nil {
+		e.code = code
+	}
+	return e
+}
+
+// WithCategory sets a coarse error category, such as "upstream" or "validation".
+func (e *Error) WithCategory(category string) *Error {
+	if e != nil {
+		e.category = category
+	}
+	return e
+}
+
+// Retryable marks whether the failed operation can be retried.
+func (e *Error) Retryable(retryable bool) *Error {
+	if e != nil {
+		e.retryable = retryable
+		e.retryableSet = true
+	}
+	return e
+}
+
+// Code returns the error code, or "" if none was set.
+func (e *Error) Code() string {
+	if e == nil {
+		return ""
+	}
+	return e.code
+}
+
+// Category returns the error category, or "" if none was set.
+func (e *Error) Category() string {
+	if e == nil {
+		return ""
+	}
+	return e.category
+}
+
+// IsRetryable reports whether the error was marked retryable.
+func (e *Error) IsRetryable() bool {
+	return e != nil && e.retryable
+}
+
+// Error returns the message of the wrapped error, or "<nil>" for a nil *Error.
+func (e *Error) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.err
+}
+
+// details returns the structured representation of err emitted in error_details.
+func (e *Error) details(err error) map[string]any {
+	d := map[string]any{"message": err.Error()}
+	if e.code != "" {
+		d["code"] = e.code
+	}
+	if e.category != "" {
+		d["category"] = e.category
+	}
+	if e.retryableSet {
+		d["retryable"] = e.retryable
+	}
+	return d
+}
+
+// errorDetails builds the error_details array for errs, returning nil if none
+// of them carry metadata. When sources are recorded, every error gets an
+// entry carrying its source location.
+func errorDetails(errs []error, sources []errorSource) []map[string]any {
+	var details []map[string]any
+	for i, err := range errs {
+		var d map[string]any
+		var ce *Error
+		if errors.As(err, &ce) && ce != nil {
+			d = ce.details(err)
+		}
+		if i < len(sources) && sources[i].file != "" {
+			if d == nil {
+				d = map[string]any{"message": err.Error()}
+			}
+			sources[i].addTo(d)
+		}
+		if d != nil {
+			details = append(details, d)
+		}
+	}
+	return details
+}
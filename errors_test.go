@@ -0,0 +1,101 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestNewError(t *testing.T) {
+	base := errors.New("card declined")
+	err := NewError(base).WithCode("PAYMENT_DECLINED").WithCategory("upstream").Retryable(true)
+
+	if err.Error() != "card declined" {
+		t.Errorf("Expected message 'card declined', got %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("Error should unwrap to the base error")
+	}
+	if err.Code() != "PAYMENT_DECLINED" || err.Category() != "upstream" || !err.IsRetryable() {
+		t.Errorf("Unexpected metadata: code=%q category=%q retryable=%v", err.Code(), err.Category(), err.IsRetryable())
+	}
+}
+
+func TestNewErrorNil(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	err := NewError(nil).WithCode("X")
+	if err != nil {
+		t.Fatal("NewError(nil) should return nil")
+	}
+
+	l := New()
+	l.ErrorAdd(err)
+	if len(l.errors) != 0 {
+		t.Errorf("Expected nil *Error to be ignored, got %d errors", len(l.errors))
+	}
+	l.WarnError(err)
+	if len(l.warnings) != 0 {
+		t.Errorf("Expected nil *Error to be ignored, got %d warnings", len(l.warnings))
+	}
+}
+
+func TestNilErrorAccessors(t *testing.T) {
+	var err *Error
+	if err.Code() != "" || err.Category() != "" || err.IsRetryable() || err.Unwrap() != nil {
+		t.Errorf("nil *Error metadata: code=%q category=%q retryable=%v", err.Code(), err.Category(), err.IsRetryable())
+	}
+	if err.Error() != "<nil>" {
+		t.Errorf("Error() = %q, want <nil>", err.Error())
+	}
+}
+
+func TestErrorDetailsFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.ErrorAdd(errors.New("plain"))
+	l.ErrorAdd(fmt.Errorf("charge: %w", NewError(errors.New("declined")).WithCode("PAYMENT_DECLINED").Retryable(false)))
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+
+	errs, ok := entry["errors"].([]any)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", entry["errors"])
+	}
+
+	details, ok := entry[errorDetailsKey].([]any)
+	if !ok || len(details) != 1 {
+		t.Fatalf("Expected 1 error detail, got %v", entry[errorDetailsKey])
+	}
+	d := details[0].(map[string]any)
+	if d["message"] != "charge: declined" {
+		t.Errorf("Expected message 'charge: declined', got %v", d["message"])
+	}
+	if d["code"] != "PAYMENT_DECLINED" {
+		t.Errorf("Expected code PAYMENT_DECLINED, got %v", d["code"])
+	}
+	if d["retryable"] != false {
+		t.Errorf("Expected retryable=false, got %v", d["retryable"])
+	}
+	if _, exists := d["category"]; exists {
+		t.Error("Unset category should be omitted")
+	}
+}
+
+func TestErrorDetailsOmittedForPlainErrors(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.ErrorAdd(errors.New("plain"))
+	l.Flush(context.Background())
+
+	if _, exists := decodeEntry(t, buf)[errorDetailsKey]; exists {
+		t.Errorf("Expected no %s for plain errors", errorDetailsKey)
+	}
+}
@@ -0,0 +1,49 @@
+package canonlog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// WithErrorSource records where each ErrorAdd call was made. Every error then
+// gets an entry in error_details with a source of the form "dir/file.go:42"
+// and the calling function, so the line shows where in the code an error was
+// recorded without a stack trace.
+func WithErrorSource() Option {
+	return func(l *Logger) {
+		l.errorSource = true
+	}
+}
+
+// errorSource is the call site of an ErrorAdd call.
+type errorSource struct {
+	file     string
+	line     int
+	function string
+}
+
+// callerSource returns the call site of the exported ErrorAdd function that
+// called errorAdd, which called callerSource.
+func callerSource() errorSource {
+	pc, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return errorSource{}
+	}
+	src := errorSource{
+		file: filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file)),
+		line: line,
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		src.function = fn.Name()
+	}
+	return src
+}
+
+// addTo adds the source fields to an error_details entry.
+func (s errorSource) addTo(d map[string]any) {
+	d["source"] = s.file + ":" + strconv.Itoa(s.line)
+	if s.function != "" {
+		d["function"] = s.function
+	}
+}
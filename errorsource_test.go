@@ -0,0 +1,54 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithErrorSource(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background(), WithErrorSource())
+	_, _, line, _ := runtime.Caller(0)
+	GetLogger(ctx).ErrorAdd(errors.New("method"))
+	ErrorAdd(ctx, NewError(errors.New("helper")).WithCode("E1"))
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	details, ok := entry["error_details"].([]any)
+	if !ok || len(details) != 2 {
+		t.Fatalf("Expected details for both errors, got %v", entry["error_details"])
+	}
+	for i, d := range details {
+		d := d.(map[string]any)
+		want := "errorsource_test.go:" + strconv.Itoa(line+1+i)
+		if src, _ := d["source"].(string); !strings.HasSuffix(src, want) {
+			t.Errorf("Expected source ending in %q, got %q", want, src)
+		}
+		if fn, _ := d["function"].(string); !strings.HasSuffix(fn, "TestWithErrorSource") {
+			t.Errorf("Expected calling function, got %q", fn)
+		}
+	}
+	if details[1].(map[string]any)["code"] != "E1" {
+		t.Errorf("Expected error metadata alongside source, got %v", details[1])
+	}
+}
+
+func TestErrorSourceDisabledByDefault(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.ErrorAdd(errors.New("plain"))
+	l.Flush(context.Background())
+
+	if entry := decodeEntry(t, buf); entry["error_details"] != nil {
+		t.Errorf("Expected no error_details without metadata, got %v", entry["error_details"])
+	}
+}
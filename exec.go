@@ -0,0 +1,104 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Fields recorded by Exec.
+const (
+	execCommandKey  = "exec_command"
+	execCountKey    = "exec_count"
+	execDurationKey = "exec_duration_ms"
+	execExitCodeKey = "exec_exit_code"
+	execStderrKey   = "exec_stderr"
+	execErrorKey    = "exec_error"
+)
+
+// execStderrLimit is the most stderr Exec records, from the end of the output.
+const execStderrLimit = 1024
+
+// Exec runs cmd, waiting for it to finish, and records it on the logger in ctx
+// at info level: exec_command is the program name, exec_count is
+// incremented, and exec_duration_ms and exec_exit_code describe the run, with
+// an exit code of -1 if the command did not start or was killed by a signal.
+// If the command exits unsuccessfully, the last 1 KiB of its stderr is
+// recorded as exec_stderr; if it cannot be started, for example because the
+// binary is missing, the error is recorded as exec_error. When a request runs
+// several commands, these fields describe the most recent one, and
+// exec_stderr and exec_error the most recent failure. Stderr is still written
+// to cmd.Stderr if set. The duration is measured with the logger's
+// Clock. It runs cmd without recording anything if ctx has no logger.
+//
+// Example:
+//
+//	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", src, "-vf", "scale=640:-1", dst)
+//	if err := canonlog.Exec(ctx, cmd); err != nil {
+//		return fmt.Errorf("transcoding: %w", err)
+//	}
+//	// exec_command=ffmpeg exec_count=1 exec_duration_ms=842.1 exec_exit_code=0
+func Exec(ctx context.Context, cmd *exec.Cmd) error {
+	l, ok := TryGetLogger(ctx)
+	if !ok || l.gateLevel.Load() > slog.LevelInfo {
+		return cmd.Run()
+	}
+	stderr := &tailBuffer{limit: execStderrLimit}
+	switch {
+	case cmd.Stderr == nil:
+		cmd.Stderr = stderr
+	case cmd.Stderr == cmd.Stdout:
+		// os/exec copies both streams through one pipe only while Stdout and
+		// Stderr are the same writer, so keep them the same
+		w := io.MultiWriter(cmd.Stdout, stderr)
+		cmd.Stdout, cmd.Stderr = w, w
+	default:
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	}
+	clock := l.Clock()
+	start := clock.Now()
+	err := cmd.Run()
+	elapsed := clock.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.storeField(execCommandKey, filepath.Base(cmd.Path))
+	n, _ := l.fields[execCountKey].(int)
+	l.storeField(execCountKey, n+1)
+	l.storeField(execDurationKey, float64(elapsed)/float64(time.Millisecond))
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	l.storeField(execExitCodeKey, code)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		if out := bytes.TrimSpace(stderr.buf); len(out) > 0 {
+			l.storeField(execStderrKey, string(out))
+		}
+	default:
+		l.storeField(execErrorKey, err.Error())
+	}
+	return err
+}
+
+// tailBuffer is a writer that keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.limit; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	var out bytes.Buffer
+	cmd := exec.Command("sh", "-c", "echo converted; echo progress >&2")
+	cmd.Stdout = &out
+	if err := Exec(ctx, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "converted\n" {
+		t.Errorf("Expected stdout to be passed through, got %q", out.String())
+	}
+
+	var stderr bytes.Buffer
+	cmd = exec.Command("sh", "-c", "echo "+strings.Repeat("x", 2000)+" >&2; echo 'no such file' >&2; exit 3")
+	cmd.Stderr = &stderr
+	if err := Exec(ctx, cmd); err == nil {
+		t.Fatal("Expected an exit error")
+	}
+	if !strings.HasSuffix(stderr.String(), "no such file\n") {
+		t.Errorf("Expected stderr to still reach cmd.Stderr, got %q", stderr.String())
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	if entry["exec_command"] != "sh" || entry["exec_count"] != float64(2) || entry["exec_exit_code"] != float64(3) {
+		t.Errorf("Expected exec_command=sh exec_count=2 exec_exit_code=3, got %v", entry)
+	}
+	if _, ok := entry["exec_duration_ms"].(float64); !ok {
+		t.Errorf("Expected exec_duration_ms, got %v", entry["exec_duration_ms"])
+	}
+	got, _ := entry["exec_stderr"].(string)
+	if len(got) > execStderrLimit || !strings.HasSuffix(got, "no such file") {
+		t.Errorf("Expected the tail of stderr, got %d bytes ending %q", len(got), got[max(0, len(got)-20):])
+	}
+	if entry["exec_error"] != nil {
+		t.Errorf("Expected no exec_error, got %v", entry["exec_error"])
+	}
+}
+
+func TestExecStartError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	if err := Exec(ctx, exec.Command("/nonexistent/canonlog-test-binary")); err == nil {
+		t.Fatal("Expected a start error")
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if entry["exec_command"] != "canonlog-test-binary" || entry["exec_exit_code"] != float64(-1) {
+		t.Errorf("Expected exec_command and exec_exit_code=-1, got %v", entry)
+	}
+	if msg, _ := entry["exec_error"].(string); !strings.Contains(msg, "canonlog-test-binary") {
+		t.Errorf("Expected exec_error, got %v", entry["exec_error"])
+	}
+
+	// Without a logger the command still runs
+	if err := Exec(context.Background(), exec.Command("/nonexistent/canonlog-test-binary")); err == nil {
+		t.Error("Expected a start error without a logger")
+	}
+}
+
+func TestExecCombinedOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	var out bytes.Buffer
+	cmd := exec.Command("sh", "-c", "for i in 1 2 3 4 5; do echo out$i; echo err$i >&2; done; exit 1")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := Exec(ctx, cmd); err == nil {
+		t.Fatal("Expected an exit error")
+	}
+	if strings.Count(out.String(), "\n") != 10 {
+		t.Errorf("Expected combined output in the shared buffer, got %q", out.String())
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if got, _ := entry["exec_stderr"].(string); !strings.HasSuffix(got, "out5\nerr5") {
+		t.Errorf("Expected the tail of the combined output, got %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package canonlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Keys under which error fingerprints are emitted.
+const (
+	errorFingerprintKey  = "error_fingerprint"
+	errorFingerprintsKey = "error_fingerprints"
+)
+
+// WithErrorFingerprint emits a fingerprint for each error of an entry: a
+// stable hash of the error's root type, its message with variable parts such
+// as numbers, IDs, and quoted values masked, and, with WithErrorSource, the
+// function that recorded it. error_fingerprints holds one per error, in the
+// order of errors; error_fingerprint is the first error's, a single key to
+// group whole entries by. Entries failing the same way share fingerprints,
+// so log backends can group and deduplicate them without an error tracker.
+func WithErrorFingerprint() Option {
+	return func(l *Logger) {
+		l.fingerprint = true
+	}
+}
+
+// Patterns masked in error messages before fingerprinting, applied in order.
+var fingerprintMasks = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), `"?"`},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// normalizeErrorMessage masks the variable parts of msg.
+func normalizeErrorMessage(msg string) string {
+	for _, m := range fingerprintMasks {
+		msg = m.re.ReplaceAllString(msg, m.repl)
+	}
+	return msg
+}
+
+// errorFingerprints returns the fingerprint of each of errs, recorded at the
+// matching sources if any.
+func errorFingerprints(errs []error, sources []errorSource) []string {
+	fps := make([]string, len(errs))
+	for i, err := range errs {
+		var src errorSource
+		if i < len(sources) {
+			src = sources[i]
+		}
+		fps[i] = errorFingerprint(err, src)
+	}
+	return fps
+}
+
+// errorFingerprint returns the fingerprint of err recorded at src.
+func errorFingerprint(err error, src errorSource) string {
+	root := err
+	for {
+		next := errors.Unwrap(root)
+		if next == nil {
+			break
+		}
+		root = next
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\x00%s\x00%s", root, normalizeErrorMessage(err.Error()), src.function)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,83 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := map[string]string{
+		`user 123 not found`:                                   `user <n> not found`,
+		`read tcp 10.0.0.1:5432: timeout after 1.5s`:           `read tcp <n>.<n>:<n>: timeout after <n>s`,
+		`order 6f1c2a3b-4d5e-6f70-8192-a3b4c5d6e7f8 is locked`: `order <uuid> is locked`,
+		`object 0xc000123abc freed`:                            `object <hex> freed`,
+		`unknown field "email"`:                                `unknown field "?"`,
+	}
+	for in, want := range tests {
+		if got := normalizeErrorMessage(in); got != want {
+			t.Errorf("normalizeErrorMessage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestErrorFingerprintStable(t *testing.T) {
+	a := errorFingerprint(fmt.Errorf("load user 1: %w", errors.New("not found")), errorSource{})
+	b := errorFingerprint(fmt.Errorf("load user 2: %w", errors.New("not found")), errorSource{})
+	if a != b {
+		t.Errorf("Expected errors differing only in IDs to share a fingerprint, got %s and %s", a, b)
+	}
+
+	c := errorFingerprint(errors.New("load user 1: timeout"), errorSource{})
+	if a == c {
+		t.Error("Expected different messages to have different fingerprints")
+	}
+
+	d := errorFingerprint(fmt.Errorf("load user 1: %w", errors.New("not found")), errorSource{function: "pkg.Handler"})
+	if a == d {
+		t.Error("Expected the recording function to be part of the fingerprint")
+	}
+}
+
+func TestWithErrorFingerprint(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	emit := func(id int) string {
+		buf.Reset()
+		l := New(WithErrorFingerprint())
+		l.ErrorAdd(fmt.Errorf("charge %d failed", id))
+		l.Flush(context.Background())
+		fp, _ := decodeEntry(t, buf)["error_fingerprint"].(string)
+		return fp
+	}
+
+	first, second := emit(1), emit(2)
+	if first == "" || first != second {
+		t.Errorf("Expected matching non-empty fingerprints, got %q and %q", first, second)
+	}
+}
+
+func TestWithErrorFingerprintPerError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithErrorFingerprint())
+	l.ErrorAdd(fmt.Errorf("charge %d failed", 1))
+	l.ErrorAdd(errors.New("refund failed"))
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	fps, _ := entry["error_fingerprints"].([]any)
+	if len(fps) != 2 || fps[0] == fps[1] {
+		t.Fatalf("Expected a distinct fingerprint per error, got %v", entry["error_fingerprints"])
+	}
+	if fps[0] != entry["error_fingerprint"] {
+		t.Errorf("Expected error_fingerprint to be the first error's, got %v and %v", entry["error_fingerprint"], fps[0])
+	}
+	if want := errorFingerprint(errors.New("refund failed"), errorSource{}); fps[1] != want {
+		t.Errorf("Expected the second fingerprint %s, got %v", want, fps[1])
+	}
+}
@@ -0,0 +1,51 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// flagsKey is the group under which evaluated feature flags are emitted.
+const flagsKey = "flags"
+
+// Flag records that feature flag name was evaluated to variant, so incidents
+// can be correlated with flag exposure. All flags evaluated during the unit of
+// work are emitted in a flags group; a flag evaluated more than once appears
+// once, with its latest variant. Flags are gated at info level.
+//
+// Call it from the evaluation hook of a feature flag SDK, for example an
+// OpenFeature After hook:
+//
+//	canonlog.Flag(ctx, details.FlagKey, details.Variant)
+func (l *Logger) Flag(name string, variant any) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.flags == nil {
+		l.flags = make(map[string]any)
+	}
+	l.flags[name] = variant
+	return l
+}
+
+// Flag records a feature flag evaluation on the logger in context.
+// Panics if no logger exists in context.
+func Flag(ctx context.Context, name string, variant any) {
+	GetLogger(ctx).Flag(name, variant)
+}
+
+// addFlagsField writes the flags group to fields. Must be called with l.mu held.
+func (l *Logger) addFlagsField(fields map[string]any) {
+	if len(l.flags) == 0 {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(l.flags))
+	for _, name := range slices.Sorted(maps.Keys(l.flags)) {
+		attrs = append(attrs, slog.Any(name, l.flags[name]))
+	}
+	fields[flagsKey] = slog.GroupValue(attrs...)
+}
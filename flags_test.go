@@ -0,0 +1,42 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestFlag(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	Flag(ctx, "new_checkout", "treatment")
+	Flag(ctx, "dark_mode", false)
+	Flag(ctx, "new_checkout", "control")
+	Flush(ctx)
+
+	flags, ok := decodeEntry(t, buf)["flags"].(map[string]any)
+	if !ok {
+		t.Fatal("Expected flags group")
+	}
+	if len(flags) != 2 || flags["new_checkout"] != "control" || flags["dark_mode"] != false {
+		t.Errorf("Expected deduplicated flags with latest variant, got %v", flags)
+	}
+}
+
+func TestFlagReset(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.Flag("beta", true)
+	l.Flush(context.Background())
+	buf.Reset()
+
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["flags"] != nil {
+		t.Errorf("Expected flags to reset after Flush, got %v", entry["flags"])
+	}
+}
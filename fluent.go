@@ -0,0 +1,293 @@
+package canonlog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// FluentConfig configures NewFluentHandler.
+type FluentConfig struct {
+	// Address is the forward input to connect to. Defaults to
+	// "localhost:24224", the Fluentd and Fluent Bit default.
+	Address string
+
+	// Network is "tcp" or "unix". Defaults to "tcp".
+	Network string
+
+	// Tag is the Fluentd tag entries are sent under. Defaults to "canonlog".
+	Tag string
+
+	// Level is the minimum level sent. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// BatchSize is the number of entries that triggers a send. Defaults to 100.
+	BatchSize int
+
+	// BatchWait is the longest an entry waits before being sent. Defaults to
+	// one second.
+	BatchWait time.Duration
+
+	// QueueSize is the number of entries buffered while sends are in
+	// progress. Entries logged while the queue is full are dropped and
+	// counted by Dropped. Defaults to 10000.
+	QueueSize int
+
+	// MaxRetries is the number of times a failed send is retried, with
+	// exponential backoff from 100ms and a fresh connection, before its
+	// entries are dropped. Defaults to 5.
+	MaxRetries int
+
+	// Timeout bounds connecting, writing, and waiting for an ack. Defaults
+	// to five seconds.
+	Timeout time.Duration
+
+	// DisableAck sends without waiting for the server to acknowledge each
+	// chunk. Faster, but entries in flight when a connection breaks are lost.
+	DisableAck bool
+
+	// OnDrop, if set, is called when entries are dropped, with how many and why.
+	// Drops from a full queue are summed and reported from the sending
+	// goroutine after each batch; drops after Close are reported on the
+	// logging goroutine, so OnDrop must not log through this handler.
+	OnDrop func(DroppedEntries)
+
+	// DeadLetter, if set, receives a JSON line for each drop reported to
+	// OnDrop, with sink, reason, count, and error, for example os.Stderr.
+	DeadLetter io.Writer
+}
+
+// FluentHandler is a slog.Handler that ships entries to Fluentd, Fluent Bit,
+// or Vector using the Fluentd forward protocol.
+type FluentHandler struct {
+	goas  []groupOrAttrs
+	state *fluentState
+}
+
+// fluentState is shared by a FluentHandler and the handlers derived from it.
+type fluentState struct {
+	cfg     FluentConfig
+	entries *batcher[[]byte]
+
+	// Used only by the sending goroutine
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewFluentHandler returns a handler that sends entries over the forward
+// protocol from a background goroutine. Entries are MessagePack maps with
+// level, msg, and every attribute, and the record time as their event time.
+// Each batch is sent in forward mode and, unless DisableAck is set, resent
+// until the server acknowledges it. Call Close during shutdown, for example by
+// registering it with AddShutdownHook, to send the entries still buffered.
+//
+// Example:
+//
+//	fluent := canonlog.NewFluentHandler(canonlog.FluentConfig{
+//		Address: "fluent-bit:24224",
+//		Tag:     "app.checkout",
+//	})
+//	slog.SetDefault(slog.New(fluent))
+//	canonlog.AddShutdownHook(fluent.Close)
+func NewFluentHandler(cfg FluentConfig) *FluentHandler {
+	if cfg.Address == "" {
+		cfg.Address = "localhost:24224"
+	}
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = "canonlog"
+	}
+	if cfg.Level == nil {
+		cfg.Level = slog.LevelInfo
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.BatchWait <= 0 {
+		cfg.BatchWait = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	s := &fluentState{cfg: cfg}
+	s.entries = newBatcher(cfg.BatchSize, cfg.QueueSize, cfg.BatchWait, s.send, s.disconnect,
+		dropReporter("fluent", cfg.OnDrop, cfg.DeadLetter))
+	return &FluentHandler{state: s}
+}
+
+// Enabled reports whether level meets the configured minimum.
+func (h *FluentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.state.cfg.Level.Level()
+}
+
+// Handle encodes r and queues it for the next send. It never blocks; if the
+// queue is full, the entry is dropped.
+func (h *FluentHandler) Handle(ctx context.Context, r slog.Record) error {
+	var w msgpackWriter
+	w.arrayHeader(2)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	w.eventTime(t)
+	w.value(h.record(r))
+
+	h.state.entries.add(w.b)
+	return nil
+}
+
+// record returns r and the handler's attributes as a nested map.
+func (h *FluentHandler) record(r slog.Record) map[string]any {
+	attrs := recordAttrs(r, h.goas)
+	m := make(map[string]any, len(attrs)+2)
+	m[slog.LevelKey] = r.Level.String()
+	m[slog.MessageKey] = r.Message
+	for _, a := range attrs {
+		addAttrToMap(m, a)
+	}
+	return m
+}
+
+// addAttrToMap stores a in m, following slog's rules for empty attributes
+// and groups.
+func addAttrToMap(m map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		m[a.Key] = a.Value.Any()
+		return
+	}
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+	sub := m
+	if a.Key != "" {
+		sub = make(map[string]any, len(attrs))
+		m[a.Key] = sub
+	}
+	for _, ga := range attrs {
+		addAttrToMap(sub, ga)
+	}
+}
+
+// WithAttrs returns a handler that adds attrs to every entry.
+func (h *FluentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &FluentHandler{goas: append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{attrs: attrs}), state: h.state}
+}
+
+// WithGroup returns a handler that nests later attributes under name.
+func (h *FluentHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &FluentHandler{goas: append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{group: name}), state: h.state}
+}
+
+// Dropped returns the number of entries dropped because the queue was full,
+// the handler was closed, or a send failed after all retries.
+func (h *FluentHandler) Dropped() uint64 {
+	return h.state.entries.dropped.Load()
+}
+
+// Close stops accepting entries and sends those still queued, waiting until
+// they are delivered or ctx is done.
+func (h *FluentHandler) Close(ctx context.Context) error {
+	return h.state.entries.close(ctx)
+}
+
+// send delivers batch as one forward mode message, retrying on a new
+// connection after failures. Entries that cannot be delivered are counted as
+// dropped.
+func (s *fluentState) send(batch [][]byte) {
+	var chunk string
+	if !s.cfg.DisableAck {
+		var id [16]byte
+		rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+
+	var w msgpackWriter
+	w.arrayHeader(3)
+	w.string(s.cfg.Tag)
+	w.arrayHeader(len(batch))
+	for _, e := range batch {
+		w.b = append(w.b, e...)
+	}
+	if chunk != "" {
+		w.mapHeader(2)
+		w.string("chunk")
+		w.string(chunk)
+	} else {
+		w.mapHeader(1)
+	}
+	w.string("size")
+	w.int(int64(len(batch)))
+
+	err := retry(s.cfg.MaxRetries, func() error {
+		err := s.write(w.b, chunk)
+		if err != nil {
+			s.disconnect()
+		}
+		return err
+	})
+	if err != nil {
+		s.entries.drop(len(batch), DropSendFailed, err)
+	}
+}
+
+var errFluentAck = errors.New("canonlog: fluent ack does not match chunk")
+
+// write sends one message and, if chunk is set, waits for its ack.
+func (s *fluentState) write(msg []byte, chunk string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.cfg.Network, s.cfg.Address, s.cfg.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.r = bufio.NewReader(conn)
+	}
+	s.conn.SetDeadline(time.Now().Add(s.cfg.Timeout))
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := msgpackRead(s.r)
+	if err != nil {
+		return err
+	}
+	if m, ok := resp.(map[string]any); !ok || m["ack"] != chunk {
+		return errFluentAck
+	}
+	return nil
+}
+
+// disconnect closes the current connection, if any.
+func (s *fluentState) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.r = nil, nil
+	}
+}
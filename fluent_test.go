@@ -0,0 +1,193 @@
+package canonlog
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fluentServer is a forward protocol server that records received entries.
+type fluentServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	tags     []string
+	entries  []map[string]any
+	times    [][]byte
+	messages int
+	dropAcks int // number of messages to answer by closing the connection
+}
+
+func newFluentServer(t *testing.T) *fluentServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fluentServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(t, conn)
+		}
+	}()
+	return s
+}
+
+func (s *fluentServer) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := msgpackRead(r)
+		if err != nil {
+			return
+		}
+		msg, ok := v.([]any)
+		if !ok || len(msg) != 3 {
+			t.Errorf("message = %#v, want forward mode array", v)
+			return
+		}
+		opts, _ := msg[2].(map[string]any)
+
+		s.mu.Lock()
+		s.messages++
+		if s.dropAcks > 0 {
+			s.dropAcks--
+			s.mu.Unlock()
+			return
+		}
+		s.tags = append(s.tags, msg[0].(string))
+		for _, e := range msg[1].([]any) {
+			entry := e.([]any)
+			s.times = append(s.times, entry[0].([]byte))
+			s.entries = append(s.entries, entry[1].(map[string]any))
+		}
+		if opts["size"] != int64(len(msg[1].([]any))) {
+			t.Errorf("size option = %v, want %d", opts["size"], len(msg[1].([]any)))
+		}
+		s.mu.Unlock()
+
+		if chunk, ok := opts["chunk"].(string); ok {
+			var w msgpackWriter
+			w.value(map[string]any{"ack": chunk})
+			conn.Write(w.b)
+		}
+	}
+}
+
+func (s *fluentServer) received() ([]string, []map[string]any, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tags, s.entries, s.messages
+}
+
+func TestFluentHandler(t *testing.T) {
+	srv := newFluentServer(t)
+	h := NewFluentHandler(FluentConfig{Address: srv.ln.Addr().String(), Tag: "app.test", BatchWait: time.Hour})
+	logger := slog.New(h).With("service", "checkout").WithGroup("req")
+	logger.Info("", "route", "/orders", "status", 200, "duration", 1500*time.Millisecond)
+	logger.Warn("slow", slog.Group("db", "queries", 3))
+	logger.Debug("dropped")
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	tags, entries, _ := srv.received()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if tags[0] != "app.test" {
+		t.Errorf("tag = %q, want app.test", tags[0])
+	}
+	e := entries[0]
+	if e["level"] != "INFO" || e["msg"] != "" || e["service"] != "checkout" {
+		t.Errorf("entry = %v", e)
+	}
+	req, ok := e["req"].(map[string]any)
+	if !ok {
+		t.Fatalf("req = %#v, want group", e["req"])
+	}
+	if req["route"] != "/orders" || req["status"] != int64(200) || req["duration"] != int64(1500*time.Millisecond) {
+		t.Errorf("req = %v", req)
+	}
+	db := entries[1]["req"].(map[string]any)["db"].(map[string]any)
+	if entries[1]["level"] != "WARN" || db["queries"] != int64(3) {
+		t.Errorf("entry = %v", entries[1])
+	}
+	if len(srv.times[0]) != 8 {
+		t.Errorf("event time is %d bytes, want 8", len(srv.times[0]))
+	}
+}
+
+func TestFluentHandlerRetriesUnacked(t *testing.T) {
+	srv := newFluentServer(t)
+	srv.dropAcks = 1
+	h := NewFluentHandler(FluentConfig{Address: srv.ln.Addr().String(), BatchWait: time.Hour})
+	slog.New(h).Info("", "n", 1)
+	h.Close(context.Background())
+
+	_, entries, messages := srv.received()
+	if messages != 2 {
+		t.Errorf("got %d messages, want 2", messages)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+	if h.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", h.Dropped())
+	}
+}
+
+func TestFluentHandlerDisableAck(t *testing.T) {
+	srv := newFluentServer(t)
+	h := NewFluentHandler(FluentConfig{Address: srv.ln.Addr().String(), BatchSize: 2, BatchWait: time.Hour, DisableAck: true})
+	logger := slog.New(h)
+	for range 3 {
+		logger.Info("")
+	}
+	h.Close(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, entries, messages := srv.received()
+		if len(entries) == 3 {
+			if messages != 2 {
+				t.Errorf("got %d messages, want 2", messages)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d entries, want 3", len(entries))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFluentHandlerDropsUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	h := NewFluentHandler(FluentConfig{Address: addr, MaxRetries: 1, BatchWait: time.Hour})
+	slog.New(h).Info("")
+	h.Close(context.Background())
+	if h.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", h.Dropped())
+	}
+
+	slog.New(h).Info("")
+	if h.Dropped() != 2 {
+		t.Errorf("Dropped() after Close = %d, want 2", h.Dropped())
+	}
+}
@@ -0,0 +1,146 @@
+package canonlog
+
+import (
+	"maps"
+	"slices"
+)
+
+// Fork returns a child logger with the same settings as l, for a goroutine to
+// accumulate into without contending on l's mutex. The child is not flushed;
+// its data is folded into l with Merge when the goroutine finishes.
+//
+// Example:
+//
+//	var wg sync.WaitGroup
+//	for _, shard := range shards {
+//		child := log.Fork()
+//		wg.Add(1)
+//		go func() {
+//			defer wg.Done()
+//			defer log.Merge(child)
+//			child.InfoAdd("shard_"+shard.ID+"_rows", query(shard))
+//		}()
+//	}
+//	wg.Wait()
+func (l *Logger) Fork() *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	child := &Logger{
+		fields:          make(map[string]any, 16),
+		level:           l.baseLevel,
+		baseLevel:       l.baseLevel,
+		keyPolicy:       l.keyPolicy,
+		maxFields:       l.maxFields,
+		maxValueLen:     l.maxValueLen,
+		maxEntrySize:    l.maxEntrySize,
+		splitSize:       l.splitSize,
+		structured:      l.structured,
+		sanitize:        l.sanitize,
+		injectionPolicy: l.injectionPolicy,
+		errorSource:     l.errorSource,
+		fingerprint:     l.fingerprint,
+		buildInfo:       l.buildInfo,
+		slogger:         l.slogger,
+		clock:           l.clock,
+		fieldOrder:      l.fieldOrder,
+	}
+	child.gateLevel.Store(l.gateLevel.Load())
+	return child
+}
+
+// Merge moves everything accumulated on child into l and resets child.
+// Fields are added under l's key conflict policy and limits, in child's
+// insertion order if it uses OrderInsertion and in key order otherwise.
+// Observations are combined with l's under the same keys and flag
+// evaluations added to l's; errors, warnings, and security events are
+// appended, l's level is escalated to child's if higher, and child's message
+// is used if l has none.
+func (l *Logger) Merge(child *Logger) *Logger {
+	if child == nil || child == l {
+		return l
+	}
+	child.mu.Lock()
+	fields := child.mergedFieldsLocked()
+	keys := mergeKeys(fields, child.fieldOrder, child.order)
+	histograms, flags := child.histograms, child.flags
+	errs, errorSources, errorsDropped := child.errors, child.errorSources, child.errorsDropped
+	warnings, warningsDropped := child.warnings, child.warningsDropped
+	security, securityEvents := child.security, child.securityEvents
+	fieldsDropped, truncated, level, message := child.fieldsDropped, child.truncated, child.level, child.message
+	// Reset reuses these slices' backing arrays, so hand them over instead
+	child.errors, child.errorSources, child.warnings = nil, nil, nil
+	child.security, child.securityEvents = nil, nil
+	child.resetLocked()
+	child.mu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, k := range keys {
+		l.setField(k, fields[k])
+	}
+	for key, h := range histograms {
+		l.mergeHistogram(key, h)
+	}
+	if len(flags) > 0 {
+		if l.flags == nil {
+			l.flags = make(map[string]any, len(flags))
+		}
+		maps.Copy(l.flags, flags)
+	}
+	for i, err := range errs {
+		var src errorSource
+		if i < len(errorSources) {
+			src = errorSources[i]
+		}
+		l.addError(err, src)
+	}
+	l.errorsDropped += errorsDropped
+	for _, err := range warnings {
+		if len(l.warnings) < maxErrors {
+			l.warnings = append(l.warnings, err)
+		} else {
+			l.warningsDropped++
+		}
+	}
+	l.warningsDropped += warningsDropped
+	if len(securityEvents) > 0 {
+		if l.security == nil {
+			l.security = make(map[string]any, len(security))
+		}
+		maps.Copy(l.security, security)
+		l.securityEvents = append(l.securityEvents, securityEvents...)
+	}
+	l.fieldsDropped += fieldsDropped
+	l.truncated = l.truncated || truncated
+	if level > l.level {
+		l.level = level
+	}
+	if l.message == "" {
+		l.message = message
+	}
+	return l
+}
+
+// mergeKeys returns the keys of fields in the order Merge adds them: the
+// recorded insertion order for OrderInsertion, then any remaining keys
+// sorted.
+func mergeKeys(fields map[string]any, mode FieldOrder, order []string) []string {
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	if mode == OrderInsertion {
+		for _, k := range order {
+			if _, ok := fields[k]; ok && !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	n := len(keys)
+	for k := range fields {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys[n:])
+	return keys
+}
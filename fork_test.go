@@ -0,0 +1,128 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestForkMerge(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.InfoAdd("request_id", "req-1")
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		child := l.Fork()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer l.Merge(child)
+			child.InfoAdd(fmt.Sprintf("shard_%d", i), i)
+			if i == 3 {
+				child.ErrorAdd(errors.New("shard 3 failed"))
+			}
+		}()
+	}
+	wg.Wait()
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["level"] != "ERROR" {
+		t.Errorf("Expected level escalated by child, got %v", entry["level"])
+	}
+	for i := range 10 {
+		if entry[fmt.Sprintf("shard_%d", i)] != float64(i) {
+			t.Errorf("Expected shard_%d from child, got %v", i, entry)
+		}
+	}
+	errs, _ := entry["errors"].([]any)
+	if len(errs) != 1 || errs[0] != "shard 3 failed" {
+		t.Errorf("Expected child error, got %v", entry["errors"])
+	}
+}
+
+func TestForkInheritsSettings(t *testing.T) {
+	l := New(WithLevel(slog.LevelWarn), WithKeyConflictPolicy(KeySuffix))
+	child := l.Fork()
+
+	child.InfoAdd("gated", true)
+	if child.Len() != 0 {
+		t.Error("Expected child to inherit gate level")
+	}
+
+	l.WarnAdd("k", 1)
+	child.WarnAdd("k", 2)
+	l.Merge(child)
+	if f := l.Fields(); f["k"] != 1 || f["k_2"] != 2 {
+		t.Errorf("Expected parent key policy to apply on merge, got %v", f)
+	}
+	if child.Len() != 0 {
+		t.Error("Expected child to be reset after merge")
+	}
+}
+
+func TestMergeNil(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	l.InfoAdd("k", "v")
+	l.Merge(nil).Merge(l)
+	if l.Len() != 1 {
+		t.Errorf("Expected merging nil or self to be a no-op, got %v", l.Fields())
+	}
+}
+
+func TestMergeComputedState(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithBuildInfo(), WithKeyConflictPolicy(KeyError))
+	l.Observe("lat", 1)
+	l.Flag("checkout_v2", "on")
+	child := l.Fork()
+	child.Observe("lat", 2)
+	child.Observe("lat", 3)
+	child.Flag("search_beta", "off")
+	l.Merge(child)
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry["level"] != "INFO" || entry["errors"] != nil {
+		t.Errorf("Expected no key conflicts from computed fields, got %v", entry)
+	}
+	if entry["lat_count"] != float64(3) || entry["lat_sum"] != float64(6) || entry["lat_min"] != float64(1) || entry["lat_max"] != float64(3) {
+		t.Errorf("Expected observations combined, got %v", entry)
+	}
+	flags, _ := entry["flags"].(map[string]any)
+	if flags["checkout_v2"] != "on" || flags["search_beta"] != "off" {
+		t.Errorf("Expected flags combined, got %v", entry["flags"])
+	}
+	for k := range entry {
+		if strings.HasSuffix(k, "_2") {
+			t.Errorf("Expected no suffixed duplicates, got %s", k)
+		}
+	}
+}
+
+func TestMergeInsertionOrder(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New(WithFieldOrder(OrderInsertion))
+	child := l.Fork()
+	child.InfoAdd("zeta", 1).InfoAdd("alpha", 2).InfoAdd("mid", 3)
+	l.InfoAdd("first", 0)
+	l.Merge(child)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if got := strings.Join(l.order, ","); got != "first,zeta,alpha,mid" {
+		t.Errorf("Expected child insertion order kept, got %s", got)
+	}
+}
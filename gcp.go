@@ -0,0 +1,152 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// Special fields recognized by Google Cloud Logging.
+const (
+	gcpTraceKey       = "logging.googleapis.com/trace"
+	gcpSpanKey        = "logging.googleapis.com/spanId"
+	gcpLabelsKey      = "logging.googleapis.com/labels"
+	gcpHTTPRequestKey = "httpRequest"
+)
+
+// gcpHTTPFields maps canonical line fields to HttpRequest properties.
+var gcpHTTPFields = map[string]string{
+	"method":        "requestMethod",
+	"http_method":   "requestMethod",
+	"path":          "requestUrl",
+	"url":           "requestUrl",
+	"status":        "status",
+	"status_code":   "status",
+	"response_size": "responseSize",
+	"request_size":  "requestSize",
+	"user_agent":    "userAgent",
+	"remote_ip":     "remoteIp",
+	"referer":       "referer",
+	"http_proto":    "protocol",
+	"duration_ms":   "latency",
+}
+
+// GCPConfig configures the "gcp" format.
+type GCPConfig struct {
+	// ProjectID qualifies trace IDs as projects/<id>/traces/<trace_id>, which
+	// Cloud Logging needs to link entries to Cloud Trace. Defaults to the
+	// GOOGLE_CLOUD_PROJECT environment variable.
+	ProjectID string `json:"project_id"`
+
+	// Labels lists fields moved into logging.googleapis.com/labels, for
+	// indexed filtering. Their values are converted to strings.
+	Labels []string `json:"labels"`
+}
+
+// NewGCPHandler returns a handler that writes JSON lines in Google Cloud
+// Logging's structured format, so Cloud Run and GKE parse them natively:
+//
+//   - the level is written as severity, and the message as message
+//   - trace_id and span_id become logging.googleapis.com/trace and spanId
+//   - request fields such as method, path, status, response_size, user_agent,
+//     remote_ip, http_proto, and duration_ms are gathered into httpRequest
+//   - fields listed in cfg.Labels are gathered into logging.googleapis.com/labels
+//
+// opts may be nil. It is used by Setup for the "gcp" format.
+func NewGCPHandler(w io.Writer, cfg GCPConfig, opts *slog.HandlerOptions) slog.Handler {
+	if cfg.ProjectID == "" {
+		cfg.ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	var o slog.HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	replace := o.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch a.Key {
+			case slog.LevelKey:
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a = slog.Attr{Key: "severity", Value: GCPSeverity(level)}
+				}
+			case slog.MessageKey:
+				a.Key = "message"
+			}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+	return &gcpHandler{Handler: slog.NewJSONHandler(w, &o), cfg: cfg}
+}
+
+// gcpHandler rearranges record attributes into Cloud Logging's special fields.
+type gcpHandler struct {
+	slog.Handler
+	cfg GCPConfig
+}
+
+func (h *gcpHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	var httpReq, labels []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		if prop, ok := gcpHTTPFields[a.Key]; ok {
+			httpReq = append(httpReq, gcpHTTPAttr(prop, a.Value))
+			return true
+		}
+		if slices.Contains(h.cfg.Labels, a.Key) {
+			labels = append(labels, slog.String(a.Key, a.Value.Resolve().String()))
+			return true
+		}
+		switch a.Key {
+		case traceIDKey:
+			trace := a.Value.String()
+			if h.cfg.ProjectID != "" {
+				trace = "projects/" + h.cfg.ProjectID + "/traces/" + trace
+			}
+			out.AddAttrs(slog.String(gcpTraceKey, trace))
+		case spanIDKey:
+			out.AddAttrs(slog.String(gcpSpanKey, a.Value.String()))
+		default:
+			out.AddAttrs(a)
+		}
+		return true
+	})
+	if len(httpReq) > 0 {
+		out.AddAttrs(slog.Attr{Key: gcpHTTPRequestKey, Value: slog.GroupValue(httpReq...)})
+	}
+	if len(labels) > 0 {
+		out.AddAttrs(slog.Attr{Key: gcpLabelsKey, Value: slog.GroupValue(labels...)})
+	}
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h *gcpHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &gcpHandler{Handler: h.Handler.WithAttrs(attrs), cfg: h.cfg}
+}
+
+func (h *gcpHandler) WithGroup(name string) slog.Handler {
+	return &gcpHandler{Handler: h.Handler.WithGroup(name), cfg: h.cfg}
+}
+
+// gcpHTTPAttr converts a field value to the type HttpRequest expects for prop:
+// a duration string such as "0.125s" for latency, a decimal string for sizes,
+// and an integer for status.
+func gcpHTTPAttr(prop string, v slog.Value) slog.Attr {
+	v = v.Resolve()
+	switch prop {
+	case "latency":
+		if ms, ok := toFloat(v.Any()); ok {
+			return slog.String(prop, fmt.Sprintf("%.9gs", ms/1000))
+		}
+	case "responseSize", "requestSize":
+		if n, ok := toFloat(v.Any()); ok {
+			return slog.String(prop, fmt.Sprintf("%.0f", n))
+		}
+	}
+	return slog.Attr{Key: prop, Value: v}
+}
@@ -0,0 +1,69 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestGCPHandler(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var buf bytes.Buffer
+	if err := Setup(Config{Format: "gcp", Output: &buf, GCP: GCPConfig{ProjectID: "acme-prod", Labels: []string{"service"}}}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	l := New()
+	l.Persist("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+	l.Persist("span_id", "00f067aa0ba902b7")
+	l.InfoAddMany(map[string]any{
+		"method":        "GET",
+		"path":          "/users/42",
+		"status":        503,
+		"response_size": 1024,
+		"duration_ms":   125 * time.Millisecond,
+		"service":       "users",
+		"cache":         "miss",
+	})
+	l.WarnAdd("retry", true)
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, &buf)
+	if entry["severity"] != "WARNING" || entry["level"] != nil {
+		t.Errorf("Expected severity=WARNING in place of level, got %v", entry)
+	}
+	if _, ok := entry["message"]; !ok {
+		t.Errorf("Expected message in place of msg, got %v", entry)
+	}
+	if entry["logging.googleapis.com/trace"] != "projects/acme-prod/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected qualified trace, got %v", entry["logging.googleapis.com/trace"])
+	}
+	if entry["logging.googleapis.com/spanId"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected spanId, got %v", entry["logging.googleapis.com/spanId"])
+	}
+
+	req, _ := entry["httpRequest"].(map[string]any)
+	want := map[string]any{
+		"requestMethod": "GET",
+		"requestUrl":    "/users/42",
+		"status":        float64(503),
+		"responseSize":  "1024",
+		"latency":       "0.125s",
+	}
+	for k, v := range want {
+		if req[k] != v {
+			t.Errorf("Expected httpRequest.%s=%v, got %v", k, v, req[k])
+		}
+	}
+	labels, _ := entry["logging.googleapis.com/labels"].(map[string]any)
+	if labels["service"] != "users" || entry["service"] != nil {
+		t.Errorf("Expected service moved to labels, got %v", entry)
+	}
+	if entry["cache"] != "miss" || entry["method"] != nil {
+		t.Errorf("Expected other fields unchanged and request fields moved, got %v", entry)
+	}
+}
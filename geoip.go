@@ -0,0 +1,86 @@
+package canonlog
+
+import (
+	"context"
+	"net/netip"
+)
+
+// Fields added by GeoIPEnricher.
+const (
+	geoCountryKey = "geo_country"
+	geoASNKey     = "geo_asn"
+	geoASOrgKey   = "geo_as_org"
+)
+
+// GeoIPRecord is what a GeoIPReader knows about an address. Empty fields are
+// not recorded.
+type GeoIPRecord struct {
+	// Country is the ISO 3166-1 alpha-2 country code, such as "DE".
+	Country string
+
+	// ASN is the autonomous system number.
+	ASN uint
+
+	// ASOrg is the autonomous system's organization, such as "Google LLC".
+	ASOrg string
+}
+
+// GeoIPReader looks up addresses in a GeoIP database. canonlog has no
+// dependencies, so this adapts a reader such as MaxMind's geoip2-golang or
+// maxminddb-golang. Lookup is called on every Flush with a client IP, so it
+// should read from a local database rather than a remote service.
+type GeoIPReader interface {
+	Lookup(ip netip.Addr) (GeoIPRecord, error)
+}
+
+// GeoIPEnricher returns an Enricher that records geo_country, geo_asn, and
+// geo_as_org for the client address in the remote_ip field, as recorded by
+// ExtractClientIP. Entries without a parseable remote_ip, private and
+// loopback addresses, and failed lookups are left unchanged.
+//
+// Example:
+//
+//	db, err := geoip2.Open("GeoLite2-Country.mmdb")
+//	if err != nil {
+//		return err
+//	}
+//	canonlog.AddEnricher(canonlog.GeoIPEnricher(maxmind{db}))
+//
+//	type maxmind struct{ db *geoip2.Reader }
+//
+//	func (m maxmind) Lookup(ip netip.Addr) (canonlog.GeoIPRecord, error) {
+//		c, err := m.db.Country(ip.AsSlice())
+//		if err != nil {
+//			return canonlog.GeoIPRecord{}, err
+//		}
+//		return canonlog.GeoIPRecord{Country: c.Country.IsoCode}, nil
+//	}
+func GeoIPEnricher(reader GeoIPReader) Enricher {
+	return func(ctx context.Context, fields map[string]any) {
+		s, ok := fields[remoteIPKey].(string)
+		if !ok {
+			return
+		}
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return
+		}
+		ip = ip.Unmap()
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			return
+		}
+		rec, err := reader.Lookup(ip)
+		if err != nil {
+			return
+		}
+		if rec.Country != "" {
+			fields[geoCountryKey] = rec.Country
+		}
+		if rec.ASN != 0 {
+			fields[geoASNKey] = rec.ASN
+		}
+		if rec.ASOrg != "" {
+			fields[geoASOrgKey] = rec.ASOrg
+		}
+	}
+}
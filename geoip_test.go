@@ -0,0 +1,70 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/netip"
+	"testing"
+)
+
+// fakeGeoIP answers lookups from a map.
+type fakeGeoIP map[netip.Addr]GeoIPRecord
+
+func (f fakeGeoIP) Lookup(ip netip.Addr) (GeoIPRecord, error) {
+	rec, ok := f[ip]
+	if !ok {
+		return GeoIPRecord{}, errors.New("not found")
+	}
+	return rec, nil
+}
+
+func TestGeoIPEnricher(t *testing.T) {
+	enrich := GeoIPEnricher(fakeGeoIP{
+		netip.MustParseAddr("203.0.113.7"):  {Country: "DE", ASN: 64500, ASOrg: "Example AS"},
+		netip.MustParseAddr("198.51.100.1"): {Country: "US"},
+		netip.MustParseAddr("10.0.0.1"):     {Country: "XX"},
+	})
+
+	tests := []struct {
+		ip   any
+		want map[string]any
+	}{
+		{"203.0.113.7", map[string]any{geoCountryKey: "DE", geoASNKey: uint(64500), geoASOrgKey: "Example AS"}},
+		{"::ffff:198.51.100.1", map[string]any{geoCountryKey: "US"}},
+		{"10.0.0.1", nil},
+		{"127.0.0.1", nil},
+		{"192.0.2.1", nil},
+		{"not an ip", nil},
+		{42, nil},
+	}
+	for _, tt := range tests {
+		fields := map[string]any{remoteIPKey: tt.ip}
+		enrich(context.Background(), fields)
+		delete(fields, remoteIPKey)
+		if len(fields) != len(tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.ip, fields, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if fields[k] != v {
+				t.Errorf("%v: %s = %v, want %v", tt.ip, k, fields[k], v)
+			}
+		}
+	}
+}
+
+func TestGeoIPEnricherAtFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	resetEnrichers(t)
+	AddEnricher(GeoIPEnricher(fakeGeoIP{netip.MustParseAddr("203.0.113.7"): {Country: "DE", ASN: 64500}}))
+
+	l := New()
+	l.InfoAdd(remoteIPKey, "203.0.113.7")
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if entry[geoCountryKey] != "DE" || entry[geoASNKey] != float64(64500) {
+		t.Errorf("Expected geo fields, got %v", entry)
+	}
+}
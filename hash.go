@@ -0,0 +1,90 @@
+package canonlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// hashLen is the number of HMAC bytes kept, enough to make collisions
+// between distinct identifiers practically impossible.
+const hashLen = 16
+
+// HashConfig configures identifier hashing.
+type HashConfig struct {
+	// Secret keys the HMAC. Keep it out of the logs' reach: anyone holding it
+	// can confirm guesses of the original values. Rotating it changes every
+	// hash.
+	Secret []byte
+
+	// Fields lists keys whose values are hashed at emit, as emitted after
+	// key normalization, such as "email" or "client_ip".
+	Fields []string
+}
+
+// hashConfig holds the configuration set by SetHashConfig.
+var hashConfig atomic.Pointer[HashConfig]
+
+// SetHashConfig sets the secret used by Hashed and the fields hashed at emit.
+// Their values are replaced by a keyed HMAC-SHA256 of the value's string
+// form, hex encoded and truncated to 128 bits, which is stable for
+// correlating entries but cannot be reversed without the secret. Passing a
+// zero config disables hashing.
+//
+// Example:
+//
+//	canonlog.SetHashConfig(canonlog.HashConfig{
+//		Secret: []byte(os.Getenv("LOG_HASH_SECRET")),
+//		Fields: []string{"email", "client_ip"},
+//	})
+func SetHashConfig(cfg HashConfig) {
+	if len(cfg.Secret) == 0 {
+		hashConfig.Store(nil)
+		return
+	}
+	cfg.Secret = slices.Clone(cfg.Secret)
+	cfg.Fields = slices.Clone(cfg.Fields)
+	hashConfig.Store(&cfg)
+}
+
+// Hashed returns the HMAC of value under the secret set with SetHashConfig,
+// for recording an identifier in a form that correlates without revealing it.
+// Without a secret it returns "[REDACTED]", so the raw value is never logged.
+//
+// Example:
+//
+//	log.InfoAdd("user_email", canonlog.Hashed(user.Email))
+func Hashed(value any) string {
+	cfg := hashConfig.Load()
+	if cfg == nil {
+		return redactedMark
+	}
+	return hashValue(cfg.Secret, value)
+}
+
+// hashValue returns the truncated, hex-encoded HMAC of value's string form.
+func hashValue(secret []byte, value any) string {
+	mac := hmac.New(sha256.New, secret)
+	if s, ok := value.(string); ok {
+		mac.Write([]byte(s))
+	} else {
+		fmt.Fprint(mac, value)
+	}
+	return hex.EncodeToString(mac.Sum(nil)[:hashLen])
+}
+
+// hashFields replaces the values of the configured fields with their HMAC.
+func hashFields(fields map[string]any) {
+	cfg := hashConfig.Load()
+	if cfg == nil {
+		return
+	}
+	for _, k := range cfg.Fields {
+		if v, ok := fields[k]; ok {
+			fields[k] = hashValue(cfg.Secret, v)
+		}
+	}
+}
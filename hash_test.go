@@ -0,0 +1,62 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func setHashConfig(t *testing.T, cfg HashConfig) {
+	t.Helper()
+	SetHashConfig(cfg)
+	t.Cleanup(func() { SetHashConfig(HashConfig{}) })
+}
+
+func TestHashed(t *testing.T) {
+	if got := Hashed("a@example.com"); got != redactedMark {
+		t.Errorf("Expected %s without a secret, got %q", redactedMark, got)
+	}
+
+	setHashConfig(t, HashConfig{Secret: []byte("secret")})
+	a := Hashed("a@example.com")
+	if len(a) != 2*hashLen {
+		t.Errorf("Expected %d hex characters, got %q", 2*hashLen, a)
+	}
+	if Hashed("a@example.com") != a {
+		t.Error("Expected hash to be stable")
+	}
+	if Hashed("b@example.com") == a {
+		t.Error("Expected different values to hash differently")
+	}
+	if Hashed(42) != Hashed("42") {
+		t.Error("Expected values to hash by their string form")
+	}
+
+	SetHashConfig(HashConfig{Secret: []byte("rotated")})
+	if Hashed("a@example.com") == a {
+		t.Error("Expected a new secret to change the hash")
+	}
+}
+
+func TestHashFields(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setHashConfig(t, HashConfig{Secret: []byte("secret"), Fields: []string{"email", "client_ip"}})
+
+	l := New()
+	l.InfoAdd("email", "a@example.com")
+	l.InfoAdd("client_ip", "203.0.113.7")
+	l.InfoAdd("status", 200)
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry["email"] != Hashed("a@example.com") {
+		t.Errorf("Expected email hashed, got %v", entry["email"])
+	}
+	if entry["client_ip"] != Hashed("203.0.113.7") {
+		t.Errorf("Expected client_ip hashed, got %v", entry["client_ip"])
+	}
+	if entry["status"] != float64(200) {
+		t.Errorf("Expected status unchanged, got %v", entry["status"])
+	}
+}
@@ -0,0 +1,105 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+)
+
+// maxHistogramSamples bounds the samples kept per Observe key. Beyond it,
+// samples are replaced at random so the percentile stays representative.
+const maxHistogramSamples = 1000
+
+// histogram summarizes the values observed under one key.
+type histogram struct {
+	count   int
+	sum     float64
+	min     float64
+	max     float64
+	samples []float64
+}
+
+// Observe records one measurement of a repeated sub-operation, such as a
+// database query, without a field per call. At Flush the key is emitted as
+// key_count, key_sum, key_min, key_max, and key_p95. Values must be numeric;
+// time.Duration values are recorded in milliseconds. Observations are gated
+// at info level.
+//
+// Example:
+//
+//	for _, id := range ids {
+//		start := time.Now()
+//		db.Load(ctx, id)
+//		log.Observe("db_query_ms", time.Since(start))
+//	}
+func (l *Logger) Observe(key string, value any) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	v, ok := toFloat(value)
+	if !ok {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.histograms == nil {
+		l.histograms = make(map[string]*histogram)
+	}
+	h, ok := l.histograms[key]
+	if !ok {
+		h = &histogram{min: v, max: v}
+		l.histograms[key] = h
+	}
+	h.count++
+	h.sum += v
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+	if len(h.samples) < maxHistogramSamples {
+		h.samples = append(h.samples, v)
+	} else if i := rand.IntN(h.count); i < maxHistogramSamples {
+		h.samples[i] = v
+	}
+	return l
+}
+
+// mergeHistogram combines src into the histogram under key, keeping at most
+// maxHistogramSamples samples. Must be called with l.mu held.
+func (l *Logger) mergeHistogram(key string, src *histogram) {
+	if l.histograms == nil {
+		l.histograms = make(map[string]*histogram)
+	}
+	h, ok := l.histograms[key]
+	if !ok {
+		l.histograms[key] = src
+		return
+	}
+	h.count += src.count
+	h.sum += src.sum
+	h.min = min(h.min, src.min)
+	h.max = max(h.max, src.max)
+	h.samples = append(h.samples, src.samples...)
+	if len(h.samples) > maxHistogramSamples {
+		rand.Shuffle(len(h.samples), func(i, j int) { h.samples[i], h.samples[j] = h.samples[j], h.samples[i] })
+		h.samples = h.samples[:maxHistogramSamples]
+	}
+}
+
+// Observe records one measurement under key in the logger in context.
+// Panics if no logger exists in context.
+func Observe(ctx context.Context, key string, value any) {
+	GetLogger(ctx).Observe(key, value)
+}
+
+// addHistogramFields writes the summary fields of every histogram to fields.
+// Must be called with l.mu held.
+func (l *Logger) addHistogramFields(fields map[string]any) {
+	for key, h := range l.histograms {
+		sorted := slices.Sorted(slices.Values(h.samples))
+		fields[key+"_count"] = h.count
+		fields[key+"_sum"] = h.sum
+		fields[key+"_min"] = h.min
+		fields[key+"_max"] = h.max
+		fields[key+"_p95"] = percentile(sorted, 95)
+	}
+}
@@ -0,0 +1,74 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	for i := 1; i <= 100; i++ {
+		Observe(ctx, "db_query_ms", i)
+	}
+	Observe(ctx, "db_query_ms", "ignored")
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	want := map[string]float64{
+		"db_query_ms_count": 100,
+		"db_query_ms_sum":   5050,
+		"db_query_ms_min":   1,
+		"db_query_ms_max":   100,
+		"db_query_ms_p95":   95,
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+}
+
+func TestObserveDuration(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	l.Observe("rpc", 2*time.Millisecond).Observe("rpc", 4*time.Millisecond)
+	f := l.Fields()
+	if len(f) != 0 {
+		t.Errorf("Expected histograms to stay out of Fields until emitted, got %v", f)
+	}
+
+	buf := captureOutput(t)
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if entry["rpc_max"] != float64(4) || entry["rpc_sum"] != float64(6) {
+		t.Errorf("Expected durations in milliseconds, got %v", entry)
+	}
+}
+
+func TestObserveReset(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.Observe("q", 10)
+	l.Checkpoint(context.Background(), "mid")
+	l.Observe("q", 20)
+	buf.Reset()
+	l.Flush(context.Background())
+
+	if entry := decodeEntry(t, buf); entry["q_count"] != float64(2) {
+		t.Errorf("Expected checkpoint to keep observations, got %v", entry["q_count"])
+	}
+
+	buf.Reset()
+	l.Flush(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("Expected histograms to reset after Flush, got %s", buf.String())
+	}
+}
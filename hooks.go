@@ -0,0 +1,87 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Entry is the data emitted by a single Flush.
+type Entry struct {
+	// Level is the level the entry was emitted at.
+	Level slog.Level
+
+	// Fields holds the accumulated fields. Hooks must not modify it.
+	Fields map[string]any
+
+	// Errors holds the errors recorded with ErrorAdd, excluding any dropped
+	// beyond the 10 error limit.
+	Errors []error
+
+	// Warnings holds the errors recorded with WarnError, excluding any dropped
+	// beyond the 10 warning limit.
+	Warnings []error
+
+	// Security holds the names of security events recorded with SecurityEvent.
+	Security []string
+}
+
+// FlushHook is called after a logger emits an entry.
+// The context is the one passed to Flush.
+type FlushHook func(ctx context.Context, entry Entry)
+
+// flushHooks holds the registered hooks. It is replaced wholesale on
+// registration so Flush can read it without locking.
+var (
+	flushHooks   atomic.Pointer[[]FlushHook]
+	flushHooksMu sync.Mutex
+)
+
+// AddFlushHook registers a hook that runs after every Flush that emits an entry.
+// Checkpoint lines do not run hooks, so reporters see each entry once, when
+// it is flushed.
+// Hooks run synchronously, in registration order, on the goroutine calling Flush,
+// so slow work such as network calls should be handed off.
+//
+// Hooks are the integration point for error reporters. For example, to send
+// error-level entries to Sentry:
+//
+//	canonlog.AddFlushHook(func(ctx context.Context, e canonlog.Entry) {
+//		if e.Level < slog.LevelError {
+//			return
+//		}
+//		hub := sentry.CurrentHub().Clone()
+//		hub.ConfigureScope(func(scope *sentry.Scope) {
+//			scope.SetContext("canonlog", e.Fields)
+//		})
+//		for _, err := range e.Errors {
+//			hub.CaptureException(err)
+//		}
+//	})
+func AddFlushHook(hook FlushHook) {
+	if hook == nil {
+		return
+	}
+	flushHooksMu.Lock()
+	defer flushHooksMu.Unlock()
+
+	var hooks []FlushHook
+	if current := flushHooks.Load(); current != nil {
+		hooks = append(hooks, *current...)
+	}
+	hooks = append(hooks, hook)
+	flushHooks.Store(&hooks)
+}
+
+// runFlushHooks passes entry to every registered hook and reports whether any ran.
+func runFlushHooks(ctx context.Context, entry Entry) (ran bool) {
+	hooks := flushHooks.Load()
+	if hooks == nil {
+		return false
+	}
+	for _, hook := range *hooks {
+		hook(ctx, entry)
+	}
+	return len(*hooks) > 0
+}
@@ -0,0 +1,113 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// resetFlushHooks removes all registered flush hooks when the test ends.
+func resetFlushHooks(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { flushHooks.Store(nil) })
+}
+
+func TestAddFlushHook(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	resetFlushHooks(t)
+
+	var got []Entry
+	AddFlushHook(func(ctx context.Context, e Entry) { got = append(got, e) })
+	AddFlushHook(nil)
+
+	l := New()
+	l.InfoAdd("user_id", "123")
+	l.ErrorAdd(errors.New("boom"))
+	l.WarnError(errors.New("degraded"))
+	l.Flush(context.Background())
+	l.Flush(context.Background()) // no-op, hook should not run
+
+	if len(got) != 1 {
+		t.Fatalf("Expected hook to run once, ran %d times", len(got))
+	}
+	e := got[0]
+	if e.Level != slog.LevelError {
+		t.Errorf("Expected Error level, got %v", e.Level)
+	}
+	if e.Fields["user_id"] != "123" {
+		t.Errorf("Expected user_id=123, got %v", e.Fields["user_id"])
+	}
+	if len(e.Errors) != 1 || e.Errors[0].Error() != "boom" {
+		t.Errorf("Expected errors [boom], got %v", e.Errors)
+	}
+	if len(e.Warnings) != 1 || e.Warnings[0].Error() != "degraded" {
+		t.Errorf("Expected warnings [degraded], got %v", e.Warnings)
+	}
+}
+
+func TestFlushHookOrder(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	resetFlushHooks(t)
+
+	var order []int
+	AddFlushHook(func(context.Context, Entry) { order = append(order, 1) })
+	AddFlushHook(func(context.Context, Entry) { order = append(order, 2) })
+
+	New().InfoAdd("k", "v").Flush(context.Background())
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("Expected hooks to run in registration order, got %v", order)
+	}
+}
+
+func TestFlushHookSkipsCheckpoints(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	resetFlushHooks(t)
+
+	var got []Entry
+	AddFlushHook(func(ctx context.Context, e Entry) { got = append(got, e) })
+
+	l := New()
+	l.InfoAdd("k", "v")
+	l.ErrorAdd(errors.New("boom"))
+	l.Checkpoint(context.Background(), "step")
+	l.Checkpoint(context.Background(), "step")
+	l.Flush(context.Background())
+
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 3 {
+		t.Errorf("Expected 2 checkpoint lines and the final line, got %d", lines)
+	}
+	if len(got) != 1 || got[0].Fields[checkpointKey] != nil {
+		t.Errorf("Expected the hook to run once for the final entry, got %v", got)
+	}
+}
+
+func TestFlushHookEntryNotReused(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	resetFlushHooks(t)
+
+	var got []Entry
+	AddFlushHook(func(ctx context.Context, e Entry) { got = append(got, e) })
+
+	l := New()
+	l.InfoAdd("item", 1).ErrorAdd(errors.New("first"))
+	l.Flush(context.Background())
+	l.InfoAdd("item", 2).ErrorAdd(errors.New("second"))
+	l.Flush(context.Background())
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(got))
+	}
+	if got[0].Fields["item"] != 1 || got[0].Errors[0].Error() != "first" {
+		t.Errorf("First entry changed after logger reuse: %v %v", got[0].Fields, got[0].Errors)
+	}
+	if got[1].Fields["item"] != 2 || len(got[1].Errors) != 1 {
+		t.Errorf("Expected second entry to hold only its own data, got %v %v", got[1].Fields, got[1].Errors)
+	}
+}
@@ -0,0 +1,86 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// statusKey is the field RecordHTTPStatus records the response status under.
+const statusKey = "status"
+
+// StatusOption configures RecordHTTPStatus.
+type StatusOption func(*statusOptions)
+
+// statusOptions holds the settings of one RecordHTTPStatus call.
+type statusOptions struct {
+	warnClientErrors bool
+	message          func(status int) string
+}
+
+// WithClientErrorsAsWarn raises the level to Warn for 4xx responses, which
+// are otherwise left at Info because they are usually the client's fault.
+func WithClientErrorsAsWarn() StatusOption {
+	return func(o *statusOptions) {
+		o.warnClientErrors = true
+	}
+}
+
+// WithStatusMessage sets the entry's message from the response status, for
+// example to http.StatusText. An empty result leaves the message unchanged.
+func WithStatusMessage(message func(status int) string) StatusOption {
+	return func(o *statusOptions) {
+		o.message = message
+	}
+}
+
+// RecordHTTPStatus records the response status as status on l and classifies
+// the entry by it: 5xx raises the level to Error, and 4xx to Warn with
+// WithClientErrorsAsWarn. A 500 with no ErrorAdd is then emitted at Error
+// rather than Info. Like the Add methods, the status is recorded only if the
+// level it belongs to is enabled.
+//
+// Example:
+//
+//	log.RecordHTTPStatus(rec.status, canonlog.WithClientErrorsAsWarn(), canonlog.WithStatusMessage(http.StatusText))
+func (l *Logger) RecordHTTPStatus(status int, opts ...StatusOption) *Logger {
+	var o statusOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400 && o.warnClientErrors:
+		level = slog.LevelWarn
+	}
+	if l.gateLevel.Load() > level {
+		return l
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setField(statusKey, status)
+	if l.level < level {
+		l.level = level
+	}
+	if o.message != nil {
+		if msg := o.message(status); msg != "" {
+			l.message = msg
+		}
+	}
+	return l
+}
+
+// RecordHTTPStatus records the response status on the logger in ctx, as
+// (*Logger).RecordHTTPStatus does. It does nothing if ctx has no logger.
+//
+// Example:
+//
+//	next.ServeHTTP(rec, r)
+//	canonlog.RecordHTTPStatus(ctx, rec.status, canonlog.WithStatusMessage(http.StatusText))
+func RecordHTTPStatus(ctx context.Context, status int, opts ...StatusOption) {
+	if l, ok := TryGetLogger(ctx); ok {
+		l.RecordHTTPStatus(status, opts...)
+	}
+}
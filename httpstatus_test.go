@@ -0,0 +1,78 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestRecordHTTPStatus(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	tests := []struct {
+		status int
+		opts   []StatusOption
+		level  string
+	}{
+		{200, nil, "INFO"},
+		{404, nil, "INFO"},
+		{404, []StatusOption{WithClientErrorsAsWarn()}, "WARN"},
+		{503, nil, "ERROR"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		ctx := NewContext(context.Background())
+		RecordHTTPStatus(ctx, tt.status, tt.opts...)
+		Flush(ctx)
+		entry := decodeEntry(t, buf)
+		if entry["status"] != float64(tt.status) || entry["level"] != tt.level {
+			t.Errorf("status %d: expected level %s, got %v", tt.status, tt.level, entry)
+		}
+		if entry["msg"] != "" {
+			t.Errorf("status %d: expected no message by default, got %q", tt.status, entry["msg"])
+		}
+	}
+
+	// Without a logger it is a no-op
+	RecordHTTPStatus(context.Background(), 500)
+}
+
+func TestRecordHTTPStatusMessage(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.RecordHTTPStatus(502, WithStatusMessage(http.StatusText))
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["msg"] != "Bad Gateway" {
+		t.Errorf("Expected message Bad Gateway, got %v", entry["msg"])
+	}
+
+	// The message is reset with the entry
+	buf.Reset()
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["msg"] != "" {
+		t.Errorf("Expected the message reset after Flush, got %v", entry["msg"])
+	}
+}
+
+func TestRecordHTTPStatusGate(t *testing.T) {
+	defer setTestLogLevel(slog.LevelError)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.RecordHTTPStatus(404, WithClientErrorsAsWarn())
+	l.Flush(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("Expected a 4xx to be dropped at Error level, got %s", buf)
+	}
+
+	l.RecordHTTPStatus(500)
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["status"] != float64(500) || entry["level"] != "ERROR" {
+		t.Errorf("Expected the 5xx recorded at Error level, got %v", entry)
+	}
+}
@@ -0,0 +1,106 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// WithHTTPTrace returns a context whose outbound HTTP requests record
+// connection-level timings on the logger in ctx, each field prefixed with
+// prefix and an underscore:
+//
+//   - dns_ms, connect_ms, and tls_ms for new connections
+//   - conn_reused, true when an idle connection was reused
+//   - ttfb_ms, the time from sending the request to the first response byte
+//
+// Durations are in fractional milliseconds, measured with the logger's Clock.
+// Use a distinct prefix per downstream dependency, since a later request with
+// the same prefix overwrites the fields. Hooks already in ctx, for example
+// from a tracing SDK, keep running. ctx is returned unchanged if it has no
+// logger.
+//
+// Example:
+//
+//	req, _ := http.NewRequestWithContext(canonlog.WithHTTPTrace(ctx, "payments"), "POST", url, body)
+//	resp, err := client.Do(req)
+func WithHTTPTrace(ctx context.Context, prefix string) context.Context {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return ctx
+	}
+
+	clock := l.Clock()
+	var (
+		mu                            sync.Mutex
+		dnsStart, connStart, tlsStart time.Time
+		wroteRequest                  time.Time
+	)
+	since := func(start time.Time) float64 {
+		return float64(clock.Since(start).Microseconds()) / 1000
+	}
+	key := func(name string) string { return prefix + "_" + name }
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart = clock.Now()
+			mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			start := dnsStart
+			mu.Unlock()
+			l.InfoAdd(key("dns_ms"), since(start))
+		},
+		ConnectStart: func(string, string) {
+			mu.Lock()
+			if connStart.IsZero() {
+				connStart = clock.Now() // parallel dials share the first start
+			}
+			mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			start := connStart
+			mu.Unlock()
+			l.InfoAdd(key("connect_ms"), since(start))
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = clock.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			start := tlsStart
+			mu.Unlock()
+			l.InfoAdd(key("tls_ms"), since(start))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			l.InfoAdd(key("conn_reused"), info.Reused)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wroteRequest = clock.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			start := wroteRequest
+			mu.Unlock()
+			if !start.IsZero() {
+				l.InfoAdd(key("ttfb_ms"), since(start))
+			}
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace)
+}
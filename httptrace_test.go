@@ -0,0 +1,80 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPTrace(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	client := srv.Client()
+
+	ctx := NewContext(context.Background())
+	for range 2 {
+		req, err := http.NewRequestWithContext(WithHTTPTrace(ctx, "upstream"), "GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	f := GetLogger(ctx).Fields()
+	if _, ok := f["upstream_connect_ms"].(float64); !ok {
+		t.Errorf("Expected upstream_connect_ms, got %v", f)
+	}
+	if _, ok := f["upstream_ttfb_ms"].(float64); !ok {
+		t.Errorf("Expected upstream_ttfb_ms, got %v", f)
+	}
+	if f["upstream_conn_reused"] != true {
+		t.Errorf("Expected second request to reuse the connection, got %v", f["upstream_conn_reused"])
+	}
+}
+
+func TestWithHTTPTraceUsesLoggerClock(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	ctx := NewContext(context.Background(), WithClock(clock))
+	trace := httptrace.ContextClientTrace(WithHTTPTrace(ctx, "upstream"))
+
+	trace.DNSStart(httptrace.DNSStartInfo{})
+	clock.Advance(5 * time.Millisecond)
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.ConnectStart("tcp", "10.0.0.1:443")
+	clock.Advance(20 * time.Millisecond)
+	trace.ConnectDone("tcp", "10.0.0.1:443", nil)
+	trace.TLSHandshakeStart()
+	clock.Advance(30 * time.Millisecond)
+	trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	trace.WroteRequest(httptrace.WroteRequestInfo{})
+	clock.Advance(40 * time.Millisecond)
+	trace.GotFirstResponseByte()
+
+	f := GetLogger(ctx).Fields()
+	want := map[string]float64{"upstream_dns_ms": 5, "upstream_connect_ms": 20, "upstream_tls_ms": 30, "upstream_ttfb_ms": 40}
+	for k, v := range want {
+		if f[k] != v {
+			t.Errorf("Expected %s=%v from the logger's clock, got %v", k, v, f[k])
+		}
+	}
+}
+
+func TestWithHTTPTraceWithoutLogger(t *testing.T) {
+	ctx := context.Background()
+	if WithHTTPTrace(ctx, "x") != ctx {
+		t.Error("Expected context to be returned unchanged without a logger")
+	}
+}
@@ -0,0 +1,88 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+)
+
+// Well-known fields recorded by the identity helpers.
+const (
+	tenantIDKey      = "tenant_id"
+	userIDKey        = "user_id"
+	principalTypeKey = "principal_type"
+	principalIDKey   = "principal_id"
+)
+
+// SetTenant records the tenant of the unit of work as the persistent
+// tenant_id field, giving multi-tenant services a consistent key, and applies
+// the identity policy, if any. It does nothing if ctx has no logger.
+func SetTenant(ctx context.Context, tenantID string) {
+	if l := persistIdentity(ctx, tenantIDKey, tenantID); l != nil {
+		l.applyIdentityPolicy(tenantIDKey, tenantID)
+	}
+}
+
+// SetUser records the acting end user as the persistent user_id field and
+// applies the identity policy, if any. It does nothing if ctx has no logger.
+func SetUser(ctx context.Context, userID string) {
+	if l := persistIdentity(ctx, userIDKey, userID); l != nil {
+		l.applyIdentityPolicy(userIDKey, userID)
+	}
+}
+
+// SetPrincipal records the authenticated principal as the persistent
+// principal_type and principal_id fields, for callers that are not end users,
+// such as "service", "api_key", or "job". It does nothing if ctx has no logger.
+//
+// Example:
+//
+//	canonlog.SetPrincipal(ctx, "api_key", key.ID)
+func SetPrincipal(ctx context.Context, principalType, principalID string) {
+	persistIdentity(ctx, principalTypeKey, principalType)
+	persistIdentity(ctx, principalIDKey, principalID)
+}
+
+// Tenant returns the tenant recorded with SetTenant, or "" if none.
+func Tenant(ctx context.Context) string {
+	return identityField(ctx, tenantIDKey)
+}
+
+// User returns the user recorded with SetUser, or "" if none.
+func User(ctx context.Context) string {
+	return identityField(ctx, userIDKey)
+}
+
+// Principal returns the principal recorded with SetPrincipal, or empty
+// strings if none.
+func Principal(ctx context.Context) (principalType, principalID string) {
+	return identityField(ctx, principalTypeKey), identityField(ctx, principalIDKey)
+}
+
+// persistIdentity records an identity field on the logger in ctx and returns
+// the logger, or nil if ctx has none.
+func persistIdentity(ctx context.Context, key, value string) *Logger {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return nil
+	}
+	l.Persist(key, value)
+	return l
+}
+
+// identityField returns the identity field key of the logger in ctx.
+func identityField(ctx context.Context, key string) string {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return ""
+	}
+	l.mu.Lock()
+	v, ok := l.persistent[key]
+	l.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
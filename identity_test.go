@@ -0,0 +1,45 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestIdentityHelpers(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	SetTenant(ctx, "acme")
+	SetUser(ctx, "u-1")
+	SetPrincipal(ctx, "api_key", "key-9")
+
+	if Tenant(ctx) != "acme" || User(ctx) != "u-1" {
+		t.Errorf("Expected tenant and user to be readable, got %q %q", Tenant(ctx), User(ctx))
+	}
+	if typ, id := Principal(ctx); typ != "api_key" || id != "key-9" {
+		t.Errorf("Expected principal api_key/key-9, got %q/%q", typ, id)
+	}
+
+	InfoAdd(ctx, "item", 1)
+	Flush(ctx)
+	InfoAdd(ctx, "item", 2)
+	buf.Reset()
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	for k, want := range map[string]string{"tenant_id": "acme", "user_id": "u-1", "principal_type": "api_key", "principal_id": "key-9"} {
+		if entry[k] != want {
+			t.Errorf("Expected persistent %s=%s after Flush, got %v", k, want, entry[k])
+		}
+	}
+}
+
+func TestIdentityHelpersWithoutLogger(t *testing.T) {
+	ctx := context.Background()
+	SetTenant(ctx, "acme") // must not panic
+	if Tenant(ctx) != "" || User(ctx) != "" {
+		t.Error("Expected empty identity without a logger")
+	}
+}
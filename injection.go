@@ -0,0 +1,82 @@
+package canonlog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// InjectionPolicy controls how a logger handles field keys and string values
+// containing newlines, ANSI escape sequences, or other control characters,
+// which can be used to forge log lines or corrupt terminals.
+type InjectionPolicy int
+
+const (
+	// InjectionAllow records keys and values as given. This is the default;
+	// the JSON and text handlers still quote such values when writing them.
+	InjectionAllow InjectionPolicy = iota
+
+	// InjectionEscape records the field with its key and value passed through
+	// Sanitize, so newlines are escaped and other control characters removed.
+	InjectionEscape
+
+	// InjectionDrop discards the field.
+	InjectionDrop
+
+	// InjectionError discards the field and records an error, which escalates
+	// the entry to Error.
+	InjectionError
+)
+
+// WithInjectionPolicy sets how the logger handles keys and string values that
+// contain control characters. Every violation is also reported to the hook set
+// with SetInjectionHook. The default is InjectionAllow.
+//
+// Example:
+//
+//	ctx = canonlog.NewContext(ctx, canonlog.WithInjectionPolicy(canonlog.InjectionEscape))
+//	canonlog.InfoAdd(ctx, "user_agent", r.UserAgent()) // "x\nlevel=ERROR" is recorded as "x\\nlevel=ERROR"
+func WithInjectionPolicy(policy InjectionPolicy) Option {
+	return func(l *Logger) {
+		l.injectionPolicy = policy
+	}
+}
+
+// injectionHook holds the hook set by SetInjectionHook.
+var injectionHook atomic.Pointer[func(key, value string)]
+
+// SetInjectionHook installs a function called for every field rejected or
+// escaped by an injection policy, with the original key and value (empty for
+// non-string values), for example to count attempts or alert on them. It runs
+// with the logger's lock held and must not call back into the logger.
+// Passing nil removes the hook.
+func SetInjectionHook(fn func(key, value string)) {
+	if fn == nil {
+		injectionHook.Store(nil)
+		return
+	}
+	injectionHook.Store(&fn)
+}
+
+// checkInjection applies the logger's injection policy to a field. It returns
+// the key and value to store, or ok=false if the field is discarded.
+// Must be called with l.mu held.
+func (l *Logger) checkInjection(key string, value any) (string, any, bool) {
+	s, isString := value.(string)
+	if !needsSanitize(key) && (!isString || !needsSanitize(s)) {
+		return key, value, true
+	}
+	if fn := injectionHook.Load(); fn != nil {
+		(*fn)(key, s)
+	}
+
+	switch l.injectionPolicy {
+	case InjectionEscape:
+		if isString {
+			value = Sanitize(s)
+		}
+		return Sanitize(key), value, true
+	case InjectionError:
+		l.addError(fmt.Errorf("canonlog: control characters in field %q", Sanitize(key)), errorSource{})
+	}
+	return key, value, false
+}
@@ -0,0 +1,69 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestWithInjectionPolicy(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	tests := []struct {
+		policy    InjectionPolicy
+		wantField map[string]any
+		wantLevel slog.Level
+	}{
+		{InjectionAllow, map[string]any{"ua": "x\nlevel=ERROR", "ok": "fine", "k\x1b[2J": 1}, slog.LevelInfo},
+		{InjectionEscape, map[string]any{"ua": `x\nlevel=ERROR`, "ok": "fine", "k[2J": 1}, slog.LevelInfo},
+		{InjectionDrop, map[string]any{"ok": "fine"}, slog.LevelInfo},
+		{InjectionError, map[string]any{"ok": "fine"}, slog.LevelError},
+	}
+	for _, tt := range tests {
+		l := New(WithInjectionPolicy(tt.policy))
+		l.InfoAdd("ua", "x\nlevel=ERROR")
+		l.InfoAdd("ok", "fine")
+		l.InfoAdd("k\x1b[2J", 1)
+
+		got := l.Fields()
+		if len(got) != len(tt.wantField) {
+			t.Errorf("policy %d: expected fields %q, got %q", tt.policy, tt.wantField, got)
+			continue
+		}
+		for k, v := range tt.wantField {
+			if got[k] != v {
+				t.Errorf("policy %d: expected %q=%q, got %q", tt.policy, k, v, got[k])
+			}
+		}
+		if l.Level() != tt.wantLevel {
+			t.Errorf("policy %d: expected level %v, got %v", tt.policy, tt.wantLevel, l.Level())
+		}
+	}
+}
+
+func TestInjectionHook(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	var violations []string
+	SetInjectionHook(func(key, value string) { violations = append(violations, key+"="+value) })
+	t.Cleanup(func() { SetInjectionHook(nil) })
+
+	l := New(WithInjectionPolicy(InjectionError))
+	l.InfoAdd("path", "/a\r\n/b")
+	l.Persist("request_id", "abc\n")
+	l.InfoAdd("status", 200)
+	l.Flush(context.Background())
+
+	if len(violations) != 2 || violations[0] != "path=/a\r\n/b" || violations[1] != "request_id=abc\n" {
+		t.Errorf("Expected two reported violations, got %q", violations)
+	}
+	entry := decodeEntry(t, buf)
+	errs, _ := entry["errors"].([]any)
+	if len(errs) != 2 || errs[0] != `canonlog: control characters in field "path"` {
+		t.Errorf("Expected injection errors, got %v", entry["errors"])
+	}
+	if _, ok := entry["request_id"]; ok {
+		t.Error("Expected the persistent field to be rejected")
+	}
+}
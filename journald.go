@@ -0,0 +1,224 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultJournalSocket is where systemd-journald listens for the native protocol.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// maxJournalFieldName is the longest field name journald accepts.
+const maxJournalFieldName = 64
+
+// JournalConfig configures NewJournalHandler.
+type JournalConfig struct {
+	// SocketPath is journald's native protocol socket. Defaults to
+	// /run/systemd/journal/socket.
+	SocketPath string
+
+	// Identifier is sent as SYSLOG_IDENTIFIER, which journalctl -t filters
+	// on. Defaults to the program name.
+	Identifier string
+
+	// Level is the minimum level sent. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+}
+
+// JournalHandler is a slog.Handler that writes entries to systemd-journald
+// with the native protocol.
+type JournalHandler struct {
+	chain []groupOrAttrs
+	state *journalState
+}
+
+// journalState is shared by a JournalHandler and the handlers derived from it.
+type journalState struct {
+	cfg  JournalConfig
+	conn *net.UnixConn
+
+	mu   sync.Mutex // serializes use of text and buf
+	text slog.Handler
+	msg  bytes.Buffer
+}
+
+// NewJournalHandler returns a handler that sends each entry to journald as a
+// structured journal entry, for bare-metal and VM deployments under systemd.
+// Every field becomes a journal field with its key upper-cased and characters
+// other than letters, digits, and underscores replaced, so request_id is
+// queryable with journalctl REQUEST_ID=req-123 and group members are joined
+// with underscores. The level is sent as PRIORITY and the fields rendered as
+// text as MESSAGE. Entries too large for a datagram are passed to journald
+// in a file descriptor on Linux. It returns an error if the socket cannot be
+// reached, so callers can fall back to standard output.
+//
+// Example:
+//
+//	journal, err := canonlog.NewJournalHandler(canonlog.JournalConfig{Identifier: "checkout"})
+//	if err != nil {
+//		return err
+//	}
+//	slog.SetDefault(slog.New(journal))
+func NewJournalHandler(cfg JournalConfig) (*JournalHandler, error) {
+	if cfg.SocketPath == "" {
+		cfg.SocketPath = defaultJournalSocket
+	}
+	if cfg.Identifier == "" {
+		cfg.Identifier = filepath.Base(os.Args[0])
+	}
+	if cfg.Level == nil {
+		cfg.Level = slog.LevelInfo
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: cfg.SocketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	s := &journalState{cfg: cfg, conn: conn}
+	s.text = slog.NewTextHandler(&s.msg, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey ||
+				(a.Key == slog.MessageKey && a.Value.String() == "")) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return &JournalHandler{state: s}, nil
+}
+
+// Enabled reports whether level meets the configured minimum.
+func (h *JournalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.state.cfg.Level.Level()
+}
+
+// Handle sends r to journald.
+func (h *JournalHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	attrs := recordAttrs(r, h.chain)
+
+	var b []byte
+	b = appendJournalField(b, "PRIORITY", SyslogSeverity(r.Level).String())
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", s.cfg.Identifier)
+	for _, a := range attrs {
+		b = appendJournalAttr(b, "", a)
+	}
+
+	s.mu.Lock()
+	s.msg.Reset()
+	text := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	text.AddAttrs(attrs...)
+	err := s.text.Handle(ctx, text)
+	msg := strings.TrimSuffix(s.msg.String(), "\n")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	b = appendJournalField(b, "MESSAGE", msg)
+
+	if _, err := s.conn.Write(b); err != nil {
+		if !isMessageTooLong(err) {
+			return err
+		}
+		return sendJournalFile(s.conn, b)
+	}
+	return nil
+}
+
+// appendJournalAttr appends a as journal fields, flattening groups with
+// underscores.
+func appendJournalAttr(b []byte, prefix string, a slog.Attr) []byte {
+	if a.Equal(slog.Attr{}) {
+		return b
+	}
+	name := a.Key
+	if prefix != "" && name != "" {
+		name = prefix + "_" + name
+	} else if prefix != "" {
+		name = prefix
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		for _, ga := range v.Group() {
+			b = appendJournalAttr(b, name, ga)
+		}
+		return b
+	case slog.KindTime:
+		return appendJournalField(b, journalFieldName(name), v.Time().Format(time.RFC3339Nano))
+	}
+	return appendJournalField(b, journalFieldName(name), v.String())
+}
+
+// appendJournalField appends one field in the native protocol's format: the
+// simple NAME=value form, or the binary-safe length-prefixed form for values
+// containing newlines.
+func appendJournalField(b []byte, name, value string) []byte {
+	if name == "" {
+		return b
+	}
+	if !strings.Contains(value, "\n") {
+		b = append(b, name...)
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, name...)
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalFieldName converts key to a valid journal field name: upper case
+// letters, digits, and underscores, not starting with an underscore or digit,
+// and at most 64 bytes.
+func journalFieldName(key string) string {
+	var sb strings.Builder
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z':
+			sb.WriteRune(c - 'a' + 'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			sb.WriteRune(c)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(sb.String(), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+	return name
+}
+
+// WithAttrs returns a handler that adds attrs to every entry.
+func (h *JournalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &JournalHandler{chain: append(h.chain[:len(h.chain):len(h.chain)], groupOrAttrs{attrs: attrs}), state: h.state}
+}
+
+// WithGroup returns a handler that nests later attributes under name.
+func (h *JournalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &JournalHandler{chain: append(h.chain[:len(h.chain):len(h.chain)], groupOrAttrs{group: name}), state: h.state}
+}
+
+// Close closes the connection to journald.
+func (h *JournalHandler) Close(ctx context.Context) error {
+	return h.state.conn.Close()
+}
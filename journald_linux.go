@@ -0,0 +1,47 @@
+package canonlog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// isMessageTooLong reports whether err means the entry does not fit in a
+// datagram.
+func isMessageTooLong(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile passes b to journald in an unlinked temporary file, the
+// protocol's fallback for entries too large for a datagram.
+func sendJournalFile(conn *net.UnixConn, b []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "canonlog-journal-")
+	if err != nil {
+		if f, err = os.CreateTemp("", "canonlog-journal-"); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	// WriteMsgUnix refuses connected datagram sockets, so send directly
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	var sendErr error
+	err = rc.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,61 @@
+package canonlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournalHandlerLargeEntry(t *testing.T) {
+	conn, path := journalSocket(t)
+	h, err := NewJournalHandler(JournalConfig{SocketPath: path})
+	if err != nil {
+		t.Fatalf("NewJournalHandler: %v", err)
+	}
+	defer h.Close(context.Background())
+
+	big := strings.Repeat("x", 4<<20)
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "", 0)); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	readJournalEntry(t, conn)
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "", 0)
+	r.AddAttrs(slog.String("body", big))
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle large entry: %v", err)
+	}
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("datagram has %d bytes, want an fd only", n)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("control messages = %v, %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("fds = %v, %v", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read fd: %v", err)
+	}
+	if fields := parseJournalFields(t, b); fields["BODY"] != big {
+		t.Errorf("BODY has %d bytes, want %d", len(fields["BODY"]), len(big))
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package canonlog
+
+import "net"
+
+// isMessageTooLong reports false, since journald only runs on Linux.
+func isMessageTooLong(err error) bool {
+	return false
+}
+
+// sendJournalFile is never called outside Linux.
+func sendJournalFile(conn *net.UnixConn, b []byte) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// journalSocket listens on a unixgram socket standing in for journald.
+func journalSocket(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, path
+}
+
+// parseJournalFields decodes a native protocol payload.
+func parseJournalFields(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		nl := bytes.IndexByte(b, '\n')
+		if nl < 0 {
+			t.Fatalf("unterminated field %q", b)
+		}
+		line := b[:nl]
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			b = b[nl+1:]
+			continue
+		}
+		b = b[nl+1:]
+		n := binary.LittleEndian.Uint64(b)
+		fields[string(line)] = string(b[8 : 8+n])
+		b = b[8+n+1:]
+	}
+	return fields
+}
+
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 1<<16)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return parseJournalFields(t, buf[:n])
+}
+
+func TestJournalHandler(t *testing.T) {
+	conn, path := journalSocket(t)
+	h, err := NewJournalHandler(JournalConfig{SocketPath: path, Identifier: "checkout"})
+	if err != nil {
+		t.Fatalf("NewJournalHandler: %v", err)
+	}
+	defer h.Close(context.Background())
+
+	logger := slog.New(h).With("request_id", "req-1")
+	logger.Warn("", "status", 200, slog.Group("db", "queries", 3), "error", "line one\nline two")
+	fields := readJournalEntry(t, conn)
+
+	want := map[string]string{
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "checkout",
+		"REQUEST_ID":        "req-1",
+		"STATUS":            "200",
+		"DB_QUERIES":        "3",
+		"ERROR":             "line one\nline two",
+		"MESSAGE":           `request_id=req-1 status=200 db.queries=3 error="line one\nline two"`,
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %q, want %q", k, fields[k], v)
+		}
+	}
+
+	slog.New(h).Debug("dropped")
+	slog.New(h).WithGroup("job").Info("done", "id", 7)
+	fields = readJournalEntry(t, conn)
+	if fields["JOB_ID"] != "7" || fields["PRIORITY"] != "6" || fields["MESSAGE"] != "msg=done job.id=7" {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestNewJournalHandlerNoSocket(t *testing.T) {
+	if _, err := NewJournalHandler(JournalConfig{SocketPath: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected error for missing socket")
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"request_id":       "REQUEST_ID",
+		"http.status-code": "HTTP_STATUS_CODE",
+		"_private":         "PRIVATE",
+		"2fa":              "F_2FA",
+		"ünicode":          "NICODE",
+	}
+	for in, want := range tests {
+		if got := journalFieldName(in); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := journalFieldName(string(bytes.Repeat([]byte("a"), 100))); len(got) != maxJournalFieldName {
+		t.Errorf("long name has length %d, want %d", len(got), maxJournalFieldName)
+	}
+}
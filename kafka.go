@@ -0,0 +1,237 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// KafkaMessage is one message for a KafkaProducer.
+type KafkaMessage struct {
+	Topic string
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// KafkaProducer publishes messages to Kafka. canonlog has no dependencies, so
+// this adapts whichever client the application already uses, such as
+// franz-go, sarama, or segmentio/kafka-go. Produce should return once the
+// batch is acknowledged, and return an error only if the whole batch should be
+// retried; configure the client as an idempotent producer so retries do not
+// duplicate messages.
+type KafkaProducer interface {
+	Produce(ctx context.Context, msgs []KafkaMessage) error
+}
+
+// KafkaProducerFunc adapts a function to a KafkaProducer.
+type KafkaProducerFunc func(ctx context.Context, msgs []KafkaMessage) error
+
+// Produce implements KafkaProducer.
+func (f KafkaProducerFunc) Produce(ctx context.Context, msgs []KafkaMessage) error {
+	return f(ctx, msgs)
+}
+
+// KafkaConfig configures NewKafkaHandler.
+type KafkaConfig struct {
+	// Producer publishes batches. Required; NewKafkaHandler panics without
+	// one rather than failing later on the publishing goroutine.
+	Producer KafkaProducer
+
+	// Topic is the topic every message is published to.
+	Topic string
+
+	// KeyFields lists fields whose value becomes the message key; the first
+	// one present is used, so all lines with the same key land on the same
+	// partition in order. Defaults to request_id. Lines with none of the
+	// fields have no key.
+	KeyFields []string
+
+	// Level is the minimum level sent. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// BatchSize is the number of messages that triggers a publish. Defaults
+	// to 100.
+	BatchSize int
+
+	// BatchWait is the longest a message waits before being published.
+	// Defaults to one second.
+	BatchWait time.Duration
+
+	// QueueSize is the number of messages buffered while a publish is in
+	// progress. Lines logged while the queue is full are dropped and counted
+	// by Dropped. Defaults to 10000.
+	QueueSize int
+
+	// MaxRetries is the number of times a failed publish is retried, with
+	// exponential backoff from 100ms. Defaults to 5.
+	MaxRetries int
+
+	// Timeout bounds each Produce call. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// OnError is called with the last error and the messages of a batch that
+	// could not be published after all retries, for example to write them to
+	// a local dead letter file. It runs on the publishing goroutine.
+	OnError func(err error, msgs []KafkaMessage)
+
+	// OnDrop, if set, is called when messages are dropped, with how many and why.
+	// Drops from a full queue are summed and reported from the sending
+	// goroutine after each batch; drops after Close are reported on the
+	// logging goroutine, so OnDrop must not log through this handler.
+	OnDrop func(DroppedEntries)
+
+	// DeadLetter, if set, receives a JSON line for each drop reported to
+	// OnDrop, with sink, reason, count, and error, for example os.Stderr.
+	DeadLetter io.Writer
+}
+
+// KafkaHandler is a slog.Handler that publishes each line as a Kafka message.
+type KafkaHandler struct {
+	inner slog.Handler // JSON handler writing into state.buf
+	attrs []slog.Attr  // attributes added with WithAttrs, checked for keys
+	state *kafkaState
+}
+
+// kafkaState is shared by a KafkaHandler and the handlers derived from it.
+type kafkaState struct {
+	cfg  KafkaConfig
+	mu   sync.Mutex // serializes use of buf
+	buf  bytes.Buffer
+	msgs *batcher[KafkaMessage]
+}
+
+// NewKafkaHandler returns a handler that publishes lines, encoded as JSON, to
+// cfg.Topic from a background goroutine, for pipelines that are Kafka-first.
+// Call Close during shutdown, for example by registering it with
+// AddShutdownHook, to publish the lines still buffered.
+//
+// Example:
+//
+//	kafka := canonlog.NewKafkaHandler(canonlog.KafkaConfig{
+//		Topic:     "canonical-logs",
+//		KeyFields: []string{"tenant_id", "request_id"},
+//		Producer: canonlog.KafkaProducerFunc(func(ctx context.Context, msgs []canonlog.KafkaMessage) error {
+//			records := make([]*kgo.Record, len(msgs))
+//			for i, m := range msgs {
+//				records[i] = &kgo.Record{Topic: m.Topic, Key: m.Key, Value: m.Value, Timestamp: m.Time}
+//			}
+//			return client.ProduceSync(ctx, records...).FirstErr()
+//		}),
+//	})
+//	slog.SetDefault(slog.New(kafka))
+//	canonlog.AddShutdownHook(kafka.Close)
+func NewKafkaHandler(cfg KafkaConfig) *KafkaHandler {
+	if f, ok := cfg.Producer.(KafkaProducerFunc); cfg.Producer == nil || (ok && f == nil) {
+		panic("canonlog: NewKafkaHandler requires a Producer")
+	}
+	if len(cfg.KeyFields) == 0 {
+		cfg.KeyFields = []string{"request_id"}
+	}
+	if cfg.Level == nil {
+		cfg.Level = slog.LevelInfo
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.BatchWait <= 0 {
+		cfg.BatchWait = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	s := &kafkaState{cfg: cfg}
+	s.msgs = newBatcher(cfg.BatchSize, cfg.QueueSize, cfg.BatchWait, s.publish, nil,
+		dropReporter("kafka", cfg.OnDrop, cfg.DeadLetter))
+	h := &KafkaHandler{state: s}
+	h.inner = slog.NewJSONHandler(&s.buf, &slog.HandlerOptions{Level: cfg.Level})
+	return h
+}
+
+// Enabled reports whether level meets the configured minimum.
+func (h *KafkaHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.state.cfg.Level.Level()
+}
+
+// Handle encodes r and queues it for the next publish. It never blocks; if
+// the queue is full, the line is dropped.
+func (h *KafkaHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	s.mu.Lock()
+	s.buf.Reset()
+	err := h.inner.Handle(ctx, r)
+	value := bytes.Clone(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.msgs.add(KafkaMessage{Topic: s.cfg.Topic, Key: h.key(r), Value: value, Time: r.Time})
+	return nil
+}
+
+// key returns the value of the first key field present on the line.
+func (h *KafkaHandler) key(r slog.Record) []byte {
+	best := len(h.state.cfg.KeyFields)
+	var key []byte
+	match := func(a slog.Attr) bool {
+		if i := slices.Index(h.state.cfg.KeyFields, a.Key); i >= 0 && i < best {
+			best = i
+			key = []byte(a.Value.Resolve().String())
+		}
+		return best > 0
+	}
+	for _, a := range h.attrs {
+		match(a)
+	}
+	r.Attrs(match)
+	return key
+}
+
+// WithAttrs returns a handler that adds attrs to every line.
+func (h *KafkaHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &KafkaHandler{inner: h.inner.WithAttrs(attrs), attrs: slices.Concat(h.attrs, attrs), state: h.state}
+}
+
+// WithGroup returns a handler that nests later attributes under name.
+func (h *KafkaHandler) WithGroup(name string) slog.Handler {
+	return &KafkaHandler{inner: h.inner.WithGroup(name), attrs: h.attrs, state: h.state}
+}
+
+// Dropped returns the number of lines dropped because the queue was full, the
+// handler was closed, or a publish failed after all retries.
+func (h *KafkaHandler) Dropped() uint64 {
+	return h.state.msgs.dropped.Load()
+}
+
+// Close stops accepting lines and publishes those still queued, waiting until
+// they are sent or ctx is done.
+func (h *KafkaHandler) Close(ctx context.Context) error {
+	return h.state.msgs.close(ctx)
+}
+
+// publish produces batch, retrying failures, and reports batches that cannot
+// be delivered to OnError.
+func (s *kafkaState) publish(batch []KafkaMessage) {
+	err := retry(s.cfg.MaxRetries, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+		defer cancel()
+		return s.cfg.Producer.Produce(ctx, batch)
+	})
+	if err == nil {
+		return
+	}
+	s.msgs.drop(len(batch), DropSendFailed, err)
+	if s.cfg.OnError != nil {
+		s.cfg.OnError(err, slices.Clone(batch))
+	}
+}
@@ -0,0 +1,127 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProducer records produced batches, failing the first failures calls.
+type fakeProducer struct {
+	mu       sync.Mutex
+	batches  [][]KafkaMessage
+	calls    int
+	failures int
+}
+
+func (p *fakeProducer) Produce(ctx context.Context, msgs []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("broker unavailable")
+	}
+	p.batches = append(p.batches, slices.Clone(msgs))
+	return nil
+}
+
+func TestKafkaHandler(t *testing.T) {
+	p := &fakeProducer{}
+	h := NewKafkaHandler(KafkaConfig{
+		Producer:  p,
+		Topic:     "canonical",
+		KeyFields: []string{"tenant_id", "request_id"},
+		BatchWait: time.Hour,
+	})
+	logger := slog.New(h)
+	logger.Info("", "request_id", "req-1", "tenant_id", "acme", "status", 200)
+	logger.With("request_id", "req-2").Info("", "status", 201)
+	logger.Info("", "status", 202)
+	logger.Debug("", "request_id", "req-3")
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(p.batches) != 1 || len(p.batches[0]) != 3 {
+		t.Fatalf("batches = %v, want one batch of 3", p.batches)
+	}
+	msgs := p.batches[0]
+	for i, want := range []string{"acme", "req-2", ""} {
+		if msgs[i].Topic != "canonical" {
+			t.Errorf("msgs[%d].Topic = %q", i, msgs[i].Topic)
+		}
+		if string(msgs[i].Key) != want {
+			t.Errorf("msgs[%d].Key = %q, want %q", i, msgs[i].Key, want)
+		}
+	}
+	var line map[string]any
+	if err := json.Unmarshal(msgs[1].Value, &line); err != nil {
+		t.Fatalf("value is not JSON: %v", err)
+	}
+	if line["request_id"] != "req-2" || line["status"] != float64(201) {
+		t.Errorf("value = %v", line)
+	}
+	if msgs[0].Time.IsZero() {
+		t.Error("Time is zero")
+	}
+}
+
+func TestKafkaHandlerRetries(t *testing.T) {
+	p := &fakeProducer{failures: 2}
+	h := NewKafkaHandler(KafkaConfig{Producer: p, Topic: "t", BatchWait: time.Hour})
+	slog.New(h).Info("")
+	h.Close(context.Background())
+
+	if p.calls != 3 || len(p.batches) != 1 {
+		t.Errorf("calls = %d, batches = %d, want 3 and 1", p.calls, len(p.batches))
+	}
+	if h.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", h.Dropped())
+	}
+}
+
+func TestKafkaHandlerOnError(t *testing.T) {
+	p := &fakeProducer{failures: 10}
+	var failed []KafkaMessage
+	var failErr error
+	h := NewKafkaHandler(KafkaConfig{
+		Producer:   p,
+		Topic:      "t",
+		BatchWait:  time.Hour,
+		MaxRetries: 1,
+		OnError: func(err error, msgs []KafkaMessage) {
+			failErr, failed = err, msgs
+		},
+	})
+	slog.New(h).Info("", "request_id", "r1")
+	slog.New(h).Info("", "request_id", "r2")
+	h.Close(context.Background())
+
+	if p.calls != 2 {
+		t.Errorf("calls = %d, want 2", p.calls)
+	}
+	if failErr == nil || len(failed) != 2 || string(failed[1].Key) != "r2" {
+		t.Errorf("OnError got %v, %v", failErr, failed)
+	}
+	if h.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", h.Dropped())
+	}
+}
+
+func TestNewKafkaHandlerRequiresProducer(t *testing.T) {
+	for name, p := range map[string]KafkaProducer{"nil": nil, "nil func": KafkaProducerFunc(nil)} {
+		func() {
+			defer func() {
+				if r := recover(); r != "canonlog: NewKafkaHandler requires a Producer" {
+					t.Errorf("%s: expected a panic naming the missing Producer, got %v", name, r)
+				}
+			}()
+			NewKafkaHandler(KafkaConfig{Producer: p, Topic: "t"})
+		}()
+	}
+}
@@ -0,0 +1,123 @@
+package canonlog
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// keyNormalizer rewrites field keys at emission, if set.
+var keyNormalizer atomic.Pointer[func(string) string]
+
+// SetKeyNormalizer installs a function that rewrites every field key when an
+// entry is emitted, so one naming convention is enforced no matter how fields
+// were added. Normalizers can be combined with ChainKeyNormalizers. If two keys
+// normalize to the same key, a key already in normalized form wins.
+// Passing nil removes the normalizer.
+//
+// Example:
+//
+//	canonlog.SetKeyNormalizer(canonlog.SnakeCase)
+//	// requestID=abc userAgent=curl becomes request_id=abc user_agent=curl
+func SetKeyNormalizer(fn func(key string) string) {
+	if fn == nil {
+		keyNormalizer.Store(nil)
+		return
+	}
+	keyNormalizer.Store(&fn)
+}
+
+// normalizeKeys rewrites the keys of fields with the installed normalizer.
+// fields is returned unchanged if no normalizer is installed.
+func normalizeKeys(fields map[string]any) map[string]any {
+	fn := keyNormalizer.Load()
+	if fn == nil {
+		return fields
+	}
+	normalize := *fn
+
+	out := make(map[string]any, len(fields))
+	var renamed []string
+	for k, v := range fields {
+		if normalize(k) == k {
+			out[k] = v
+		} else {
+			renamed = append(renamed, k)
+		}
+	}
+	for _, k := range renamed {
+		nk := normalize(k)
+		if _, exists := out[nk]; !exists {
+			out[nk] = fields[k]
+		}
+	}
+	return out
+}
+
+// SnakeCase converts a key to snake_case: "requestID" and "Request-Id" both
+// become "request_id". Acronyms are kept together, so "HTTPStatus" becomes
+// "http_status".
+func SnakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// CamelCase converts a key to lowerCamelCase: "request_id" becomes "requestId".
+func CamelCase(key string) string {
+	parts := strings.FieldsFunc(SnakeCase(key), func(r rune) bool { return r == '_' })
+	for i := 1; i < len(parts); i++ {
+		r := []rune(parts[i])
+		r[0] = unicode.ToUpper(r[0])
+		parts[i] = string(r)
+	}
+	return strings.Join(parts, "")
+}
+
+// PrefixMap returns a normalizer that replaces key prefixes using m, for
+// example mapping "db_" to "database." so "db_time_ms" becomes
+// "database.time_ms". The longest matching prefix is used.
+func PrefixMap(m map[string]string) func(string) string {
+	return func(key string) string {
+		best := ""
+		for prefix := range m {
+			if len(prefix) > len(best) && strings.HasPrefix(key, prefix) {
+				best = prefix
+			}
+		}
+		if best == "" {
+			return key
+		}
+		return m[best] + key[len(best):]
+	}
+}
+
+// ChainKeyNormalizers returns a normalizer that applies fns in order.
+func ChainKeyNormalizers(fns ...func(string) string) func(string) string {
+	return func(key string) string {
+		for _, fn := range fns {
+			key = fn(key)
+		}
+		return key
+	}
+}
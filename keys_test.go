@@ -0,0 +1,81 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"requestID":    "request_id",
+		"user_agent":   "user_agent",
+		"UserAgent":    "user_agent",
+		"HTTPStatus":   "http_status",
+		"Request-Id":   "request_id",
+		"db.query_ms":  "db_query_ms",
+		"retry2Count":  "retry2_count",
+		"already__bad": "already_bad",
+		"ID":           "id",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"request_id": "requestId",
+		"requestID":  "requestId",
+		"user-agent": "userAgent",
+		"status":     "status",
+	}
+	for in, want := range tests {
+		if got := CamelCase(in); got != want {
+			t.Errorf("CamelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPrefixMap(t *testing.T) {
+	fn := PrefixMap(map[string]string{"db_": "database.", "db_pg_": "postgres."})
+
+	if got := fn("db_time_ms"); got != "database.time_ms" {
+		t.Errorf("Expected database.time_ms, got %q", got)
+	}
+	if got := fn("db_pg_conns"); got != "postgres.conns" {
+		t.Errorf("Expected longest prefix match, got %q", got)
+	}
+	if got := fn("status"); got != "status" {
+		t.Errorf("Expected unmatched key unchanged, got %q", got)
+	}
+}
+
+func TestSetKeyNormalizer(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetKeyNormalizer(nil) })
+
+	SetKeyNormalizer(ChainKeyNormalizers(SnakeCase, PrefixMap(map[string]string{"http_": "http."})))
+
+	l := New()
+	l.InfoAdd("requestID", "abc").InfoAdd("HTTPStatus", 200)
+	l.InfoAdd("userAgent", "camel").InfoAdd("user_agent", "snake")
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["request_id"] != "abc" {
+		t.Errorf("Expected request_id=abc, got %v", entry)
+	}
+	if entry["http.status"] != float64(200) {
+		t.Errorf("Expected http.status=200, got %v", entry)
+	}
+	if entry["user_agent"] != "snake" {
+		t.Errorf("Expected already-normalized key to win, got %v", entry["user_agent"])
+	}
+	if _, exists := entry["requestID"]; exists {
+		t.Error("Original key should be replaced")
+	}
+}
@@ -0,0 +1,57 @@
+package canonlog
+
+import "log/slog"
+
+// LevelMapper converts a slog level into the severity a logging backend
+// expects, written in place of the level attribute's value.
+type LevelMapper func(level slog.Level) slog.Value
+
+// GCPSeverity maps levels to Google Cloud Logging severities: DEBUG, INFO,
+// WARNING, ERROR, and CRITICAL for levels above Error.
+func GCPSeverity(level slog.Level) slog.Value {
+	switch {
+	case level < slog.LevelInfo:
+		return slog.StringValue("DEBUG")
+	case level < slog.LevelWarn:
+		return slog.StringValue("INFO")
+	case level < slog.LevelError:
+		return slog.StringValue("WARNING")
+	case level == slog.LevelError:
+		return slog.StringValue("ERROR")
+	}
+	return slog.StringValue("CRITICAL")
+}
+
+// SyslogSeverity maps levels to RFC 5424 syslog severities: 7 (debug),
+// 6 (informational), 4 (warning), 3 (error), and 2 (critical) for levels
+// above Error.
+func SyslogSeverity(level slog.Level) slog.Value {
+	switch {
+	case level < slog.LevelInfo:
+		return slog.IntValue(7)
+	case level < slog.LevelWarn:
+		return slog.IntValue(6)
+	case level < slog.LevelError:
+		return slog.IntValue(4)
+	case level == slog.LevelError:
+		return slog.IntValue(3)
+	}
+	return slog.IntValue(2)
+}
+
+// NumericLevel maps levels to the numeric levels used by pino and bunyan:
+// 20 (debug), 30 (info), 40 (warn), 50 (error), and 60 (fatal) for levels
+// above Error.
+func NumericLevel(level slog.Level) slog.Value {
+	switch {
+	case level < slog.LevelInfo:
+		return slog.IntValue(20)
+	case level < slog.LevelWarn:
+		return slog.IntValue(30)
+	case level < slog.LevelError:
+		return slog.IntValue(40)
+	case level == slog.LevelError:
+		return slog.IntValue(50)
+	}
+	return slog.IntValue(60)
+}
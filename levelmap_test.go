@@ -0,0 +1,26 @@
+package canonlog
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestLevelMappers(t *testing.T) {
+	levels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, slog.LevelError + 4}
+	tests := []struct {
+		name   string
+		mapper LevelMapper
+		want   []any
+	}{
+		{"gcp", GCPSeverity, []any{"DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"}},
+		{"syslog", SyslogSeverity, []any{int64(7), int64(6), int64(4), int64(3), int64(2)}},
+		{"numeric", NumericLevel, []any{int64(20), int64(30), int64(40), int64(50), int64(60)}},
+	}
+	for _, tt := range tests {
+		for i, level := range levels {
+			if got := tt.mapper(level).Any(); got != tt.want[i] {
+				t.Errorf("%s(%v) = %v, want %v", tt.name, level, got, tt.want[i])
+			}
+		}
+	}
+}
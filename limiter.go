@@ -0,0 +1,191 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// suppressedCountKey reports how many matching entries a Limiter suppressed.
+const suppressedCountKey = "suppressed_count"
+
+// limiterMaxWindows caps the signatures a Limiter tracks, so high-cardinality
+// key values cannot grow it without bound.
+const limiterMaxWindows = 10000
+
+// limiter is the globally installed Limiter, if any.
+var limiter atomic.Pointer[Limiter]
+
+// Limiter suppresses repeated entries that share the same values for a set of
+// key fields. The first matching entry in each window is emitted; later ones in
+// the same window are dropped and counted. The count is reported as
+// suppressed_count on the next matching entry emitted after the window ends, or
+// by FlushSuppressed.
+//
+// Entries that contain none of the key fields are never limited. Windows that
+// have ended are discarded once per window length, with any unreported count
+// emitted as a summary line first. At most 10000 signatures are tracked;
+// entries with new signatures beyond that are emitted unlimited until old
+// windows expire.
+//
+// Example:
+//
+//	canonlog.SetLimiter(canonlog.NewLimiter(10*time.Second, "route", "status"))
+type Limiter struct {
+	window time.Duration
+	keys   []string
+
+	mu        sync.Mutex
+	windows   map[string]*limiterWindow
+	lastSweep time.Time
+}
+
+// limiterWindow tracks one signature's current window.
+type limiterWindow struct {
+	start      time.Time
+	level      slog.Level
+	fields     map[string]any // key field values, encrypted if designated, used for summary lines
+	suppressed int
+}
+
+// NewLimiter creates a Limiter that allows one entry per window for each
+// distinct combination of values of keys.
+func NewLimiter(window time.Duration, keys ...string) *Limiter {
+	return &Limiter{
+		window:  window,
+		keys:    keys,
+		windows: make(map[string]*limiterWindow),
+	}
+}
+
+// SetLimiter installs lim for all loggers. Passing nil removes the limiter.
+func SetLimiter(lim *Limiter) {
+	limiter.Store(lim)
+}
+
+// allow reports whether an entry with fields should be emitted at now.
+// When it is allowed, suppressed is the number of matching entries dropped
+// during the previous window.
+func (lim *Limiter) allow(fields map[string]any, level slog.Level, now time.Time) (ok bool, suppressed int) {
+	sig, keyFields := lim.signature(fields)
+	if keyFields == nil {
+		return true, 0
+	}
+
+	lim.mu.Lock()
+	var expired []limiterSummary
+	if now.Sub(lim.lastSweep) >= lim.window {
+		expired = lim.sweep(now, sig)
+		lim.lastSweep = now
+	}
+	ok, suppressed = lim.allowLocked(sig, keyFields, level, now)
+	lim.mu.Unlock()
+
+	// Summaries belong to no request, so they are not logged with its context
+	logSummaries(context.Background(), expired)
+	return ok, suppressed
+}
+
+// allowLocked is allow for signature sig. Must be called with lim.mu held.
+func (lim *Limiter) allowLocked(sig string, keyFields map[string]any, level slog.Level, now time.Time) (bool, int) {
+	w, exists := lim.windows[sig]
+	if exists && now.Sub(w.start) < lim.window {
+		w.suppressed++
+		if level > w.level {
+			w.level = level
+		}
+		return false, 0
+	}
+
+	if exists {
+		suppressed := w.suppressed
+		w.start = now
+		w.level = level
+		w.suppressed = 0
+		return true, suppressed
+	}
+
+	if len(lim.windows) < limiterMaxWindows {
+		// The signature is computed on plaintext, but summary lines print
+		// these values, so encrypted fields are kept sealed
+		encryptFields(keyFields)
+		lim.windows[sig] = &limiterWindow{start: now, level: level, fields: keyFields}
+	}
+	return true, 0
+}
+
+// limiterSummary is the unreported count of one signature.
+type limiterSummary struct {
+	level  slog.Level
+	fields map[string]any
+	count  int
+}
+
+// sweep discards windows that have ended, other than keep's, whose count is
+// reported on the entry being allowed, and returns the counts they had not
+// yet reported. Must be called with lim.mu held.
+func (lim *Limiter) sweep(now time.Time, keep string) []limiterSummary {
+	var expired []limiterSummary
+	for sig, w := range lim.windows {
+		if sig == keep || now.Sub(w.start) < lim.window {
+			continue
+		}
+		if w.suppressed > 0 {
+			expired = append(expired, limiterSummary{w.level, w.fields, w.suppressed})
+		}
+		delete(lim.windows, sig)
+	}
+	return expired
+}
+
+// signature builds the limiter key for fields. It returns nil keyFields if
+// fields contains none of the limiter's keys.
+func (lim *Limiter) signature(fields map[string]any) (string, map[string]any) {
+	var keyFields map[string]any
+	var b strings.Builder
+	for _, k := range lim.keys {
+		if v, ok := fields[k]; ok {
+			if keyFields == nil {
+				keyFields = make(map[string]any, len(lim.keys))
+			}
+			keyFields[k] = v
+			fmt.Fprint(&b, v)
+		}
+		b.WriteByte(0)
+	}
+	return b.String(), keyFields
+}
+
+// FlushSuppressed emits a summary line for every signature with suppressed
+// entries that have not yet been reported. Each line contains the key field
+// values and suppressed_count, at the highest level suppressed.
+// Call it periodically or at shutdown so trailing counts are not lost.
+func (lim *Limiter) FlushSuppressed(ctx context.Context) {
+	lim.mu.Lock()
+	var summaries []limiterSummary
+	for _, w := range lim.windows {
+		if w.suppressed > 0 {
+			summaries = append(summaries, limiterSummary{w.level, w.fields, w.suppressed})
+			w.suppressed = 0
+		}
+	}
+	lim.mu.Unlock()
+
+	logSummaries(ctx, summaries)
+}
+
+// logSummaries emits a summary line for each of summaries.
+func logSummaries(ctx context.Context, summaries []limiterSummary) {
+	for _, s := range summaries {
+		attrs := make([]slog.Attr, 0, len(s.fields)+1)
+		for k, v := range s.fields {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+		attrs = append(attrs, slog.Int(suppressedCountKey, s.count))
+		slog.LogAttrs(ctx, s.level, "", attrs...)
+	}
+}
@@ -0,0 +1,147 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	lim := NewLimiter(time.Minute, "route", "status")
+	now := time.Now()
+	fields := map[string]any{"route": "/users", "status": 500, "request_id": "a"}
+
+	if ok, _ := lim.allow(fields, slog.LevelError, now); !ok {
+		t.Fatal("First entry should be allowed")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := lim.allow(fields, slog.LevelError, now.Add(time.Second)); ok {
+			t.Fatal("Repeated entry within window should be suppressed")
+		}
+	}
+
+	other := map[string]any{"route": "/users", "status": 200}
+	if ok, _ := lim.allow(other, slog.LevelInfo, now.Add(time.Second)); !ok {
+		t.Error("Entry with different key values should be allowed")
+	}
+
+	ok, suppressed := lim.allow(fields, slog.LevelError, now.Add(2*time.Minute))
+	if !ok {
+		t.Fatal("Entry after window should be allowed")
+	}
+	if suppressed != 3 {
+		t.Errorf("Expected 3 suppressed, got %d", suppressed)
+	}
+}
+
+func TestLimiterIgnoresEntriesWithoutKeys(t *testing.T) {
+	lim := NewLimiter(time.Minute, "route")
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := lim.allow(map[string]any{"job": "sync"}, slog.LevelInfo, now); !ok {
+			t.Fatal("Entries without key fields should never be limited")
+		}
+	}
+}
+
+func TestLimiterFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	lim := NewLimiter(time.Hour, "route")
+	SetLimiter(lim)
+	t.Cleanup(func() { SetLimiter(nil) })
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		New().InfoAdd("route", "/healthz").Flush(ctx)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Fatalf("Expected 1 line emitted, got %d", lines)
+	}
+
+	buf.Reset()
+	lim.FlushSuppressed(ctx)
+	entry := decodeEntry(t, buf)
+	if entry[suppressedCountKey] != float64(4) {
+		t.Errorf("Expected %s=4, got %v", suppressedCountKey, entry[suppressedCountKey])
+	}
+	if entry["route"] != "/healthz" {
+		t.Errorf("Expected route in summary line, got %v", entry["route"])
+	}
+
+	buf.Reset()
+	lim.FlushSuppressed(ctx)
+	if buf.Len() != 0 {
+		t.Error("FlushSuppressed should not repeat reported counts")
+	}
+}
+
+func TestLimiterExpiresWindows(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	lim := NewLimiter(time.Minute, "path")
+	now := time.Now()
+
+	for i := range 100 {
+		fields := map[string]any{"path": fmt.Sprintf("/users/%d", i)}
+		lim.allow(fields, slog.LevelInfo, now)
+		lim.allow(fields, slog.LevelWarn, now) // suppressed
+	}
+	if len(lim.windows) != 100 {
+		t.Fatalf("Expected 100 windows, got %d", len(lim.windows))
+	}
+
+	lim.allow(map[string]any{"path": "/health"}, slog.LevelInfo, now.Add(2*time.Minute))
+	if len(lim.windows) != 1 {
+		t.Errorf("Expected ended windows to be discarded, got %d", len(lim.windows))
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("Expected a summary line per discarded window with suppressed entries, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], `"suppressed_count":1`) || !strings.Contains(lines[0], `"level":"WARN"`) {
+		t.Errorf("Expected suppressed_count=1 at the highest level suppressed, got %s", lines[0])
+	}
+}
+
+func TestLimiterMaxWindows(t *testing.T) {
+	lim := NewLimiter(time.Hour, "path")
+	now := time.Now()
+	for i := range limiterMaxWindows + 50 {
+		if ok, _ := lim.allow(map[string]any{"path": fmt.Sprintf("/users/%d", i)}, slog.LevelInfo, now); !ok {
+			t.Fatalf("Expected first entry for signature %d to be allowed", i)
+		}
+	}
+	if len(lim.windows) != limiterMaxWindows {
+		t.Errorf("Expected at most %d windows, got %d", limiterMaxWindows, len(lim.windows))
+	}
+	untracked := map[string]any{"path": fmt.Sprintf("/users/%d", limiterMaxWindows+1)}
+	if ok, _ := lim.allow(untracked, slog.LevelInfo, now); !ok {
+		t.Error("Expected entries beyond the cap to be emitted unlimited")
+	}
+}
+
+func TestLimiterUsesLoggerClock(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	SetLimiter(NewLimiter(time.Minute, "route"))
+	t.Cleanup(func() { SetLimiter(nil) })
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	ctx := context.Background()
+	New(WithClock(clock)).InfoAdd("route", "/a").Flush(ctx)
+	New(WithClock(clock)).InfoAdd("route", "/a").Flush(ctx)
+	clock.Advance(2 * time.Minute)
+	buf.Reset()
+	New(WithClock(clock)).InfoAdd("route", "/a").Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	if entry[suppressedCountKey] != float64(1) {
+		t.Errorf("Expected the window to end by the logger's clock, got %v", entry)
+	}
+}
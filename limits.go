@@ -0,0 +1,130 @@
+package canonlog
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Keys emitted when a logger's limits discard or shorten data.
+const (
+	truncatedKey     = "canonlog_truncated"
+	fieldsDroppedKey = "canonlog_fields_dropped"
+)
+
+// WithMaxFields caps the number of distinct fields a logger accumulates.
+// Fields added under new keys once the cap is reached are dropped; updates to
+// existing keys are still applied. Dropped fields are counted and reported as
+// canonlog_fields_dropped alongside canonlog_truncated=true.
+// A value of zero or less disables the limit.
+func WithMaxFields(n int) Option {
+	return func(l *Logger) {
+		l.maxFields = n
+	}
+}
+
+// WithMaxValueLength caps the length in bytes of string and []byte field values.
+// Longer values are cut at the limit (on a UTF-8 boundary for strings) and the
+// entry is marked with canonlog_truncated=true.
+// A value of zero or less disables the limit.
+func WithMaxValueLength(n int) Option {
+	return func(l *Logger) {
+		l.maxValueLen = n
+	}
+}
+
+// WithMaxEntrySize caps the approximate size in bytes of accumulated fields,
+// measured as the sum of key and value lengths. Non-string values are measured
+// by their fmt representation. Fields that would push the entry over the limit
+// are dropped and counted in canonlog_fields_dropped.
+// A value of zero or less disables the limit.
+func WithMaxEntrySize(n int) Option {
+	return func(l *Logger) {
+		l.maxEntrySize = n
+	}
+}
+
+// storeField writes value under key, enforcing field count and entry size limits.
+// Must be called with l.mu held.
+func (l *Logger) storeField(key string, value any) {
+	if l.sanitize {
+		if s, ok := value.(string); ok {
+			value = Sanitize(s)
+		}
+	}
+	if l.injectionPolicy != InjectionAllow {
+		var ok bool
+		if key, value, ok = l.checkInjection(key, value); !ok {
+			return
+		}
+	}
+	if l.maxValueLen > 0 {
+		value = l.truncateValue(value)
+	}
+
+	old, exists := l.fields[key]
+	if !exists && l.maxFields > 0 && len(l.fields) >= l.maxFields {
+		l.fieldsDropped++
+		return
+	}
+
+	if l.maxEntrySize > 0 {
+		size := l.entrySize + fieldSize(key, value)
+		if exists {
+			size -= fieldSize(key, old)
+		}
+		if size > l.maxEntrySize {
+			l.fieldsDropped++
+			return
+		}
+		l.entrySize = size
+	}
+
+	if l.fields == nil {
+		l.fields = make(map[string]any, 16)
+	}
+	if !exists && l.fieldOrder == OrderInsertion {
+		l.order = append(l.order, key)
+	}
+	l.fields[key] = value
+}
+
+// truncateValue shortens string and []byte values that exceed maxValueLen.
+// Must be called with l.mu held.
+func (l *Logger) truncateValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if len(v) > l.maxValueLen {
+			l.truncated = true
+			return truncateString(v, l.maxValueLen)
+		}
+	case []byte:
+		if len(v) > l.maxValueLen {
+			l.truncated = true
+			return v[:l.maxValueLen]
+		}
+	}
+	return value
+}
+
+// truncateString cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// fieldSize approximates the encoded size of a field.
+func fieldSize(key string, value any) int {
+	switch v := value.(type) {
+	case string:
+		return len(key) + len(v)
+	case []byte:
+		return len(key) + len(v)
+	default:
+		return len(key) + len(fmt.Sprint(v))
+	}
+}
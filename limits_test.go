@@ -0,0 +1,98 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestMaxFields(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithMaxFields(2))
+	l.InfoAdd("a", 1).InfoAdd("b", 2).InfoAdd("c", 3).InfoAdd("d", 4)
+	l.InfoAdd("a", 10) // updates to existing keys are still applied
+
+	if len(l.fields) != 2 {
+		t.Fatalf("Expected 2 fields, got %d", len(l.fields))
+	}
+	if l.fields["a"] != 10 {
+		t.Errorf("Expected a=10, got %v", l.fields["a"])
+	}
+
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry[truncatedKey] != true {
+		t.Errorf("Expected %s=true, got %v", truncatedKey, entry[truncatedKey])
+	}
+	if entry[fieldsDroppedKey] != float64(2) {
+		t.Errorf("Expected %s=2, got %v", fieldsDroppedKey, entry[fieldsDroppedKey])
+	}
+	if _, exists := entry["c"]; exists {
+		t.Error("Dropped field c should not be emitted")
+	}
+}
+
+func TestMaxValueLength(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithMaxValueLength(5))
+	l.InfoAdd("short", "abc").InfoAdd("long", "abcdefgh").InfoAdd("utf8", "héllo")
+
+	if l.fields["short"] != "abc" {
+		t.Errorf("Expected short value untouched, got %v", l.fields["short"])
+	}
+	if l.fields["long"] != "abcde" {
+		t.Errorf("Expected long=abcde, got %v", l.fields["long"])
+	}
+	if l.fields["utf8"] != "héll" {
+		t.Errorf("Expected truncation on rune boundary, got %q", l.fields["utf8"])
+	}
+
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+
+	if entry[truncatedKey] != true {
+		t.Errorf("Expected %s=true, got %v", truncatedKey, entry[truncatedKey])
+	}
+	if _, exists := entry[fieldsDroppedKey]; exists {
+		t.Errorf("Expected no %s when only values were truncated", fieldsDroppedKey)
+	}
+}
+
+func TestMaxEntrySize(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New(WithMaxEntrySize(10))
+	l.InfoAdd("k1", "1234")  // 6 bytes
+	l.InfoAdd("k2", "12345") // would be 13 bytes, dropped
+	l.InfoAdd("k1", "12")    // shrinks to 4 bytes
+	l.InfoAdd("k3", "123")   // 9 bytes total
+
+	if _, exists := l.fields["k2"]; exists {
+		t.Error("Field k2 should have been dropped")
+	}
+	if l.fields["k3"] != "123" {
+		t.Errorf("Expected k3=123, got %v", l.fields["k3"])
+	}
+	if l.fieldsDropped != 1 {
+		t.Errorf("Expected 1 dropped field, got %d", l.fieldsDropped)
+	}
+}
+
+func TestLimitsResetAfterFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	l := New(WithMaxFields(1), WithMaxEntrySize(100))
+	l.InfoAdd("a", 1).InfoAdd("b", 2)
+	l.Flush(context.Background())
+
+	if l.fieldsDropped != 0 || l.truncated || l.entrySize != 0 {
+		t.Errorf("Expected limit state reset after Flush, got dropped=%d truncated=%v size=%d",
+			l.fieldsDropped, l.truncated, l.entrySize)
+	}
+}
@@ -0,0 +1,308 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Fields recorded by RecordLLMCall.
+const (
+	llmCallsKey            = "llm_calls"
+	llmErrorsKey           = "llm_errors"
+	llmModelKey            = "llm_model"
+	llmPromptTokensKey     = "llm_prompt_tokens"
+	llmCompletionTokensKey = "llm_completion_tokens"
+	llmTimeKey             = "llm_time_ms"
+	llmCostKey             = "llm_cost_usd"
+)
+
+// llmBodyLimit is the most of a JSON response body buffered for its usage.
+const llmBodyLimit = 1 << 20
+
+// LLMCall describes one call to a language model API.
+type LLMCall struct {
+	// Model is the model that served the call, such as "gpt-4o-2024-08-06".
+	Model string
+
+	// PromptTokens and CompletionTokens are the input and output token
+	// counts reported by the API.
+	PromptTokens     int
+	CompletionTokens int
+
+	// Latency is how long the call took, including a streamed response.
+	Latency time.Duration
+
+	// Failed is set if the call returned an error.
+	Failed bool
+}
+
+// LLMPrice is the price of a model in US dollars per million tokens.
+type LLMPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// LLMConfig configures cost estimates for RecordLLMCall.
+type LLMConfig struct {
+	// Prices maps model names to their price. A model matches the longest
+	// name it starts with, so "gpt-4o" prices "gpt-4o-2024-08-06".
+	Prices map[string]LLMPrice
+}
+
+// llmConfig holds the configuration set by SetLLMConfig.
+var llmConfig atomic.Pointer[LLMConfig]
+
+// SetLLMConfig sets the model prices used to estimate llm_cost_usd. Passing a
+// zero config disables cost estimates.
+//
+// Example:
+//
+//	canonlog.SetLLMConfig(canonlog.LLMConfig{Prices: map[string]canonlog.LLMPrice{
+//		"gpt-4o":            {Prompt: 2.50, Completion: 10},
+//		"claude-sonnet-4-5": {Prompt: 3, Completion: 15},
+//	}})
+func SetLLMConfig(cfg LLMConfig) {
+	if len(cfg.Prices) == 0 {
+		llmConfig.Store(nil)
+		return
+	}
+	cfg.Prices = maps.Clone(cfg.Prices)
+	llmConfig.Store(&cfg)
+}
+
+// llmPrice returns the configured price for model.
+func llmPrice(model string) (LLMPrice, bool) {
+	cfg := llmConfig.Load()
+	if cfg == nil {
+		return LLMPrice{}, false
+	}
+	var (
+		best  LLMPrice
+		found string
+		ok    bool
+	)
+	for name, p := range cfg.Prices {
+		if strings.HasPrefix(model, name) && (!ok || len(name) > len(found)) {
+			best, found, ok = p, name, true
+		}
+	}
+	return best, ok
+}
+
+// RecordLLMCall adds call to the totals on the logger in ctx at info level:
+// llm_calls, llm_errors, llm_prompt_tokens, llm_completion_tokens, and
+// llm_time_ms, in fractional milliseconds, with llm_model set to the most
+// recent model. If SetLLMConfig has a price for the model, the estimated
+// cost is added to llm_cost_usd. Use it with SDKs that report usage directly;
+// NewLLMTransport calls it for each API request. It does nothing if ctx has
+// no logger.
+//
+// Example:
+//
+//	start := time.Now()
+//	resp, err := client.Chat.Completions.New(ctx, params)
+//	if err == nil {
+//		canonlog.RecordLLMCall(ctx, canonlog.LLMCall{
+//			Model:            resp.Model,
+//			PromptTokens:     int(resp.Usage.PromptTokens),
+//			CompletionTokens: int(resp.Usage.CompletionTokens),
+//			Latency:          time.Since(start),
+//		})
+//	}
+func RecordLLMCall(ctx context.Context, call LLMCall) {
+	l, ok := TryGetLogger(ctx)
+	if !ok || l.gateLevel.Load() > slog.LevelInfo {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	addInt := func(key string, n int) {
+		total, _ := l.fields[key].(int)
+		l.storeField(key, total+n)
+	}
+	addInt(llmCallsKey, 1)
+	if call.Failed {
+		addInt(llmErrorsKey, 1)
+	}
+	if call.Model != "" {
+		l.storeField(llmModelKey, call.Model)
+	}
+	addInt(llmPromptTokensKey, call.PromptTokens)
+	addInt(llmCompletionTokensKey, call.CompletionTokens)
+	total, _ := l.fields[llmTimeKey].(float64)
+	l.storeField(llmTimeKey, total+float64(call.Latency)/float64(time.Millisecond))
+	if price, ok := llmPrice(call.Model); ok {
+		cost, _ := l.fields[llmCostKey].(float64)
+		cost += (float64(call.PromptTokens)*price.Prompt + float64(call.CompletionTokens)*price.Completion) / 1e6
+		l.storeField(llmCostKey, cost)
+	}
+}
+
+// llmTransport records language model API calls.
+type llmTransport struct {
+	base http.RoundTripper
+}
+
+// NewLLMTransport wraps base so calls to OpenAI-style and Anthropic-style
+// APIs are recorded with RecordLLMCall. The model and token counts are read
+// from the response's usage object, in both JSON and streamed (server-sent
+// events) responses, as the caller reads the body. Calls that fail or get a
+// 4xx or 5xx response are counted in llm_errors. A call is recorded when its
+// response body is read to the end or closed, so close it before the logger is
+// flushed or released with ReleaseContext; later ones are not recorded.
+// Requests whose context has no logger pass through unrecorded. If base is
+// nil, http.DefaultTransport is used.
+//
+// Example:
+//
+//	client := openai.NewClient(option.WithHTTPClient(&http.Client{
+//		Transport: canonlog.NewLLMTransport(nil),
+//	}))
+//	// llm_calls=1 llm_model=gpt-4o-2024-08-06 llm_prompt_tokens=812
+//	// llm_completion_tokens=164 llm_time_ms=2210.4 llm_cost_usd=0.00367
+func NewLLMTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &llmTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *llmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	l, ok := TryGetLogger(ctx)
+	if !ok || l.gateLevel.Load() > slog.LevelInfo {
+		return t.base.RoundTrip(req)
+	}
+	clock := l.Clock()
+	start := clock.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 400 || resp.Body == nil || resp.Body == http.NoBody {
+		RecordLLMCall(ctx, LLMCall{Latency: clock.Since(start), Failed: err != nil || resp.StatusCode >= 400})
+		return resp, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	resp.Body = &llmBody{
+		ReadCloser: resp.Body,
+		stream:     mediaType == "text/event-stream",
+		done: func(call LLMCall) {
+			call.Latency = clock.Since(start)
+			RecordLLMCall(ctx, call)
+		},
+	}
+	return resp, nil
+}
+
+// llmUsage is the part of a response or stream event that carries usage.
+// OpenAI reports prompt and completion tokens; Anthropic reports input and
+// output tokens, with the model and input tokens nested under message in a
+// stream's message_start event.
+type llmUsage struct {
+	Model string `json:"model"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		InputTokens      int `json:"input_tokens"`
+		OutputTokens     int `json:"output_tokens"`
+	} `json:"usage"`
+	Message *llmUsage `json:"message"`
+}
+
+// llmBody reads usage from a response body as it is read, and reports the
+// call once, at EOF or Close, whichever comes first.
+type llmBody struct {
+	io.ReadCloser
+	stream bool
+	buf    []byte // JSON body, or the unfinished line of a stream
+	call   LLMCall
+	once   sync.Once
+	done   func(LLMCall)
+}
+
+func (b *llmBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.stream {
+		b.buf = append(b.buf, p[:n]...)
+		for {
+			i := bytes.IndexByte(b.buf, '\n')
+			if i < 0 {
+				break
+			}
+			b.streamLine(b.buf[:i])
+			b.buf = b.buf[i+1:]
+		}
+		if len(b.buf) > llmBodyLimit {
+			b.buf = nil
+		}
+	} else if len(b.buf)+n <= llmBodyLimit {
+		b.buf = append(b.buf, p[:n]...)
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *llmBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+// finish reports the call.
+func (b *llmBody) finish() {
+	b.once.Do(func() {
+		if b.stream {
+			b.streamLine(b.buf)
+		} else {
+			var u llmUsage
+			if json.Unmarshal(b.buf, &u) == nil {
+				b.add(u)
+			}
+		}
+		b.buf = nil
+		b.done(b.call)
+	})
+}
+
+// streamLine reads usage from one line of a server-sent event stream.
+func (b *llmBody) streamLine(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return
+	}
+	var u llmUsage
+	if json.Unmarshal(bytes.TrimSpace(data), &u) == nil {
+		b.add(u)
+		if u.Message != nil {
+			b.add(*u.Message)
+		}
+	}
+}
+
+// add merges the usage in u into the call. Streams repeat and update counts
+// across events, so later nonzero counts replace earlier ones.
+func (b *llmBody) add(u llmUsage) {
+	if u.Model != "" {
+		b.call.Model = u.Model
+	}
+	if u.Usage == nil {
+		return
+	}
+	if n := u.Usage.PromptTokens + u.Usage.InputTokens; n > 0 {
+		b.call.PromptTokens = n
+	}
+	if n := u.Usage.CompletionTokens + u.Usage.OutputTokens; n > 0 {
+		b.call.CompletionTokens = n
+	}
+}
@@ -0,0 +1,171 @@
+package canonlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setLLMConfig(t *testing.T, cfg LLMConfig) {
+	t.Helper()
+	SetLLMConfig(cfg)
+	t.Cleanup(func() { SetLLMConfig(LLMConfig{}) })
+}
+
+func TestRecordLLMCall(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setLLMConfig(t, LLMConfig{Prices: map[string]LLMPrice{
+		"gpt-4o":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+	}})
+
+	ctx := NewContext(context.Background())
+	RecordLLMCall(ctx, LLMCall{Model: "gpt-4o-2024-08-06", PromptTokens: 1000, CompletionTokens: 200, Latency: 1500 * time.Millisecond})
+	RecordLLMCall(ctx, LLMCall{Model: "gpt-4o-mini", PromptTokens: 2000, CompletionTokens: 1000, Latency: 500 * time.Millisecond})
+	RecordLLMCall(ctx, LLMCall{Latency: 100 * time.Millisecond, Failed: true})
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	want := map[string]any{
+		"llm_calls":             float64(3),
+		"llm_errors":            float64(1),
+		"llm_model":             "gpt-4o-mini",
+		"llm_prompt_tokens":     float64(3000),
+		"llm_completion_tokens": float64(1200),
+		"llm_time_ms":           float64(2100),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+	// 0.0025 + 0.002 for gpt-4o, 0.0003 + 0.0006 for gpt-4o-mini
+	if cost, _ := entry["llm_cost_usd"].(float64); math.Abs(cost-0.0054) > 1e-9 {
+		t.Errorf("Expected llm_cost_usd=0.0054, got %v", entry["llm_cost_usd"])
+	}
+
+	// Without a logger it is a no-op
+	RecordLLMCall(context.Background(), LLMCall{Model: "gpt-4o"})
+}
+
+func TestRecordLLMCallWithoutPrice(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	RecordLLMCall(ctx, LLMCall{Model: "gpt-4o", PromptTokens: 10})
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if _, ok := entry["llm_cost_usd"]; ok {
+		t.Errorf("Expected no llm_cost_usd without prices, got %v", entry["llm_cost_usd"])
+	}
+}
+
+func TestNewLLMTransport(t *testing.T) {
+	const (
+		openAIJSON = `{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[],"usage":{"prompt_tokens":812,"completion_tokens":164,"total_tokens":976}}`
+
+		anthropicStream = "event: message_start\n" +
+			`data: {"type":"message_start","message":{"model":"claude-sonnet-4-5","usage":{"input_tokens":25,"output_tokens":1}}}` + "\n\n" +
+			"event: content_block_delta\n" +
+			`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hello"}}` + "\n\n" +
+			"event: message_delta\n" +
+			`data: {"type":"message_delta","usage":{"output_tokens":15}}` + "\n\n" +
+			"event: message_stop\n" +
+			`data: {"type":"message_stop"}`
+
+		openAIStream = `data: {"model":"gpt-4o-mini","choices":[{"delta":{"content":"Hi"}}],"usage":null}` + "\n\n" +
+			`data: {"model":"gpt-4o-mini","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}` + "\n\n" +
+			"data: [DONE]\n\n"
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        map[string]any
+	}{
+		{"openai json", "application/json", openAIJSON, 200, map[string]any{
+			"llm_calls": float64(1), "llm_model": "gpt-4o-2024-08-06", "llm_prompt_tokens": float64(812), "llm_completion_tokens": float64(164),
+		}},
+		{"anthropic stream", "text/event-stream; charset=utf-8", anthropicStream, 200, map[string]any{
+			"llm_calls": float64(1), "llm_model": "claude-sonnet-4-5", "llm_prompt_tokens": float64(25), "llm_completion_tokens": float64(15),
+		}},
+		{"openai stream", "text/event-stream", openAIStream, 200, map[string]any{
+			"llm_calls": float64(1), "llm_model": "gpt-4o-mini", "llm_prompt_tokens": float64(7), "llm_completion_tokens": float64(3),
+		}},
+		{"rate limited", "application/json", `{"error":{"type":"rate_limit_error"}}`, 429, map[string]any{
+			"llm_calls": float64(1), "llm_errors": float64(1), "llm_prompt_tokens": float64(0),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setTestLogLevel(slog.LevelInfo)()
+			buf := captureOutput(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			client := &http.Client{Transport: NewLLMTransport(nil)}
+
+			ctx := NewContext(context.Background())
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/v1/messages", strings.NewReader(`{}`))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.body {
+				t.Errorf("Expected the body to pass through unchanged, got %q", body)
+			}
+			GetLogger(ctx).Flush(ctx)
+			entry := decodeEntry(t, buf)
+			for k, v := range tt.want {
+				if entry[k] != v {
+					t.Errorf("Expected %s=%v, got %v", k, v, entry[k])
+				}
+			}
+			if _, ok := entry["llm_time_ms"].(float64); !ok {
+				t.Errorf("Expected llm_time_ms, got %v", entry["llm_time_ms"])
+			}
+		})
+	}
+}
+
+func TestNewLLMTransportClosedEarly(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"model":"gpt-4o","usage":{"prompt_tokens":5,"completion_tokens":1}}`)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewLLMTransport(nil)}
+
+	ctx := NewContext(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if entry["llm_calls"] != float64(1) {
+		t.Errorf("Expected the call to be recorded once when the body is closed unread, got %v", entry["llm_calls"])
+	}
+}
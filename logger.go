@@ -9,8 +9,6 @@ package canonlog
 
 import (
 	"log/slog"
-	"os"
-	"strings"
 	"sync"
 	"sync/atomic"
 )
@@ -33,54 +31,26 @@ func getLogLevel() slog.Level {
 
 // SetupGlobalLogger configures the global slog logger with the specified level and format.
 // This function is safe to call from multiple goroutines but only executes once;
-// subsequent calls are no-ops.
+// subsequent calls are no-ops. Use Setup for more options and error reporting.
 //
 // Valid log levels: "debug", "info", "warn", "warning", "error".
 // Invalid or empty level values default to "info".
 //
-// Valid formats: "json", "text".
-// Invalid or empty format values default to "text".
+// Valid formats: "json", "text", "gcp", or the name of an encoder registered with
+// RegisterEncoder. Invalid or empty format values default to "text".
 //
 // Example:
 //
 //	canonlog.SetupGlobalLogger("debug", "json")
 func SetupGlobalLogger(levelStr, logFormat string) {
 	setupOnce.Do(func() {
-		// Parse log level
-		var level slog.Level
-		switch strings.ToLower(levelStr) {
-		case "debug":
-			level = slog.LevelDebug
-		case "info":
-			level = slog.LevelInfo
-		case "warn", "warning":
-			level = slog.LevelWarn
-		case "error":
-			level = slog.LevelError
-		default:
-			level = slog.LevelInfo // Default to info if unknown
+		cfg := Config{Level: levelStr, Format: logFormat}
+		if _, err := parseLevel(levelStr); err != nil {
+			cfg.Level = "" // Default to info if unknown
 		}
-
-		// Create handler based on format
-		var handler slog.Handler
-		opts := &slog.HandlerOptions{
-			Level: level,
-		}
-
-		switch strings.ToLower(logFormat) {
-		case "json":
-			handler = slog.NewJSONHandler(os.Stdout, opts)
-		case "text":
-			handler = slog.NewTextHandler(os.Stdout, opts)
-		default:
-			handler = slog.NewTextHandler(os.Stdout, opts) // Default to text
+		if _, err := newHandler(cfg, slog.LevelInfo); err != nil {
+			cfg.Format = "" // Default to text
 		}
-
-		// Store the level for accumulation filtering (atomic)
-		logLevel.Store(int32(level))
-
-		// Set the global logger
-		logger := slog.New(handler)
-		slog.SetDefault(logger)
+		_ = Setup(cfg)
 	})
 }
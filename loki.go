@@ -0,0 +1,286 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LokiConfig configures NewLokiHandler.
+type LokiConfig struct {
+	// URL is Loki's push endpoint, for example
+	// "http://loki:3100/loki/api/v1/push".
+	URL string
+
+	// Labels are static stream labels, for example {"service": "checkout"}.
+	Labels map[string]string
+
+	// LabelFields lists fields whose values become stream labels, for
+	// example "route". "level" labels streams with the line's level. Keep
+	// this to low-cardinality fields; each distinct combination is a stream.
+	LabelFields []string
+
+	// Header is added to every push request, for example X-Scope-OrgID for
+	// multi-tenant Loki or an Authorization header.
+	Header http.Header
+
+	// Level is the minimum level sent. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// BatchSize is the number of lines that triggers a push. Defaults to 500.
+	BatchSize int
+
+	// BatchWait is the longest a line waits before being pushed. Defaults to
+	// one second.
+	BatchWait time.Duration
+
+	// QueueSize is the number of lines buffered while pushes are in
+	// progress. Lines logged while the queue is full are dropped and counted
+	// by Dropped, so a slow Loki never blocks request handling. Defaults
+	// to 10000.
+	QueueSize int
+
+	// MaxRetries is the number of times a failed push is retried, with
+	// exponential backoff from 100ms, before its lines are dropped. Retries
+	// happen on network errors, 429, and 5xx responses. Defaults to 5.
+	MaxRetries int
+
+	// Client sends push requests. Defaults to a client with a 10 second timeout.
+	Client *http.Client
+
+	// OnDrop, if set, is called when lines are dropped, with how many and why.
+	// Drops from a full queue are summed and reported from the sending
+	// goroutine after each batch; drops after Close are reported on the
+	// logging goroutine, so OnDrop must not log through this handler.
+	OnDrop func(DroppedEntries)
+
+	// DeadLetter, if set, receives a JSON line for each drop reported to
+	// OnDrop, with sink, reason, count, and error, for example os.Stderr.
+	DeadLetter io.Writer
+}
+
+// LokiHandler is a slog.Handler that batches lines and pushes them to
+// Grafana Loki's HTTP API.
+type LokiHandler struct {
+	inner slog.Handler // JSON handler writing into state.buf
+	attrs []slog.Attr  // attributes added with WithAttrs, checked for labels
+	state *lokiState
+}
+
+// lokiState is shared by a LokiHandler and the handlers derived from it.
+type lokiState struct {
+	cfg   LokiConfig
+	mu    sync.Mutex // serializes use of buf
+	buf   bytes.Buffer
+	lines *batcher[lokiLine]
+}
+
+// lokiLine is one line waiting to be pushed.
+type lokiLine struct {
+	labels string // canonical label set, used to group lines into streams
+	stream map[string]string
+	ts     time.Time
+	line   string
+}
+
+// NewLokiHandler returns a handler that pushes lines to Loki from a
+// background goroutine, for environments without a log shipping agent. Lines
+// are encoded as JSON. Use it as the output for Setup's handler, or combine it
+// with other outputs. Call Close during shutdown, for example by registering it
+// with AddShutdownHook, to push the lines still buffered.
+//
+// Example:
+//
+//	loki := canonlog.NewLokiHandler(canonlog.LokiConfig{
+//		URL:         "http://loki:3100/loki/api/v1/push",
+//		Labels:      map[string]string{"service": "checkout"},
+//		LabelFields: []string{"level", "route"},
+//	})
+//	slog.SetDefault(slog.New(loki))
+//	canonlog.AddShutdownHook(loki.Close)
+func NewLokiHandler(cfg LokiConfig) *LokiHandler {
+	if cfg.Level == nil {
+		cfg.Level = slog.LevelInfo
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BatchWait <= 0 {
+		cfg.BatchWait = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &lokiState{cfg: cfg}
+	s.lines = newBatcher(cfg.BatchSize, cfg.QueueSize, cfg.BatchWait, s.push, nil,
+		dropReporter("loki", cfg.OnDrop, cfg.DeadLetter))
+	h := &LokiHandler{state: s}
+	h.inner = slog.NewJSONHandler(&s.buf, &slog.HandlerOptions{Level: cfg.Level})
+	return h
+}
+
+// Enabled reports whether level meets the configured minimum.
+func (h *LokiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.state.cfg.Level.Level()
+}
+
+// Handle encodes r and queues it for the next push. It never blocks; if the
+// queue is full, the line is dropped.
+func (h *LokiHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	s.mu.Lock()
+	s.buf.Reset()
+	err := h.inner.Handle(ctx, r)
+	line := strings.TrimSuffix(s.buf.String(), "\n")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	stream := h.labels(r)
+	s.lines.add(lokiLine{labels: labelKey(stream), stream: stream, ts: r.Time, line: line})
+	return nil
+}
+
+// labels returns the stream labels for r.
+func (h *LokiHandler) labels(r slog.Record) map[string]string {
+	cfg := h.state.cfg
+	labels := make(map[string]string, len(cfg.Labels)+len(cfg.LabelFields))
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	if len(cfg.LabelFields) == 0 {
+		return labels
+	}
+	if slices.Contains(cfg.LabelFields, slog.LevelKey) {
+		labels[slog.LevelKey] = strings.ToLower(r.Level.String())
+	}
+	match := func(a slog.Attr) bool {
+		if slices.Contains(cfg.LabelFields, a.Key) && a.Key != slog.LevelKey {
+			labels[a.Key] = a.Value.Resolve().String()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		match(a)
+	}
+	r.Attrs(match)
+	return labels
+}
+
+// WithAttrs returns a handler that adds attrs to every line.
+func (h *LokiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LokiHandler{inner: h.inner.WithAttrs(attrs), attrs: slices.Concat(h.attrs, attrs), state: h.state}
+}
+
+// WithGroup returns a handler that nests later attributes under name.
+func (h *LokiHandler) WithGroup(name string) slog.Handler {
+	return &LokiHandler{inner: h.inner.WithGroup(name), attrs: h.attrs, state: h.state}
+}
+
+// Dropped returns the number of lines dropped because the queue was full, the
+// handler was closed, or a push failed after all retries.
+func (h *LokiHandler) Dropped() uint64 {
+	return h.state.lines.dropped.Load()
+}
+
+// Close stops accepting lines and pushes those still queued, waiting until
+// they are sent or ctx is done.
+func (h *LokiHandler) Close(ctx context.Context) error {
+	return h.state.lines.close(ctx)
+}
+
+// lokiStream is one stream of a push request.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push sends batch, retrying transient failures. Lines of a batch that cannot
+// be delivered are counted as dropped.
+func (s *lokiState) push(batch []lokiLine) {
+	streams := make(map[string]*lokiStream)
+	var order []string
+	for _, l := range batch {
+		st, ok := streams[l.labels]
+		if !ok {
+			st = &lokiStream{Stream: l.stream}
+			streams[l.labels] = st
+			order = append(order, l.labels)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(l.ts.UnixNano(), 10), l.line})
+	}
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, k := range order {
+		body.Streams = append(body.Streams, streams[k])
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		s.lines.drop(len(batch), DropSendFailed, err)
+		return
+	}
+
+	if err := retry(s.cfg.MaxRetries, func() error { return s.send(payload) }); err != nil {
+		s.lines.drop(len(batch), DropSendFailed, err)
+	}
+}
+
+// send makes one push request.
+func (s *lokiState) send(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range s.cfg.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("canonlog: loki push failed with status %d", resp.StatusCode)
+	}
+	return &permanentError{fmt.Errorf("canonlog: loki push rejected with status %d", resp.StatusCode)}
+}
+
+// labelKey returns a canonical string for a label set.
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// lokiServer records push requests, answering with the given statuses in turn
+// and 204 after they run out.
+func lokiServer(t *testing.T, statuses ...int) (*httptest.Server, func() []lokiPush) {
+	t.Helper()
+	var mu sync.Mutex
+	var pushes []lokiPush
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(statuses) > 0 {
+			status := statuses[0]
+			statuses = statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("X-Scope-OrgID") != "tenant" {
+			t.Errorf("X-Scope-OrgID = %q", r.Header.Get("X-Scope-OrgID"))
+		}
+		var p lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode push: %v", err)
+		}
+		pushes = append(pushes, p)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []lokiPush {
+		mu.Lock()
+		defer mu.Unlock()
+		return pushes
+	}
+}
+
+func TestLokiHandler(t *testing.T) {
+	srv, pushes := lokiServer(t)
+	h := NewLokiHandler(LokiConfig{
+		URL:         srv.URL,
+		Labels:      map[string]string{"service": "checkout"},
+		LabelFields: []string{"level", "route"},
+		Header:      http.Header{"X-Scope-OrgID": {"tenant"}},
+		BatchWait:   time.Hour,
+	})
+	logger := slog.New(h)
+	logger.Info("", "route", "/orders", "status", 200)
+	logger.Info("", "route", "/orders", "status", 201)
+	logger.Error("", "route", "/users", "status", 500)
+	logger.Debug("", "route", "/users")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got := pushes()
+	if len(got) != 1 {
+		t.Fatalf("got %d pushes, want 1", len(got))
+	}
+	streams := got[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams, want 2: %+v", len(streams), streams)
+	}
+	want := map[string]string{"service": "checkout", "level": "info", "route": "/orders"}
+	for k, v := range want {
+		if streams[0].Stream[k] != v {
+			t.Errorf("stream[0] label %s = %q, want %q", k, streams[0].Stream[k], v)
+		}
+	}
+	if len(streams[0].Values) != 2 {
+		t.Fatalf("stream[0] has %d values, want 2", len(streams[0].Values))
+	}
+	var line map[string]any
+	if err := json.Unmarshal([]byte(streams[0].Values[1][1]), &line); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if line["status"] != float64(201) {
+		t.Errorf("status = %v, want 201", line["status"])
+	}
+	if streams[1].Stream["level"] != "error" || streams[1].Stream["route"] != "/users" {
+		t.Errorf("stream[1] labels = %v", streams[1].Stream)
+	}
+}
+
+func TestLokiHandlerWithAttrsLabels(t *testing.T) {
+	srv, pushes := lokiServer(t)
+	h := NewLokiHandler(LokiConfig{
+		URL:         srv.URL,
+		LabelFields: []string{"route"},
+		Header:      http.Header{"X-Scope-OrgID": {"tenant"}},
+		BatchWait:   time.Hour,
+	})
+	slog.New(h).With("route", "/health").Info("")
+	h.Close(context.Background())
+
+	got := pushes()
+	if len(got) != 1 || got[0].Streams[0].Stream["route"] != "/health" {
+		t.Errorf("pushes = %+v, want route label from With", got)
+	}
+}
+
+func TestLokiHandlerBatchSize(t *testing.T) {
+	srv, pushes := lokiServer(t)
+	h := NewLokiHandler(LokiConfig{
+		URL:       srv.URL,
+		Header:    http.Header{"X-Scope-OrgID": {"tenant"}},
+		BatchSize: 2,
+		BatchWait: time.Hour,
+	})
+	logger := slog.New(h)
+	for range 5 {
+		logger.Info("")
+	}
+	h.Close(context.Background())
+
+	if got := len(pushes()); got != 3 {
+		t.Errorf("got %d pushes, want 3", got)
+	}
+}
+
+func TestLokiHandlerRetries(t *testing.T) {
+	srv, pushes := lokiServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	h := NewLokiHandler(LokiConfig{
+		URL:       srv.URL,
+		Header:    http.Header{"X-Scope-OrgID": {"tenant"}},
+		BatchWait: time.Hour,
+	})
+	slog.New(h).Info("")
+	h.Close(context.Background())
+
+	if got := len(pushes()); got != 1 {
+		t.Errorf("got %d pushes, want 1 after retries", got)
+	}
+	if h.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", h.Dropped())
+	}
+}
+
+func TestLokiHandlerDropsRejected(t *testing.T) {
+	srv, _ := lokiServer(t, http.StatusBadRequest)
+	h := NewLokiHandler(LokiConfig{URL: srv.URL, BatchWait: time.Hour})
+	slog.New(h).Info("")
+	h.Close(context.Background())
+
+	if h.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", h.Dropped())
+	}
+}
+
+func TestLokiHandlerOnDrop(t *testing.T) {
+	srv, _ := lokiServer(t, http.StatusBadRequest)
+	var got DroppedEntries
+	h := NewLokiHandler(LokiConfig{
+		URL:       srv.URL,
+		BatchWait: time.Hour,
+		OnDrop:    func(d DroppedEntries) { got = d },
+	})
+	slog.New(h).Info("")
+	slog.New(h).Info("")
+	h.Close(context.Background())
+
+	if got.Sink != "loki" || got.Reason != DropSendFailed || got.Count != 2 || got.Err == nil {
+		t.Errorf("OnDrop got %+v", got)
+	}
+}
+
+func TestLokiHandlerBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	h := NewLokiHandler(LokiConfig{URL: srv.URL, BatchSize: 1, QueueSize: 1, BatchWait: time.Hour})
+	logger := slog.New(h)
+	logger.Info("")
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// The first line is in flight; one fits in the queue, the rest are dropped
+	for range 3 {
+		logger.Info("")
+	}
+	if h.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", h.Dropped())
+	}
+	close(release)
+	h.Close(context.Background())
+
+	logger.Info("")
+	if h.Dropped() != 3 {
+		t.Errorf("Dropped() after Close = %d, want 3", h.Dropped())
+	}
+}
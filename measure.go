@@ -0,0 +1,82 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// maxAppendValues bounds the number of values Append collects under one key.
+const maxAppendValues = 100
+
+// Max records value under key at info level if it is greater than the value
+// already recorded, so repeated measurements keep the largest, such as the
+// slowest upstream call. Values are compared numerically, with time.Duration
+// values in milliseconds. Non-numeric values are ignored, and a non-numeric
+// existing value is replaced.
+func (l *Logger) Max(key string, value any) *Logger {
+	return l.keepIf(key, value, func(v, old float64) bool { return v > old })
+}
+
+// Min records value under key at info level if it is less than the value
+// already recorded. See Max.
+func (l *Logger) Min(key string, value any) *Logger {
+	return l.keepIf(key, value, func(v, old float64) bool { return v < old })
+}
+
+// keepIf stores value under key unless an existing numeric value should be kept.
+func (l *Logger) keepIf(key string, value any, better func(v, old float64) bool) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	v, ok := toFloat(value)
+	if !ok {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if existing, exists := l.fields[key]; exists {
+		if old, ok := toFloat(existing); ok && !better(v, old) {
+			return l
+		}
+	}
+	l.storeField(key, value)
+	return l
+}
+
+// Append adds value to the list recorded under key at info level, so repeated
+// observations accumulate, such as every host attempted. The field is emitted
+// as an array. At most 100 values are kept; further values are dropped and
+// the entry is marked with canonlog_truncated=true.
+func (l *Logger) Append(key string, value any) *Logger {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return l
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list, _ := l.fields[key].([]any)
+	if len(list) >= maxAppendValues {
+		l.truncated = true
+		return l
+	}
+	// Always copy, since emitted entries may still hold the previous list
+	l.storeField(key, append(list[:len(list):len(list)], value))
+	return l
+}
+
+// Max records value under key in the logger in context if it is the largest seen.
+// Panics if no logger exists in context.
+func Max(ctx context.Context, key string, value any) {
+	GetLogger(ctx).Max(key, value)
+}
+
+// Min records value under key in the logger in context if it is the smallest seen.
+// Panics if no logger exists in context.
+func Min(ctx context.Context, key string, value any) {
+	GetLogger(ctx).Min(key, value)
+}
+
+// Append adds value to the list under key in the logger in context.
+// Panics if no logger exists in context.
+func Append(ctx context.Context, key string, value any) {
+	GetLogger(ctx).Append(key, value)
+}
@@ -0,0 +1,77 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestMaxMin(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	for _, v := range []int{30, 120, 45} {
+		Max(ctx, "upstream_latency_ms", v)
+		Min(ctx, "fastest_ms", v)
+	}
+	Max(ctx, "upstream_latency_ms", "not a number")
+
+	f := GetLogger(ctx).Fields()
+	if f["upstream_latency_ms"] != 120 {
+		t.Errorf("Expected max 120, got %v", f["upstream_latency_ms"])
+	}
+	if f["fastest_ms"] != 30 {
+		t.Errorf("Expected min 30, got %v", f["fastest_ms"])
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	l.Max("slowest", 20*time.Millisecond).Max("slowest", 5*time.Millisecond).Max("slowest", time.Second)
+	if got := l.Fields()["slowest"]; got != time.Second {
+		t.Errorf("Expected 1s, got %v", got)
+	}
+}
+
+func TestMaxGated(t *testing.T) {
+	l := New(WithLevel(slog.LevelWarn))
+	l.Max("k", 1).Min("k", 1).Append("k", 1)
+	if l.Len() != 0 {
+		t.Errorf("Expected gated fields to be ignored, got %v", l.Fields())
+	}
+}
+
+func TestAppend(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	Append(ctx, "attempted_hosts", "a.internal")
+	Checkpoint(ctx, "first")
+	Append(ctx, "attempted_hosts", "b.internal")
+	buf.Reset()
+	Flush(ctx)
+
+	hosts, _ := decodeEntry(t, buf)["attempted_hosts"].([]any)
+	if len(hosts) != 2 || hosts[0] != "a.internal" || hosts[1] != "b.internal" {
+		t.Errorf("Expected both hosts, got %v", hosts)
+	}
+}
+
+func TestAppendLimit(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	for i := range maxAppendValues + 5 {
+		l.Append("ids", i)
+	}
+	if got := l.Fields()["ids"].([]any); len(got) != maxAppendValues {
+		t.Errorf("Expected %d values, got %d", maxAppendValues, len(got))
+	}
+	if !l.truncated {
+		t.Error("Expected entry to be marked truncated")
+	}
+}
@@ -0,0 +1,88 @@
+package canonlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewMeteredTransport(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Advance(10 * time.Millisecond)
+		switch r.Method {
+		case http.MethodPut:
+			io.Copy(io.Discard, r.Body)
+		case http.MethodGet:
+			io.WriteString(w, strings.Repeat("o", 500))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewMeteredTransport(nil, "s3")}
+
+	ctx := NewContext(context.Background(), WithClock(clock))
+	do := func(method string, body io.Reader) {
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL+"/bucket/key", body)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	do(http.MethodPut, strings.NewReader(strings.Repeat("i", 200)))
+	do(http.MethodGet, nil)
+	do(http.MethodDelete, nil)
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	want := map[string]any{
+		"s3_requests":       float64(3),
+		"s3_errors":         float64(1),
+		"s3_time_ms":        float64(30),
+		"s3_bytes_sent":     float64(200),
+		"s3_bytes_received": float64(500),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+}
+
+func TestNewMeteredTransportError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+	client := &http.Client{Transport: NewMeteredTransport(nil, "gcs")}
+
+	ctx := NewContext(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Expected a connection error")
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if entry["gcs_requests"] != float64(1) || entry["gcs_errors"] != float64(1) {
+		t.Errorf("Expected gcs_requests=1 gcs_errors=1, got %v", entry)
+	}
+
+	// Requests without a logger pass through
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected a connection error without a logger")
+	}
+}
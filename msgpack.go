@@ -0,0 +1,314 @@
+package canonlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// msgpackWriter appends MessagePack-encoded values to a byte slice. It covers
+// the subset of the format needed to ship log records.
+type msgpackWriter struct {
+	b []byte
+}
+
+func (w *msgpackWriter) nil() { w.b = append(w.b, 0xc0) }
+
+func (w *msgpackWriter) bool(v bool) {
+	if v {
+		w.b = append(w.b, 0xc3)
+	} else {
+		w.b = append(w.b, 0xc2)
+	}
+}
+
+func (w *msgpackWriter) int(v int64) {
+	switch {
+	case v >= 0:
+		w.uint(uint64(v))
+	case v >= -32:
+		w.b = append(w.b, byte(v))
+	case v >= math.MinInt8:
+		w.b = append(w.b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		w.b = binary.BigEndian.AppendUint16(append(w.b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		w.b = binary.BigEndian.AppendUint32(append(w.b, 0xd2), uint32(v))
+	default:
+		w.b = binary.BigEndian.AppendUint64(append(w.b, 0xd3), uint64(v))
+	}
+}
+
+func (w *msgpackWriter) uint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		w.b = append(w.b, byte(v))
+	case v <= math.MaxUint8:
+		w.b = append(w.b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		w.b = binary.BigEndian.AppendUint16(append(w.b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		w.b = binary.BigEndian.AppendUint32(append(w.b, 0xce), uint32(v))
+	default:
+		w.b = binary.BigEndian.AppendUint64(append(w.b, 0xcf), v)
+	}
+}
+
+func (w *msgpackWriter) float(v float64) {
+	w.b = binary.BigEndian.AppendUint64(append(w.b, 0xcb), math.Float64bits(v))
+}
+
+func (w *msgpackWriter) string(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		w.b = append(w.b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.b = append(w.b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.b = binary.BigEndian.AppendUint16(append(w.b, 0xda), uint16(n))
+	default:
+		w.b = binary.BigEndian.AppendUint32(append(w.b, 0xdb), uint32(n))
+	}
+	w.b = append(w.b, s...)
+}
+
+func (w *msgpackWriter) arrayHeader(n int) {
+	switch {
+	case n <= 15:
+		w.b = append(w.b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.b = binary.BigEndian.AppendUint16(append(w.b, 0xdc), uint16(n))
+	default:
+		w.b = binary.BigEndian.AppendUint32(append(w.b, 0xdd), uint32(n))
+	}
+}
+
+func (w *msgpackWriter) mapHeader(n int) {
+	switch {
+	case n <= 15:
+		w.b = append(w.b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.b = binary.BigEndian.AppendUint16(append(w.b, 0xde), uint16(n))
+	default:
+		w.b = binary.BigEndian.AppendUint32(append(w.b, 0xdf), uint32(n))
+	}
+}
+
+// eventTime writes t as the Fluentd EventTime extension (type 0), which keeps
+// nanosecond precision.
+func (w *msgpackWriter) eventTime(t time.Time) {
+	w.b = append(w.b, 0xd7, 0x00)
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(t.Unix()))
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(t.Nanosecond()))
+}
+
+// value writes v, falling back to its fmt representation for types
+// MessagePack has no encoding for.
+func (w *msgpackWriter) value(v any) {
+	switch v := v.(type) {
+	case nil:
+		w.nil()
+	case bool:
+		w.bool(v)
+	case int:
+		w.int(int64(v))
+	case int8:
+		w.int(int64(v))
+	case int16:
+		w.int(int64(v))
+	case int32:
+		w.int(int64(v))
+	case int64:
+		w.int(v)
+	case uint:
+		w.uint(uint64(v))
+	case uint8:
+		w.uint(uint64(v))
+	case uint16:
+		w.uint(uint64(v))
+	case uint32:
+		w.uint(uint64(v))
+	case uint64:
+		w.uint(v)
+	case float32:
+		w.float(float64(v))
+	case float64:
+		w.float(v)
+	case string:
+		w.string(v)
+	case time.Duration:
+		w.int(int64(v))
+	case time.Time:
+		w.string(v.Format(time.RFC3339Nano))
+	case error:
+		w.string(v.Error())
+	case []string:
+		w.arrayHeader(len(v))
+		for _, s := range v {
+			w.string(s)
+		}
+	case []any:
+		w.arrayHeader(len(v))
+		for _, e := range v {
+			w.value(e)
+		}
+	case map[string]any:
+		w.mapHeader(len(v))
+		for k, e := range v {
+			w.string(k)
+			w.value(e)
+		}
+	case fmt.Stringer:
+		w.string(v.String())
+	default:
+		w.string(fmt.Sprint(v))
+	}
+}
+
+var errMsgpackFormat = errors.New("canonlog: invalid msgpack")
+
+// msgpackRead decodes one value from r. Maps decode to map[string]any, arrays
+// to []any, integers to int64 (uint64 above math.MaxInt64), and extensions to
+// their raw payload.
+func msgpackRead(r *bufio.Reader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return msgpackReadMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return msgpackReadArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return msgpackReadString(r, int(c&0x1f))
+	}
+	n := func(size int) (uint64, error) {
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[8-size:]); err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(buf[:]), nil
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := n(1 << (c - 0xcc))
+		if v > math.MaxInt64 {
+			return v, err
+		}
+		return int64(v), err
+	case 0xd0:
+		v, err := n(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := n(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := n(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := n(8)
+		return int64(v), err
+	case 0xca:
+		v, err := n(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := n(8)
+		return math.Float64frombits(v), err
+	case 0xd9, 0xda, 0xdb:
+		size, err := n(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadString(r, int(size))
+	case 0xc4, 0xc5, 0xc6:
+		size, err := n(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadBytes(r, int(size))
+	case 0xdc, 0xdd:
+		size, err := n(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadArray(r, int(size))
+	case 0xde, 0xdf:
+		size, err := n(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadMap(r, int(size))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		if _, err := r.ReadByte(); err != nil {
+			return nil, err
+		}
+		return msgpackReadBytes(r, 1<<(c-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		size, err := n(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.ReadByte(); err != nil {
+			return nil, err
+		}
+		return msgpackReadBytes(r, int(size))
+	}
+	return nil, errMsgpackFormat
+}
+
+func msgpackReadBytes(r *bufio.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func msgpackReadString(r *bufio.Reader, n int) (string, error) {
+	b, err := msgpackReadBytes(r, n)
+	return string(b), err
+}
+
+func msgpackReadArray(r *bufio.Reader, n int) ([]any, error) {
+	a := make([]any, 0, min(n, 1024))
+	for range n {
+		v, err := msgpackRead(r)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func msgpackReadMap(r *bufio.Reader, n int) (map[string]any, error) {
+	m := make(map[string]any, min(n, 1024))
+	for range n {
+		k, err := msgpackRead(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errMsgpackFormat
+		}
+		if m[key], err = msgpackRead(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
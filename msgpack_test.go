@@ -0,0 +1,75 @@
+package canonlog
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{nil, nil},
+		{true, true},
+		{false, false},
+		{0, int64(0)},
+		{127, int64(127)},
+		{255, int64(255)},
+		{65535, int64(65535)},
+		{1 << 20, int64(1 << 20)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{-1, int64(-1)},
+		{-32, int64(-32)},
+		{-100, int64(-100)},
+		{-1000, int64(-1000)},
+		{-100000, int64(-100000)},
+		{int64(math.MinInt64), int64(math.MinInt64)},
+		{1.5, 1.5},
+		{"", ""},
+		{"hello", "hello"},
+		{strings.Repeat("a", 40), strings.Repeat("a", 40)},
+		{strings.Repeat("b", 300), strings.Repeat("b", 300)},
+		{strings.Repeat("c", 70000), strings.Repeat("c", 70000)},
+		{1500 * time.Millisecond, int64(1500 * time.Millisecond)},
+		{[]string{"a", "b"}, []any{"a", "b"}},
+		{[]any{1, "x", nil}, []any{int64(1), "x", nil}},
+		{map[string]any{"k": "v", "n": -5}, map[string]any{"k": "v", "n": int64(-5)}},
+		{make([]any, 20), make([]any, 20)},
+	}
+	for _, tt := range tests {
+		var w msgpackWriter
+		w.value(tt.in)
+		got, err := msgpackRead(bufio.NewReader(bytes.NewReader(w.b)))
+		if err != nil {
+			t.Errorf("%v: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("round trip %v = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMsgpackEventTime(t *testing.T) {
+	var w msgpackWriter
+	w.eventTime(time.Unix(1700000000, 123456789))
+	want := []byte{0xd7, 0x00, 0x65, 0x53, 0xf1, 0x00, 0x07, 0x5b, 0xcd, 0x15}
+	if !bytes.Equal(w.b, want) {
+		t.Errorf("eventTime = % x, want % x", w.b, want)
+	}
+}
+
+func TestMsgpackReadInvalid(t *testing.T) {
+	if _, err := msgpackRead(bufio.NewReader(bytes.NewReader([]byte{0xc1}))); err == nil {
+		t.Error("expected error for reserved byte 0xc1")
+	}
+	if _, err := msgpackRead(bufio.NewReader(bytes.NewReader([]byte{0xa5, 'a'}))); err == nil {
+		t.Error("expected error for truncated string")
+	}
+}
@@ -0,0 +1,51 @@
+package canonlog
+
+import (
+	"context"
+	"strings"
+)
+
+// notificationsKey is the field Notify appends to.
+const notificationsKey = "notifications"
+
+// Notify records an outbound notification, such as an email, SMS, or push
+// message, on the logger in ctx by appending it to the notifications field
+// as an object with channel, recipient, and result: "sent" if err is nil, or
+// "failed" with the error message as error. The recipient is masked, keeping
+// the first character and domain of an email address or the last four
+// characters of anything else, so support can tell recipients apart without
+// the line carrying contact details. Call it after each provider send; it
+// does nothing if ctx has no logger.
+//
+// Example:
+//
+//	_, err := sendgrid.Send(msg)
+//	canonlog.Notify(ctx, "email", user.Email, err)
+//	// notifications=[{"channel":"email","recipient":"j***@example.com","result":"sent"}]
+func Notify(ctx context.Context, channel, recipient string, err error) {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return
+	}
+	n := map[string]any{
+		"channel":   channel,
+		"recipient": maskRecipient(recipient),
+		"result":    "sent",
+	}
+	if err != nil {
+		n["result"] = "failed"
+		n["error"] = err.Error()
+	}
+	l.Append(notificationsKey, n)
+}
+
+// maskRecipient hides most of an email address, phone number, or device token.
+func maskRecipient(r string) string {
+	if at := strings.LastIndexByte(r, '@'); at > 0 {
+		return r[:1] + "***" + r[at:]
+	}
+	if len(r) <= 4 {
+		return "***"
+	}
+	return "***" + r[len(r)-4:]
+}
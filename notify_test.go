@@ -0,0 +1,46 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestNotify(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	Notify(ctx, "email", "jane.doe@example.com", nil)
+	Notify(ctx, "sms", "+15551234567", errors.New("invalid number"))
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	want := []any{
+		map[string]any{"channel": "email", "recipient": "j***@example.com", "result": "sent"},
+		map[string]any{"channel": "sms", "recipient": "***4567", "result": "failed", "error": "invalid number"},
+	}
+	if !reflect.DeepEqual(entry["notifications"], want) {
+		t.Errorf("Expected %v, got %v", want, entry["notifications"])
+	}
+
+	// Without a logger it is a no-op
+	Notify(context.Background(), "email", "jane.doe@example.com", nil)
+}
+
+func TestMaskRecipient(t *testing.T) {
+	tests := map[string]string{
+		"jane@example.com": "j***@example.com",
+		"+15551234567":     "***4567",
+		"abc":              "***",
+		"":                 "***",
+		"@example.com":     "***.com",
+	}
+	for in, want := range tests {
+		if got := maskRecipient(in); got != want {
+			t.Errorf("maskRecipient(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package canonlog
+
+import (
+	"maps"
+	"slices"
+)
+
+// FieldOrder controls the order in which a logger's fields are emitted.
+type FieldOrder int
+
+const (
+	// OrderUnordered emits fields in map iteration order, which varies between
+	// lines. This is the default and the cheapest.
+	OrderUnordered FieldOrder = iota
+
+	// OrderSorted emits fields sorted by key.
+	OrderSorted
+
+	// OrderInsertion emits fields in the order their keys were first recorded.
+	// Persistent fields and fields canonlog derives at emission, such as
+	// histogram summaries and checkpoint labels, follow in sorted order.
+	OrderInsertion
+)
+
+// WithFieldOrder sets the order in which fields are emitted, so lines can be
+// diffed across requests and compared against golden files. Errors, warnings,
+// and other entry metadata always follow the fields. The default is
+// OrderUnordered.
+func WithFieldOrder(order FieldOrder) Option {
+	return func(l *Logger) {
+		l.fieldOrder = order
+	}
+}
+
+// orderedKeys returns the keys of fields in the given order. order lists keys
+// as first recorded, before normalization, and is only used for OrderInsertion.
+func orderedKeys(fields map[string]any, mode FieldOrder, order []string) []string {
+	if mode == OrderSorted {
+		return slices.Sorted(maps.Keys(fields))
+	}
+
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	var normalize func(string) string
+	if fn := keyNormalizer.Load(); fn != nil {
+		normalize = *fn
+	}
+	for _, k := range order {
+		if normalize != nil {
+			k = normalize(k)
+		}
+		if _, ok := fields[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	n := len(keys)
+	for k := range fields {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys[n:])
+	return keys
+}
@@ -0,0 +1,67 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// assertKeyOrder checks that keys appear in line in the given order.
+func assertKeyOrder(t *testing.T, line string, keys ...string) {
+	t.Helper()
+	last := -1
+	for _, k := range keys {
+		i := strings.Index(line, `"`+k+`":`)
+		if i < 0 {
+			t.Fatalf("Expected key %q in %s", k, line)
+		}
+		if i < last {
+			t.Errorf("Expected %v in order, got %s", keys, line)
+			return
+		}
+		last = i
+	}
+}
+
+func TestWithFieldOrderSorted(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithFieldOrder(OrderSorted))
+	l.InfoAdd("zeta", 1).InfoAdd("alpha", 2).InfoAdd("mid", 3)
+	l.Flush(context.Background())
+
+	assertKeyOrder(t, buf.String(), "alpha", "mid", "zeta")
+}
+
+func TestWithFieldOrderInsertion(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithFieldOrder(OrderInsertion))
+	l.Persist("request_id", "abc")
+	l.InfoAdd("zeta", 1).InfoAdd("alpha", 2).InfoAdd("mid", 3).InfoAdd("zeta", 4)
+	l.Flush(context.Background())
+
+	assertKeyOrder(t, buf.String(), "zeta", "alpha", "mid", "request_id")
+
+	// The order starts over after Flush
+	buf.Reset()
+	l.InfoAdd("mid", 1).InfoAdd("alpha", 2)
+	l.Checkpoint(context.Background(), "step")
+	assertKeyOrder(t, buf.String(), "mid", "alpha", "checkpoint", "request_id", "seq")
+}
+
+func TestWithFieldOrderInsertionNormalized(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	SetKeyNormalizer(SnakeCase)
+	t.Cleanup(func() { SetKeyNormalizer(nil) })
+
+	l := New(WithFieldOrder(OrderInsertion))
+	l.InfoAdd("userID", 1).InfoAdd("accountID", 2)
+	l.Flush(context.Background())
+
+	assertKeyOrder(t, buf.String(), "user_id", "account_id")
+}
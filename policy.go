@@ -0,0 +1,77 @@
+package canonlog
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Override adjusts logging for a specific tenant or user.
+type Override struct {
+	// Level replaces the logger's gate level, for example slog.LevelDebug to
+	// capture everything for an allowlisted customer during an incident, or
+	// slog.LevelWarn to keep a noisy tenant's Info fields out. Only the gate
+	// changes: entries are still emitted at the level their contents call
+	// for. Nil leaves the gate unchanged.
+	Level slog.Leveler
+
+	// SampleRate is the fraction of the logger's entries emitted, from 0 to
+	// 1, for example 0.01 for a high-volume tenant. Entries at Error level
+	// and above are always emitted. Zero leaves the logger's sampling
+	// unchanged.
+	SampleRate float64
+
+	// Unsampled exempts the logger's entries from the Limiter, the
+	// Aggregator, and sampling, including SampleRate, so every one is
+	// emitted.
+	Unsampled bool
+}
+
+// IdentityPolicy returns the override for an identity field set with SetTenant
+// or SetUser, or false for none. key is "tenant_id" or "user_id".
+type IdentityPolicy func(key, value string) (Override, bool)
+
+// identityPolicy holds the policy set by SetIdentityPolicy.
+var identityPolicy atomic.Pointer[IdentityPolicy]
+
+// SetIdentityPolicy installs a policy consulted whenever SetTenant or SetUser
+// records an identity, so logging can be tuned for individual tenants or users
+// without redeploying. The override applies to fields added after the identity
+// is set and lasts for the logger's lifetime. Passing nil removes the policy.
+//
+// Example:
+//
+//	debugTenants := map[string]bool{"acme": true}
+//	canonlog.SetIdentityPolicy(func(key, value string) (canonlog.Override, bool) {
+//		if key == "tenant_id" && debugTenants[value] {
+//			return canonlog.Override{Level: slog.LevelDebug, Unsampled: true}, true
+//		}
+//		return canonlog.Override{}, false
+//	})
+func SetIdentityPolicy(p IdentityPolicy) {
+	if p == nil {
+		identityPolicy.Store(nil)
+		return
+	}
+	identityPolicy.Store(&p)
+}
+
+// applyIdentityPolicy applies the override for key and value, if any, to l.
+func (l *Logger) applyIdentityPolicy(key, value string) {
+	p := identityPolicy.Load()
+	if p == nil {
+		return
+	}
+	o, ok := (*p)(key, value)
+	if !ok {
+		return
+	}
+	if o.Level != nil {
+		l.gateLevel.Store(o.Level.Level())
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if o.SampleRate > 0 {
+		l.sampleRate = o.SampleRate
+	}
+	l.unsampled = l.unsampled || o.Unsampled
+}
@@ -0,0 +1,172 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIdentityPolicy(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetIdentityPolicy(nil) })
+
+	SetIdentityPolicy(func(key, value string) (Override, bool) {
+		if key == "tenant_id" && value == "acme" {
+			return Override{Level: slog.LevelDebug}, true
+		}
+		return Override{}, false
+	})
+
+	ctx := NewContext(context.Background())
+	SetTenant(ctx, "acme")
+	DebugAdd(ctx, "sql", "SELECT 1")
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	if entry["sql"] != "SELECT 1" {
+		t.Errorf("Expected debug field for overridden tenant, got %v", entry)
+	}
+	if entry["level"] != "INFO" {
+		t.Errorf("Expected output level to stay INFO, got %v", entry["level"])
+	}
+
+	other := NewContext(context.Background())
+	SetTenant(other, "globex")
+	DebugAdd(other, "sql", "SELECT 1")
+	if GetLogger(other).HasField("sql") {
+		t.Error("Expected no override for other tenants")
+	}
+}
+
+func TestIdentityPolicyUnsampled(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetIdentityPolicy(nil) })
+	SetLimiter(NewLimiter(time.Hour, "route"))
+	t.Cleanup(func() { SetLimiter(nil) })
+
+	SetIdentityPolicy(func(key, value string) (Override, bool) {
+		if key == "user_id" && value == "vip" {
+			return Override{Level: slog.LevelInfo, Unsampled: true}, true
+		}
+		return Override{}, false
+	})
+
+	for _, user := range []string{"vip", "vip", "vip", "other", "other"} {
+		ctx := NewContext(context.Background())
+		SetUser(ctx, user)
+		InfoAdd(ctx, "route", "/search")
+		Flush(ctx)
+	}
+
+	if n := strings.Count(buf.String(), `"user_id":"vip"`); n != 3 {
+		t.Errorf("Expected all 3 unsampled entries, got %d", n)
+	}
+	if n := strings.Count(buf.String(), `"user_id":"other"`); n != 1 {
+		t.Errorf("Expected limiter to apply to other users, got %d entries", n)
+	}
+}
+
+func TestIdentityPolicyKeepsLevels(t *testing.T) {
+	defer setTestLogLevel(slog.LevelDebug)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetIdentityPolicy(nil) })
+
+	SetIdentityPolicy(func(key, value string) (Override, bool) {
+		switch value {
+		case "vip":
+			return Override{Unsampled: true}, true
+		case "noisy":
+			return Override{Level: slog.LevelWarn}, true
+		}
+		return Override{}, false
+	})
+
+	ctx := NewContext(context.Background())
+	SetTenant(ctx, "vip")
+	DebugAdd(ctx, "after", true)
+	Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if entry["after"] != true || entry["level"] != "DEBUG" {
+		t.Errorf("Expected an override without Level to keep the Debug gate, got %v", entry)
+	}
+
+	buf.Reset()
+	ctx = NewContext(context.Background())
+	SetTenant(ctx, "noisy")
+	InfoAdd(ctx, "x", 1)
+	WarnAdd(ctx, "slow", true)
+	Flush(ctx)
+	entry = decodeEntry(t, buf)
+	if entry["x"] != nil || entry["slow"] != true {
+		t.Errorf("Expected the raised gate to drop Info fields only, got %v", entry)
+	}
+
+	ctx = NewContext(context.Background())
+	SetTenant(ctx, "noisy")
+	if lvl := GetLogger(ctx).Level(); lvl != slog.LevelDebug {
+		t.Errorf("Expected the output level to stay at the logger's own level, got %v", lvl)
+	}
+}
+
+func TestIdentityPolicySampleRate(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { SetIdentityPolicy(nil) })
+
+	SetIdentityPolicy(func(key, value string) (Override, bool) {
+		if value == "bulk" {
+			return Override{SampleRate: 1e-9}, true
+		}
+		return Override{}, false
+	})
+
+	for range 20 {
+		ctx := NewContext(context.Background())
+		SetTenant(ctx, "bulk")
+		InfoAdd(ctx, "route", "/import")
+		Flush(ctx)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected sampled tenant's entries to be dropped, got %s", buf.String())
+	}
+
+	ctx := NewContext(context.Background())
+	SetTenant(ctx, "bulk")
+	ErrorAdd(ctx, errors.New("import failed"))
+	Flush(ctx)
+	if !strings.Contains(buf.String(), "import failed") {
+		t.Error("Expected error entries to bypass sampling")
+	}
+}
+
+func TestIdentityPolicyConcurrentLogging(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	t.Cleanup(func() { SetIdentityPolicy(nil) })
+
+	SetIdentityPolicy(func(key, value string) (Override, bool) {
+		return Override{Level: slog.LevelDebug}, true
+	})
+
+	ctx := NewContext(context.Background())
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				InfoAdd(ctx, fmt.Sprintf("k_%d_%d", i, j), j)
+			}
+		}()
+	}
+	SetTenant(ctx, "acme")
+	wg.Wait()
+	Flush(ctx)
+}
@@ -0,0 +1,60 @@
+package canonlog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Fields recorded by ExtractProtocol.
+const (
+	httpProtoKey       = "http_proto"
+	httpPriorityKey    = "http_priority_urgency"
+	httpIncrementalKey = "http_priority_incremental"
+)
+
+// defaultPriorityUrgency is the RFC 9218 urgency of requests that omit u.
+const defaultPriorityUrgency = 3
+
+// ExtractProtocol records the protocol of r on the logger in ctx as http_proto,
+// for example "HTTP/1.1", "HTTP/2.0", or "HTTP/3.0", so latency can be segmented
+// by protocol. When the client sent an RFC 9218 Priority header, its urgency
+// (0-7, lower is more urgent) and incremental flag are recorded as
+// http_priority_urgency and http_priority_incremental.
+func ExtractProtocol(ctx context.Context, r *http.Request) {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return
+	}
+	l.InfoAdd(httpProtoKey, r.Proto)
+
+	h := r.Header.Get("Priority")
+	if h == "" {
+		return
+	}
+	urgency, incremental := parsePriority(h)
+	l.InfoAddMany(map[string]any{
+		httpPriorityKey:    urgency,
+		httpIncrementalKey: incremental,
+	})
+}
+
+// parsePriority parses the u and i parameters of an RFC 9218 Priority header.
+// Missing or invalid parameters take their defaults of u=3 and i=?0.
+func parsePriority(h string) (urgency int, incremental bool) {
+	urgency = defaultPriorityUrgency
+	for _, part := range strings.Split(h, ",") {
+		k, v, hasValue := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "u":
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 7 {
+				urgency = n
+			}
+		case "i":
+			// A bare "i" is the structured field boolean true
+			incremental = !hasValue || v == "?1"
+		}
+	}
+	return urgency, incremental
+}
@@ -0,0 +1,59 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractProtocol(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+
+	ctx := NewContext(context.Background())
+	ExtractProtocol(ctx, r)
+
+	f := GetLogger(ctx).Fields()
+	if f["http_proto"] != "HTTP/2.0" {
+		t.Errorf("Expected http_proto=HTTP/2.0, got %v", f["http_proto"])
+	}
+	if _, ok := f["http_priority_urgency"]; ok {
+		t.Error("Expected no priority fields without a Priority header")
+	}
+}
+
+func TestExtractProtocolPriority(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	tests := []struct {
+		header      string
+		urgency     int
+		incremental bool
+	}{
+		{"u=1", 1, false},
+		{"u=5, i", 5, true},
+		{"i=?1", 3, true},
+		{"i=?0, u=0", 0, false},
+		{"u=9", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Priority", tt.header)
+
+			ctx := NewContext(context.Background())
+			ExtractProtocol(ctx, r)
+
+			f := GetLogger(ctx).Fields()
+			if f["http_priority_urgency"] != tt.urgency {
+				t.Errorf("Expected urgency %d, got %v", tt.urgency, f["http_priority_urgency"])
+			}
+			if f["http_priority_incremental"] != tt.incremental {
+				t.Errorf("Expected incremental %v, got %v", tt.incremental, f["http_priority_incremental"])
+			}
+		})
+	}
+}
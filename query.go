@@ -0,0 +1,107 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Keys and markers used when recording query parameters.
+const (
+	queryKey     = "query"
+	redactedMark = "[REDACTED]"
+)
+
+// defaultSensitiveParams are substrings that mark a query parameter as sensitive
+// when QueryConfig.Sensitive is not set.
+var defaultSensitiveParams = []string{"token", "key", "password", "passwd", "secret", "signature", "auth"}
+
+// QueryConfig configures ExtractQuery.
+type QueryConfig struct {
+	// Allow lists the parameters to record. If empty, every parameter not in
+	// Deny is recorded.
+	Allow []string
+
+	// Deny lists parameters that are never recorded.
+	Deny []string
+
+	// Sensitive lists case-insensitive substrings that mark a parameter as
+	// sensitive. Sensitive parameters are recorded with their value replaced by
+	// "[REDACTED]", even if allowlisted. Defaults to token, key, password,
+	// passwd, secret, signature, and auth.
+	Sensitive []string
+}
+
+// queryConfig holds the configuration set by SetQueryConfig.
+var queryConfig atomic.Pointer[QueryConfig]
+
+// SetQueryConfig configures which query parameters ExtractQuery records.
+//
+// Example:
+//
+//	canonlog.SetQueryConfig(canonlog.QueryConfig{
+//		Allow: []string{"page", "per_page", "filter", "api_key"},
+//	})
+//	// ?page=2&api_key=abc&q=x records query.page=2 query.api_key=[REDACTED]
+func SetQueryConfig(cfg QueryConfig) {
+	queryConfig.Store(&cfg)
+}
+
+// ExtractQuery records the selected query parameters of q as a "query" group
+// on the logger in ctx, redacting sensitive values. Parameters with multiple
+// values are joined with commas. Nothing is recorded if no parameter is selected.
+//
+//	canonlog.ExtractQuery(ctx, r.URL.Query())
+func ExtractQuery(ctx context.Context, q url.Values) {
+	l, ok := TryGetLogger(ctx)
+	if !ok || len(q) == 0 {
+		return
+	}
+	cfg := QueryConfig{}
+	if c := queryConfig.Load(); c != nil {
+		cfg = *c
+	}
+	sensitive := cfg.Sensitive
+	if sensitive == nil {
+		sensitive = defaultSensitiveParams
+	}
+
+	names := make([]string, 0, len(q))
+	for name := range q {
+		if len(cfg.Allow) > 0 && !slices.Contains(cfg.Allow, name) {
+			continue
+		}
+		if slices.Contains(cfg.Deny, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.Sort(names)
+
+	attrs := make([]slog.Attr, len(names))
+	for i, name := range names {
+		value := strings.Join(q[name], ",")
+		if isSensitive(name, sensitive) {
+			value = redactedMark
+		}
+		attrs[i] = slog.String(name, value)
+	}
+	l.InfoAdd(queryKey, slog.GroupValue(attrs...))
+}
+
+// isSensitive reports whether name contains any of the sensitive substrings.
+func isSensitive(name string, sensitive []string) bool {
+	lower := strings.ToLower(name)
+	for _, s := range sensitive {
+		if strings.Contains(lower, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"testing"
+)
+
+func TestExtractQuery(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	t.Cleanup(func() { queryConfig.Store(nil) })
+
+	q, _ := url.ParseQuery("page=2&tag=a&tag=b&access_token=abc&Password=x&debug=1")
+
+	SetQueryConfig(QueryConfig{Deny: []string{"debug"}})
+	ctx := NewContext(context.Background())
+	ExtractQuery(ctx, q)
+	Flush(ctx)
+
+	query, ok := decodeEntry(t, buf)[queryKey].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected query group, got %v", buf.String())
+	}
+	if query["page"] != "2" || query["tag"] != "a,b" {
+		t.Errorf("Expected page=2 tag=a,b, got %v", query)
+	}
+	if query["access_token"] != redactedMark || query["Password"] != redactedMark {
+		t.Errorf("Expected sensitive params redacted, got %v", query)
+	}
+	if _, exists := query["debug"]; exists {
+		t.Error("Denied param should not be recorded")
+	}
+}
+
+func TestExtractQueryAllowlist(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	t.Cleanup(func() { queryConfig.Store(nil) })
+
+	SetQueryConfig(QueryConfig{Allow: []string{"page", "api_key"}, Sensitive: []string{"api_key"}})
+
+	q, _ := url.ParseQuery("page=2&api_key=abc&q=search")
+	ctx := NewContext(context.Background())
+	ExtractQuery(ctx, q)
+
+	group, ok := GetLogger(ctx).Fields()[queryKey].(slog.Value)
+	if !ok {
+		t.Fatal("Expected query group value")
+	}
+	attrs := group.Group()
+	if len(attrs) != 2 {
+		t.Fatalf("Expected 2 allowlisted params, got %v", attrs)
+	}
+	if attrs[0].String() != "api_key="+redactedMark || attrs[1].String() != "page=2" {
+		t.Errorf("Unexpected params %v", attrs)
+	}
+}
+
+func TestExtractQueryNothingSelected(t *testing.T) {
+	t.Cleanup(func() { queryConfig.Store(nil) })
+	SetQueryConfig(QueryConfig{Allow: []string{"page"}})
+
+	q, _ := url.ParseQuery("q=search")
+	ctx := NewContext(context.Background())
+	ExtractQuery(ctx, q)
+
+	if GetLogger(ctx).HasField(queryKey) {
+		t.Error("Expected no query field when nothing is selected")
+	}
+}
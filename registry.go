@@ -0,0 +1,40 @@
+package canonlog
+
+import "sync"
+
+// registry maps IDs to loggers for code that cannot receive a context.
+var registry sync.Map
+
+// Register makes l retrievable by id with Lookup, so deeply nested code that
+// has no context, such as legacy libraries, can still enrich the canonical
+// line given only an explicitly passed ID like the request ID. It returns a
+// function that removes the registration; call it before the unit of work
+// ends, and before Release if the logger is pooled.
+//
+// Register is experimental. Prefer passing a context wherever possible.
+//
+// Example:
+//
+//	id := canonlog.ExtractRequestID(ctx, r.Header)
+//	defer canonlog.Register(id, canonlog.GetLogger(ctx))()
+//	legacy.Process(id, payload)
+//
+//	// deep inside legacy code:
+//	if l, ok := canonlog.Lookup(id); ok {
+//		l.InfoAdd("legacy_path", "v1")
+//	}
+func Register(id string, l *Logger) (unregister func()) {
+	registry.Store(id, l)
+	return func() {
+		registry.CompareAndDelete(id, l)
+	}
+}
+
+// Lookup returns the logger registered under id, if any.
+func Lookup(id string) (*Logger, bool) {
+	v, ok := registry.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return v.(*Logger), true
+}
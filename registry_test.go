@@ -0,0 +1,30 @@
+package canonlog
+
+import "testing"
+
+func TestRegisterLookup(t *testing.T) {
+	l := New()
+	unregister := Register("req-1", l)
+
+	got, ok := Lookup("req-1")
+	if !ok || got != l {
+		t.Fatalf("Expected registered logger, got %v, %v", got, ok)
+	}
+
+	unregister()
+	if _, ok := Lookup("req-1"); ok {
+		t.Error("Expected logger to be unregistered")
+	}
+}
+
+func TestUnregisterKeepsNewerRegistration(t *testing.T) {
+	first, second := New(), New()
+	unregisterFirst := Register("req-1", first)
+	unregisterSecond := Register("req-1", second)
+	defer unregisterSecond()
+
+	unregisterFirst()
+	if got, ok := Lookup("req-1"); !ok || got != second {
+		t.Error("Expected stale unregister to leave the newer registration")
+	}
+}
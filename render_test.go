@@ -0,0 +1,73 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"log/slog"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	slow := func() string { clock.Advance(15 * time.Millisecond); return "" }
+	page := htmltemplate.Must(htmltemplate.New("page.html").Funcs(htmltemplate.FuncMap{"slow": slow}).Parse(`<p>{{.}}</p>{{slow}}`))
+	mail := texttemplate.Must(texttemplate.New("mail.txt").Funcs(texttemplate.FuncMap{"slow": slow}).Parse(`Hi {{.}}{{slow}}`))
+
+	ctx := NewContext(context.Background(), WithClock(clock))
+	var out bytes.Buffer
+	if err := RenderTemplate(ctx, &out, page, "<b>"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<p>&lt;b&gt;</p>" {
+		t.Errorf("Expected the rendered page, got %q", out.String())
+	}
+	if err := RenderTemplate(ctx, &out, mail, "Ann"); err != nil {
+		t.Fatal(err)
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	if entry["template"] != "mail.txt" || entry["template_renders"] != float64(2) {
+		t.Errorf("Expected template=mail.txt template_renders=2, got %v", entry)
+	}
+	if entry["template_render_ms"] != float64(30) {
+		t.Errorf("Expected template_render_ms=30, got %v", entry["template_render_ms"])
+	}
+	if entry["template_bytes"] != float64(len("<p>&lt;b&gt;</p>Hi Ann")) {
+		t.Errorf("Expected template_bytes to total both renders, got %v", entry["template_bytes"])
+	}
+	if entry["template_error"] != nil {
+		t.Errorf("Expected no template_error, got %v", entry["template_error"])
+	}
+}
+
+func TestRenderTemplateError(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	tmpl := texttemplate.Must(texttemplate.New("broken").Parse(`{{.Missing.Field}}`))
+	ctx := NewContext(context.Background())
+	var out bytes.Buffer
+	err := RenderTemplate(ctx, &out, tmpl, struct{ Missing *struct{ Field string } }{})
+	if err == nil {
+		t.Fatal("Expected an execution error")
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if msg, _ := entry["template_error"].(string); !strings.Contains(msg, "broken") {
+		t.Errorf("Expected template_error to name the template, got %v", entry["template_error"])
+	}
+
+	// Without a logger the template still renders
+	out.Reset()
+	if err := RenderTemplate(context.Background(), &out, texttemplate.Must(texttemplate.New("t").Parse("ok")), nil); err != nil || out.String() != "ok" {
+		t.Errorf("Expected ok, got %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,212 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// requestIDKey is the field the request ID is recorded under.
+const requestIDKey = "request_id"
+
+// RequestIDConfig configures ExtractRequestID.
+type RequestIDConfig struct {
+	// Extractors are tried in order to find an existing request ID. The first
+	// non-empty result is used. Defaults to HeaderExtractor("X-Request-Id").
+	Extractors []func(h http.Header) string
+
+	// Generator creates a new ID when no extractor finds one.
+	// Defaults to NewUUIDv7.
+	Generator func() string
+
+	// Prefix is prepended to generated IDs, for example "api-".
+	// Extracted IDs are recorded unchanged.
+	Prefix string
+}
+
+// requestIDConfig holds the configuration set by SetRequestIDConfig.
+var requestIDConfig atomic.Pointer[RequestIDConfig]
+
+// SetRequestIDConfig configures how ExtractRequestID finds or generates request IDs.
+//
+// Example:
+//
+//	canonlog.SetRequestIDConfig(canonlog.RequestIDConfig{
+//		Extractors: []func(http.Header) string{
+//			canonlog.HeaderExtractor("X-Request-Id"),
+//			canonlog.AmznTraceIDExtractor,
+//			canonlog.HeaderExtractor("CF-Ray"),
+//		},
+//		Generator: canonlog.NewULID,
+//		Prefix:    "checkout-",
+//	})
+func SetRequestIDConfig(cfg RequestIDConfig) {
+	requestIDConfig.Store(&cfg)
+}
+
+// ExtractRequestID finds the request ID in h using the configured extractors,
+// or generates a new one, records it as the persistent request_id field on the
+// logger in ctx, and returns it. Call it after NewContext in the server's
+// request handling; the returned ID can be echoed in a response header.
+func ExtractRequestID(ctx context.Context, h http.Header) string {
+	cfg := RequestIDConfig{}
+	if c := requestIDConfig.Load(); c != nil {
+		cfg = *c
+	}
+	if cfg.Extractors == nil {
+		cfg.Extractors = []func(http.Header) string{HeaderExtractor("X-Request-Id")}
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = NewUUIDv7
+	}
+
+	var id string
+	for _, extract := range cfg.Extractors {
+		if id = extract(h); id != "" {
+			break
+		}
+	}
+	if id == "" {
+		id = cfg.Prefix + cfg.Generator()
+	}
+
+	if l, ok := TryGetLogger(ctx); ok {
+		l.Persist(requestIDKey, id)
+	}
+	return id
+}
+
+// HeaderExtractor returns an extractor that reads the named header.
+func HeaderExtractor(name string) func(http.Header) string {
+	return func(h http.Header) string {
+		return strings.TrimSpace(h.Get(name))
+	}
+}
+
+// AmznTraceIDExtractor reads the Root component of the X-Amzn-Trace-Id header
+// set by AWS load balancers, for example "1-5759e988-bd862e3fe1be46a994272793".
+func AmznTraceIDExtractor(h http.Header) string {
+	for _, part := range strings.Split(h.Get("X-Amzn-Trace-Id"), ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok && k == "Root" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewUUIDv7 returns a time-ordered RFC 9562 version 7 UUID.
+func NewUUIDv7() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	ms := uint64(time.Now().UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 9562 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a 26 character ULID: a 48-bit millisecond timestamp followed
+// by 80 random bits, in Crockford base32.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(b[6:])
+
+	// 128 bits encode to 26 characters of 5 bits, with 2 leading zero bits
+	var s [26]byte
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// base62 is the alphabet used by KSUIDs.
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ksuidEpoch is the KSUID timestamp epoch (2014-05-13T16:53:20Z).
+const ksuidEpoch = 1400000000
+
+// NewKSUID returns a 27 character KSUID: a 32-bit second timestamp relative to
+// the KSUID epoch followed by 128 random bits, in base62.
+func NewKSUID() string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[0:4], uint32(time.Now().Unix()-ksuidEpoch))
+	_, _ = rand.Read(b[4:])
+
+	n := new(big.Int).SetBytes(b[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	var s [27]byte
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		s[i] = base62[mod.Int64()]
+	}
+	return string(s[:])
+}
+
+// snowflakeEpoch is the custom epoch for snowflake IDs (2020-01-01T00:00:00Z).
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+// NewSnowflakeGenerator returns a generator of snowflake-style IDs: a 41-bit
+// millisecond timestamp since 2020-01-01, the low 10 bits of node, and a 12-bit
+// per-millisecond sequence, formatted in decimal. Each process or instance
+// should use a distinct node. The generator is safe for concurrent use.
+func NewSnowflakeGenerator(node int64) func() string {
+	var (
+		mu   sync.Mutex
+		last int64
+		seq  int64
+	)
+	node &= 0x3ff
+	return func() string {
+		mu.Lock()
+		now := time.Now().UnixMilli() - snowflakeEpoch
+		if now < last {
+			now = last // clock moved backwards; keep IDs monotonic
+		}
+		if now == last {
+			seq = (seq + 1) & 0xfff
+			if seq == 0 {
+				// Sequence exhausted for this millisecond; borrow the next one
+				now++
+			}
+		} else {
+			seq = 0
+		}
+		last = now
+		id := now<<22 | node<<12 | seq
+		mu.Unlock()
+		return strconv.FormatInt(id, 10)
+	}
+}
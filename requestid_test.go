@@ -0,0 +1,91 @@
+package canonlog
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestExtractRequestID(t *testing.T) {
+	t.Cleanup(func() { requestIDConfig.Store(nil) })
+
+	h := http.Header{}
+	h.Set("X-Request-Id", "incoming-123")
+
+	ctx := NewContext(context.Background())
+	if id := ExtractRequestID(ctx, h); id != "incoming-123" {
+		t.Errorf("Expected incoming ID, got %q", id)
+	}
+	if GetLogger(ctx).Fields()[requestIDKey] != "incoming-123" {
+		t.Error("Expected request_id recorded on logger")
+	}
+
+	ctx = NewContext(context.Background())
+	id := ExtractRequestID(ctx, http.Header{})
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("Expected generated UUIDv7, got %q", id)
+	}
+}
+
+func TestExtractRequestIDConfig(t *testing.T) {
+	t.Cleanup(func() { requestIDConfig.Store(nil) })
+
+	SetRequestIDConfig(RequestIDConfig{
+		Extractors: []func(http.Header) string{
+			HeaderExtractor("X-Request-Id"),
+			AmznTraceIDExtractor,
+		},
+		Generator: func() string { return "generated" },
+		Prefix:    "svc-",
+	})
+
+	h := http.Header{}
+	h.Set("X-Amzn-Trace-Id", "Self=1-abc;Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1")
+	if id := ExtractRequestID(context.Background(), h); id != "1-5759e988-bd862e3fe1be46a994272793" {
+		t.Errorf("Expected Root from X-Amzn-Trace-Id, got %q", id)
+	}
+
+	if id := ExtractRequestID(context.Background(), http.Header{}); id != "svc-generated" {
+		t.Errorf("Expected prefixed generated ID, got %q", id)
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	a, b := NewULID(), NewULID()
+
+	if !regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`).MatchString(a) {
+		t.Errorf("Invalid ULID %q", a)
+	}
+	if a == b {
+		t.Error("Expected unique ULIDs")
+	}
+}
+
+func TestNewKSUID(t *testing.T) {
+	id := NewKSUID()
+
+	if !regexp.MustCompile(`^[0-9A-Za-z]{27}$`).MatchString(id) {
+		t.Errorf("Invalid KSUID %q", id)
+	}
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	gen := NewSnowflakeGenerator(5)
+
+	var prev int64
+	for i := 0; i < 5000; i++ {
+		id, err := strconv.ParseInt(gen(), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev {
+			t.Fatalf("Expected increasing IDs, got %d after %d", id, prev)
+		}
+		if node := (id >> 12) & 0x3ff; node != 5 {
+			t.Fatalf("Expected node 5, got %d", node)
+		}
+		prev = id
+	}
+}
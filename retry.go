@@ -0,0 +1,124 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// Retryer retries an operation with exponential backoff and records the
+// attempts on the logger in context, so a slow request is explained by its
+// retries.
+//
+// Example:
+//
+//	r := canonlog.Retryer{Name: "payments", MaxAttempts: 4}
+//	err := r.Do(ctx, func(ctx context.Context) error {
+//		return payments.Charge(ctx, order)
+//	})
+//	// payments_attempts=3 payments_retries=2 payments_backoff_ms=300
+//	// payments_errors=["503 Service Unavailable","503 Service Unavailable"]
+type Retryer struct {
+	// Name prefixes the recorded fields.
+	Name string
+
+	// MaxAttempts is the most times the operation runs. Defaults to 3.
+	MaxAttempts int
+
+	// Backoff returns the wait after the given failed attempt, counting from
+	// 1. Defaults to 100ms doubled per attempt, with up to 10% jitter.
+	Backoff func(attempt int) time.Duration
+
+	// Retryable reports whether err is worth retrying. Defaults to every
+	// error except context cancellation.
+	Retryable func(err error) bool
+}
+
+// Do runs fn until it succeeds, returns an error that is not retryable, has
+// run MaxAttempts times, or ctx is done, and returns its last error. It
+// records name_attempts, and for each failed attempt that is retried, the
+// fields of RetryNotify.
+func (r Retryer) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	backoff := r.Backoff
+	if backoff == nil {
+		backoff = defaultRetryBackoff
+	}
+	notify := RetryNotify(ctx, r.Name)
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if l, ok := TryGetLogger(ctx); ok {
+			l.InfoAdd(r.Name+"_attempts", attempt)
+		}
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+		if r.Retryable != nil && !r.Retryable(err) {
+			return err
+		}
+		if r.Retryable == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			return err
+		}
+
+		wait := backoff(attempt)
+		notify(err, wait)
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// defaultRetryBackoff waits 100ms doubled per failed attempt, plus up to 10%
+// jitter.
+func defaultRetryBackoff(attempt int) time.Duration {
+	d := 100 * time.Millisecond << min(attempt-1, 16)
+	return d + rand.N(d/10+1)
+}
+
+// RetryNotify returns a function to call after each failed attempt of a
+// retried operation, with the attempt's error and the wait before the next
+// one. It records on the logger in ctx name_retries, the number of retries;
+// name_backoff_ms, the total wait in milliseconds; and name_errors, the
+// attempts' errors. Its signature matches backoff.Notify from
+// github.com/cenkalti/backoff, so it plugs into backoff.RetryNotify. It does
+// nothing if ctx has no logger.
+//
+// Example:
+//
+//	err := backoff.RetryNotify(op, backoff.NewExponentialBackOff(), canonlog.RetryNotify(ctx, "search"))
+func RetryNotify(ctx context.Context, name string) func(err error, wait time.Duration) {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return func(error, time.Duration) {}
+	}
+	return func(err error, wait time.Duration) {
+		l.recordRetry(name, err, wait)
+	}
+}
+
+// recordRetry records one retry of the operation called name.
+func (l *Logger) recordRetry(name string, err error, wait time.Duration) {
+	if l.gateLevel.Load() > slog.LevelInfo {
+		return
+	}
+	l.mu.Lock()
+	retriesKey, backoffKey := name+"_retries", name+"_backoff_ms"
+	retries, _ := l.fields[retriesKey].(int)
+	l.storeField(retriesKey, retries+1)
+	total, _ := l.fields[backoffKey].(int64)
+	l.storeField(backoffKey, total+wait.Milliseconds())
+	l.mu.Unlock()
+	if err != nil {
+		l.Append(name+"_errors", err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRetryerDo(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	calls := 0
+	r := Retryer{
+		Name:        "payments",
+		MaxAttempts: 4,
+		Backoff:     func(attempt int) time.Duration { return time.Duration(attempt) * time.Millisecond },
+	}
+	err := r.Do(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("503 Service Unavailable")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Expected success on attempt 3, got %v after %d calls", err, calls)
+	}
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+
+	if entry["payments_attempts"] != float64(3) || entry["payments_retries"] != float64(2) || entry["payments_backoff_ms"] != float64(3) {
+		t.Errorf("Expected attempts=3 retries=2 backoff_ms=3, got %v", entry)
+	}
+	if errs, _ := entry["payments_errors"].([]any); len(errs) != 2 || errs[0] != "503 Service Unavailable" {
+		t.Errorf("Expected two attempt errors, got %v", entry["payments_errors"])
+	}
+}
+
+func TestRetryerDoGivesUp(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	permanent := errors.New("400 Bad Request")
+	tests := []struct {
+		name  string
+		r     Retryer
+		err   error
+		calls int
+	}{
+		{"max attempts", Retryer{Name: "a", Backoff: func(int) time.Duration { return 0 }}, errors.New("unavailable"), 3},
+		{"not retryable", Retryer{Name: "b", Retryable: func(err error) bool { return err != permanent }}, permanent, 1},
+		{"canceled", Retryer{Name: "c"}, context.Canceled, 1},
+	}
+	for _, tt := range tests {
+		ctx := NewContext(context.Background())
+		calls := 0
+		err := tt.r.Do(ctx, func(context.Context) error {
+			calls++
+			return tt.err
+		})
+		if err != tt.err || calls != tt.calls {
+			t.Errorf("%s: got %v after %d calls, want %v after %d", tt.name, err, calls, tt.err, tt.calls)
+		}
+	}
+}
+
+func TestRetryerDoContextDone(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	ctx, cancel := context.WithCancel(NewContext(context.Background()))
+	calls := 0
+	err := Retryer{Name: "search", Backoff: func(int) time.Duration { return time.Hour }}.Do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errors.New("timeout")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected to stop waiting when ctx is done, got %v after %d calls", err, calls)
+	}
+}
+
+func TestRetryNotify(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	notify := RetryNotify(ctx, "search")
+	notify(errors.New("timeout"), 2*time.Second)
+	notify(errors.New("timeout"), 4*time.Second)
+	GetLogger(ctx).Flush(ctx)
+	entry := decodeEntry(t, buf)
+	if entry["search_retries"] != float64(2) || entry["search_backoff_ms"] != float64(6000) {
+		t.Errorf("Expected retries=2 backoff_ms=6000, got %v", entry)
+	}
+
+	// Without a logger it is a no-op
+	RetryNotify(context.Background(), "search")(errors.New("timeout"), time.Second)
+}
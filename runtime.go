@@ -0,0 +1,60 @@
+package canonlog
+
+import (
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync/atomic"
+)
+
+// Fields of a runtime snapshot.
+const (
+	goroutinesKey  = "runtime_goroutines"
+	gomaxprocsKey  = "runtime_gomaxprocs"
+	gcCyclesKey    = "runtime_gc_cycles"
+	gcLastPauseKey = "runtime_gc_last_pause_ms"
+	heapBytesKey   = "runtime_heap_bytes"
+)
+
+// errorRuntimeSnapshot is set by SetErrorRuntimeSnapshot.
+var errorRuntimeSnapshot atomic.Bool
+
+// SetErrorRuntimeSnapshot controls whether Error-level flushes carry a snapshot
+// of the Go runtime, to help correlate failures with resource pressure:
+// runtime_goroutines, runtime_gomaxprocs, runtime_gc_cycles,
+// runtime_gc_last_pause_ms, and runtime_heap_bytes (bytes in live and
+// unswept heap objects). Lines below Error and checkpoints are unaffected.
+func SetErrorRuntimeSnapshot(enabled bool) {
+	errorRuntimeSnapshot.Store(enabled)
+}
+
+// runtimeSamples are the runtime metrics read by addRuntimeDiagnostics.
+var runtimeSamples = []string{
+	"/gc/cycles/total:gc-cycles",
+	"/memory/classes/heap/objects:bytes",
+}
+
+// addRuntimeDiagnostics writes a runtime snapshot to fields. Unlike
+// runtime.ReadMemStats, none of the sources used stop the world.
+func addRuntimeDiagnostics(fields map[string]any) {
+	samples := make([]metrics.Sample, len(runtimeSamples))
+	for i, name := range runtimeSamples {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	fields[goroutinesKey] = runtime.NumGoroutine()
+	fields[gomaxprocsKey] = runtime.GOMAXPROCS(0)
+	if v := samples[0].Value; v.Kind() == metrics.KindUint64 {
+		fields[gcCyclesKey] = v.Uint64()
+	}
+	if v := samples[1].Value; v.Kind() == metrics.KindUint64 {
+		fields[heapBytesKey] = v.Uint64()
+	}
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	if len(gc.Pause) > 0 {
+		fields[gcLastPauseKey] = float64(gc.Pause[0].Microseconds()) / 1000
+	}
+}
@@ -0,0 +1,40 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestErrorRuntimeSnapshot(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	SetErrorRuntimeSnapshot(true)
+	t.Cleanup(func() { SetErrorRuntimeSnapshot(false) })
+
+	keys := []string{"runtime_goroutines", "runtime_gomaxprocs", "runtime_gc_cycles", "runtime_heap_bytes"}
+
+	l := New()
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	for _, k := range keys {
+		if _, ok := entry[k]; ok {
+			t.Errorf("Expected no %s on an INFO line", k)
+		}
+	}
+
+	buf.Reset()
+	l.ErrorAdd(errors.New("boom"))
+	l.Flush(context.Background())
+	entry = decodeEntry(t, buf)
+	for _, k := range keys {
+		if _, ok := entry[k]; !ok {
+			t.Errorf("Expected %s on an ERROR line", k)
+		}
+	}
+	if n, _ := entry["runtime_goroutines"].(float64); n < 1 {
+		t.Errorf("Expected at least one goroutine, got %v", entry["runtime_goroutines"])
+	}
+}
@@ -0,0 +1,69 @@
+package canonlog
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// WithSanitizedValues runs every string field value through Sanitize, so
+// user-supplied data such as user agents and paths cannot break line-oriented
+// parsers or forge log lines. Combine it with WithMaxValueLength to also cap
+// the length of such values.
+func WithSanitizedValues() Option {
+	return func(l *Logger) {
+		l.sanitize = true
+	}
+}
+
+// Sanitize makes s safe to write to a line-oriented log: invalid UTF-8 is
+// replaced with U+FFFD, newlines, carriage returns, and tabs are escaped as
+// \n, \r, and \t, and other control characters, including the escape that
+// starts ANSI sequences, are removed. Strings that need no change are returned
+// without allocating.
+func Sanitize(s string) string {
+	if !needsSanitize(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case isControl(r):
+			// dropped
+		default:
+			// Invalid bytes decode as utf8.RuneError and are written as U+FFFD
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// needsSanitize reports whether Sanitize would change s.
+func needsSanitize(s string) bool {
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c < 0x20 || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || isControl(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// isControl reports whether r is a C0 or C1 control character or DEL.
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r < 0xa0)
+}
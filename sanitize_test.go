@@ -0,0 +1,57 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"curl/8.4.0", "curl/8.4.0"},
+		{"héllo wörld", "héllo wörld"},
+		{"line1\nline2", `line1\nline2`},
+		{"a\r\nb\tc", `a\r\nb\tc`},
+		{"\x1b[31mred\x1b[0m", "[31mred[0m"},
+		{"nul\x00del\x7fc1\u0085", "nuldelc1"},
+		{"bad\xffutf8", "bad�utf8"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeNoAlloc(t *testing.T) {
+	s := strings.Repeat("Mozilla/5.0 (X11; Linux x86_64) ", 4)
+	if n := testing.AllocsPerRun(100, func() { Sanitize(s) }); n != 0 {
+		t.Errorf("Expected no allocations for a clean string, got %v", n)
+	}
+}
+
+func TestWithSanitizedValues(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithSanitizedValues(), WithMaxValueLength(16))
+	l.InfoAdd("path", "/login\nlevel=ERROR msg=forged")
+	l.InfoAdd("status", 200)
+	l.Persist("user_agent", "evil\r\nagent")
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["path"] != `/login\nlevel=ER` {
+		t.Errorf("Expected sanitized and truncated path, got %q", entry["path"])
+	}
+	if entry["user_agent"] != `evil\r\nagent` {
+		t.Errorf("Expected sanitized persistent field, got %q", entry["user_agent"])
+	}
+	if entry["status"] != float64(200) {
+		t.Errorf("Expected non-string values unchanged, got %v", entry["status"])
+	}
+}
@@ -0,0 +1,54 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Keys of the security group on entries with security events.
+const (
+	securityKey       = "security"
+	securityEventsKey = "events"
+)
+
+// SecurityEvent tags the entry as security-relevant, such as "auth_failure"
+// or "csrf_mismatch", so SIEM pipelines can select it reliably. The entry gets
+// a security group holding the event names in an events array plus the given
+// fields. The event is recorded regardless of the gate level and escalates the
+// entry to at least Warn. Entries with security events are never suppressed
+// by a Limiter or folded into Aggregator rollups.
+//
+// Example:
+//
+//	log.SecurityEvent("auth_failure", map[string]any{"reason": "bad_password"})
+func (l *Logger) SecurityEvent(event string, fields map[string]any) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.security == nil {
+		l.security = make(map[string]any, len(fields))
+	}
+	maps.Copy(l.security, fields)
+	l.securityEvents = append(l.securityEvents, event)
+	if l.level < slog.LevelWarn {
+		l.level = slog.LevelWarn
+	}
+	return l
+}
+
+// SecurityEvent tags the entry of the logger in context as security-relevant.
+// Panics if no logger exists in context.
+func SecurityEvent(ctx context.Context, event string, fields map[string]any) {
+	GetLogger(ctx).SecurityEvent(event, fields)
+}
+
+// securityGroup builds the security attribute of an entry.
+func securityGroup(events []string, fields map[string]any) slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields)+1)
+	attrs = append(attrs, slog.Any(securityEventsKey, events))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return slog.Attr{Key: securityKey, Value: slog.GroupValue(attrs...)}
+}
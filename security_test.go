@@ -0,0 +1,102 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecurityEvent(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	SecurityEvent(ctx, "auth_failure", map[string]any{"reason": "bad_password"})
+	SecurityEvent(ctx, "account_locked", nil)
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	if entry["level"] != "WARN" {
+		t.Errorf("Expected WARN level, got %v", entry["level"])
+	}
+	sec, ok := entry["security"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected security group, got %v", entry["security"])
+	}
+	events, _ := sec["events"].([]any)
+	if len(events) != 2 || events[0] != "auth_failure" || events[1] != "account_locked" {
+		t.Errorf("Expected both events, got %v", sec["events"])
+	}
+	if sec["reason"] != "bad_password" {
+		t.Errorf("Expected reason in security group, got %v", sec)
+	}
+}
+
+func TestSecurityEventKeepsErrorLevel(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.ErrorAdd(errors.New("replayed token")).SecurityEvent("token_replay", nil)
+	l.Flush(context.Background())
+
+	if entry := decodeEntry(t, buf); entry["level"] != "ERROR" {
+		t.Errorf("Expected ERROR level to be kept, got %v", entry["level"])
+	}
+}
+
+func TestSecurityEventIgnoresGate(t *testing.T) {
+	defer setTestLogLevel(slog.LevelError)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.InfoAdd("ignored", true).SecurityEvent("auth_failure", nil)
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["security"] == nil {
+		t.Error("Expected security event to be recorded above the gate level")
+	}
+	if _, ok := entry["ignored"]; ok {
+		t.Error("Expected gated field to be ignored")
+	}
+}
+
+func TestSecurityEventResetAfterFlush(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New()
+	l.SecurityEvent("auth_failure", nil)
+	l.Flush(context.Background())
+	buf.Reset()
+
+	l.InfoAdd("user_id", "123")
+	l.Flush(context.Background())
+	if entry := decodeEntry(t, buf); entry["security"] != nil {
+		t.Errorf("Expected security group to be reset, got %v", entry["security"])
+	}
+}
+
+func TestSecurityEventExemptFromLimiting(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	SetLimiter(NewLimiter(time.Hour, "route"))
+	t.Cleanup(func() { SetLimiter(nil) })
+	agg := NewAggregator(AggregatorConfig{KeyField: "route", Keys: []string{"/login"}})
+	SetAggregator(agg)
+	t.Cleanup(func() { SetAggregator(nil) })
+
+	for range 3 {
+		l := New()
+		l.InfoAdd("route", "/login").SecurityEvent("auth_failure", nil)
+		l.Flush(context.Background())
+	}
+
+	if n := strings.Count(buf.String(), "auth_failure"); n != 3 {
+		t.Errorf("Expected all 3 security entries to be emitted, got %d", n)
+	}
+}
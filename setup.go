@@ -0,0 +1,178 @@
+package canonlog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config configures the global logger for Setup.
+type Config struct {
+	// Level is the minimum level: "debug", "info", "warn" (or "warning"), or
+	// "error". Defaults to "info".
+	Level string
+
+	// Format is "json", "text", "gcp" (see NewGCPHandler), or the name of an
+	// encoder registered with RegisterEncoder. Defaults to "text".
+	Format string
+
+	// Output is where lines are written. Defaults to os.Stdout.
+	Output io.Writer
+
+	// AddSource adds the source position of the logging call to each line.
+	// It is ignored by registered encoders.
+	AddSource bool
+
+	// ReplaceAttr rewrites or drops attributes before they are written, as in
+	// slog.HandlerOptions, for example to rename the built-in "time" and
+	// "level" keys. It is ignored by registered encoders.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// TimeFormat is a time.Format layout for the timestamp, such as
+	// time.RFC3339 or time.StampMilli. Defaults to the handler's RFC 3339
+	// with millisecond precision. It is applied before ReplaceAttr, which sees
+	// the formatted string, and is ignored by registered encoders.
+	TimeFormat string
+
+	// TimeLocation converts the timestamp to a time zone, such as time.UTC.
+	// Defaults to local time. It is ignored by registered encoders.
+	TimeLocation *time.Location
+
+	// LevelMapper replaces the level with a backend-specific severity, such
+	// as GCPSeverity or SyslogSeverity. Like TimeFormat it is applied before
+	// ReplaceAttr and ignored by registered encoders.
+	LevelMapper LevelMapper
+
+	// GCP configures the "gcp" format.
+	GCP GCPConfig
+
+	// Sinks, if set, sends lines to several outputs, each with its own
+	// level, format, and sampling, and the fields above are ignored.
+	// canonlog accumulates fields at the lowest sink level.
+	Sinks []Sink
+}
+
+// Setup configures the global slog logger and canonlog's accumulation level
+// from cfg. Unlike SetupGlobalLogger, it reports an invalid level or format as
+// an error, leaving the current configuration unchanged, and it may be called
+// again to reconfigure.
+//
+// Example:
+//
+//	err := canonlog.Setup(canonlog.Config{
+//		Level:     os.Getenv("LOG_LEVEL"),
+//		Format:    "json",
+//		Output:    os.Stderr,
+//		AddSource: true,
+//	})
+//
+// Pretty text on stderr at Warn and above, and JSON to a file at Debug:
+//
+//	err := canonlog.Setup(canonlog.Config{Sinks: []canonlog.Sink{
+//		{Config: canonlog.Config{Level: "warn", Format: "text", Output: os.Stderr}},
+//		{Config: canonlog.Config{Level: "debug", Format: "json", Output: file}},
+//	}})
+func Setup(cfg Config) error {
+	if len(cfg.Sinks) > 0 {
+		handler, level, err := newSinksHandler(cfg.Sinks)
+		if err != nil {
+			return err
+		}
+		logLevel.Store(int32(level))
+		slog.SetDefault(slog.New(handler))
+		return nil
+	}
+
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	handler, err := newHandler(cfg, level)
+	if err != nil {
+		return err
+	}
+
+	// Store the level for accumulation filtering (atomic)
+	logLevel.Store(int32(level))
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// parseLevel parses a level name, case-insensitively. An empty name is info.
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("canonlog: unknown log level %q", s)
+}
+
+// newHandler builds the handler for cfg's format at level.
+func newHandler(cfg Config, level slog.Level) (slog.Handler, error) {
+	w := cfg.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   cfg.AddSource,
+		ReplaceAttr: replaceBuiltins(cfg),
+	}
+
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	case "text", "":
+		return slog.NewTextHandler(w, opts), nil
+	case "gcp":
+		return NewGCPHandler(w, cfg.GCP, opts), nil
+	}
+	if enc, ok := lookupEncoder(cfg.Format); ok {
+		return NewEncoderHandler(w, enc, level), nil
+	}
+	return nil, fmt.Errorf("canonlog: unknown log format %q", cfg.Format)
+}
+
+// replaceBuiltins returns cfg.ReplaceAttr preceded by cfg's timestamp
+// formatting and level mapping.
+func replaceBuiltins(cfg Config) func([]string, slog.Attr) slog.Attr {
+	if cfg.TimeFormat == "" && cfg.TimeLocation == nil && cfg.LevelMapper == nil {
+		return cfg.ReplaceAttr
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch {
+			case a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime:
+				a.Value = cfg.formatTime(a.Value.Time())
+			case a.Key == slog.LevelKey && cfg.LevelMapper != nil:
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a.Value = cfg.LevelMapper(level)
+				}
+			}
+		}
+		if cfg.ReplaceAttr != nil {
+			return cfg.ReplaceAttr(groups, a)
+		}
+		return a
+	}
+}
+
+// formatTime applies TimeLocation and TimeFormat to t.
+func (cfg Config) formatTime(t time.Time) slog.Value {
+	if cfg.TimeLocation != nil {
+		t = t.In(cfg.TimeLocation)
+	}
+	if cfg.TimeFormat != "" {
+		return slog.StringValue(t.Format(cfg.TimeFormat))
+	}
+	return slog.TimeValue(t)
+}
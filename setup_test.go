@@ -0,0 +1,116 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetup(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var buf bytes.Buffer
+	err := Setup(Config{
+		Level:     "warn",
+		Format:    "JSON",
+		Output:    &buf,
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				a.Key = "ts"
+			}
+			return a
+		},
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if getLogLevel() != slog.LevelWarn {
+		t.Errorf("Expected accumulation level WARN, got %v", getLogLevel())
+	}
+
+	l := New()
+	l.WarnAdd("k", "v")
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, &buf)
+	if _, ok := entry["ts"]; !ok {
+		t.Errorf("Expected ReplaceAttr to rename time to ts, got %v", entry)
+	}
+	if _, ok := entry["source"]; !ok {
+		t.Errorf("Expected AddSource to add source, got %v", entry)
+	}
+}
+
+func TestSetupInvalid(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	before := slog.Default()
+
+	if err := Setup(Config{Level: "verbose"}); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if err := Setup(Config{Level: "debug", Format: "xml"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if slog.Default() != before || getLogLevel() != slog.LevelInfo {
+		t.Error("Expected invalid config to leave the configuration unchanged")
+	}
+}
+
+func TestSetupTimeFormat(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var buf bytes.Buffer
+	err := Setup(Config{
+		Format:       "json",
+		Output:       &buf,
+		TimeFormat:   "2006-01-02T15:04:05Z07:00",
+		TimeLocation: time.UTC,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				a.Key = "ts"
+			case slog.LevelKey:
+				a.Key = "severity"
+			}
+			return a
+		},
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	New().InfoAdd("k", "v").Flush(context.Background())
+
+	entry := decodeEntry(t, &buf)
+	ts, _ := entry["ts"].(string)
+	if _, err := time.Parse(time.RFC3339, ts); err != nil || !strings.HasSuffix(ts, "Z") {
+		t.Errorf("Expected a UTC RFC 3339 ts without fractions, got %q", ts)
+	}
+	if strings.Contains(ts, ".") {
+		t.Errorf("Expected second precision, got %q", ts)
+	}
+	if entry["severity"] != "INFO" {
+		t.Errorf("Expected level renamed to severity, got %v", entry)
+	}
+}
+
+func TestSetupLevelMapper(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var buf bytes.Buffer
+	if err := Setup(Config{Format: "json", Output: &buf, LevelMapper: SyslogSeverity}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	New().WarnAdd("k", "v").Flush(context.Background())
+
+	if entry := decodeEntry(t, &buf); entry["level"] != float64(4) {
+		t.Errorf("Expected syslog severity 4 for WARN, got %v", entry["level"])
+	}
+}
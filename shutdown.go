@@ -0,0 +1,67 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func(context.Context) error
+)
+
+// AddShutdownHook registers a function to run when Close is called.
+// Use it for outputs that buffer entries, such as file or network handlers,
+// so they are drained before the process exits. Hooks run in reverse
+// registration order and should return promptly once ctx is done.
+func AddShutdownHook(hook func(ctx context.Context) error) {
+	if hook == nil {
+		return
+	}
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// Close drains canonlog's background state and runs shutdown hooks.
+// It stops the installed Aggregator, emitting its final rollup, emits pending
+// Limiter summaries, and then runs every hook registered with AddShutdownHook.
+//
+// Close should be called once during graceful shutdown, after the last logger
+// has been flushed. The deadline of ctx bounds the whole operation; if it
+// expires before all hooks finish, the remaining hooks are skipped and
+// ctx.Err() is included in the returned error. Hook errors are joined.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := canonlog.Close(ctx); err != nil {
+//		fmt.Fprintln(os.Stderr, "canonlog shutdown:", err)
+//	}
+func Close(ctx context.Context) error {
+	if agg := aggregator.Load(); agg != nil {
+		agg.Stop(ctx)
+	}
+	if lim := limiter.Load(); lim != nil {
+		lim.FlushSuppressed(ctx)
+	}
+
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	var order []int
+	AddShutdownHook(func(context.Context) error { order = append(order, 1); return nil })
+	AddShutdownHook(func(context.Context) error { order = append(order, 2); return errors.New("sink failed") })
+	AddShutdownHook(nil)
+
+	agg := NewAggregator(AggregatorConfig{KeyField: "route", Keys: []string{"/healthz"}})
+	SetAggregator(agg)
+	t.Cleanup(func() { SetAggregator(nil) })
+	New().InfoAdd("route", "/healthz").Flush(context.Background())
+
+	err := Close(context.Background())
+	if err == nil || err.Error() != "sink failed" {
+		t.Errorf("Expected hook error to be returned, got %v", err)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Expected hooks in reverse registration order, got %v", order)
+	}
+	if entry := decodeEntry(t, buf); entry["count"] != float64(1) {
+		t.Errorf("Expected final aggregator rollup on Close, got %v", entry)
+	}
+
+	if err := Close(context.Background()); err != nil {
+		t.Errorf("Second Close should have no hooks to run, got %v", err)
+	}
+}
+
+func TestCloseDeadline(t *testing.T) {
+	ran := false
+	AddShutdownHook(func(context.Context) error { ran = true; return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if err := Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if ran {
+		t.Error("Hooks should be skipped once the deadline has passed")
+	}
+}
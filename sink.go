@@ -0,0 +1,140 @@
+package canonlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// Sink is one output of a Setup with several, each with its own level,
+// format, and sampling.
+type Sink struct {
+	// Config configures the sink's handler as for Setup: Level, Format,
+	// Output, and the formatting options. Its Sinks field is ignored.
+	Config
+
+	// Handler, if set, is used instead of a handler built from Format and
+	// Output, for outputs such as NewLokiHandler. Level still applies.
+	Handler slog.Handler
+
+	// SampleRate is the fraction of lines, from 0 to 1, written to the sink.
+	// Lines at Error and above, security events, and entries from unsampled
+	// loggers are always written. Zero writes every line.
+	SampleRate float64
+}
+
+// unsampledKeyType marks the context of a line that sinks must not sample out,
+// such as a security event or an entry from an unsampled logger.
+type unsampledKeyType struct{}
+
+var unsampledKey = &unsampledKeyType{}
+
+// sinkHandler applies a Sink's level and sampling to its handler.
+type sinkHandler struct {
+	slog.Handler
+	level slog.Level
+	rate  float64
+}
+
+// Enabled implements slog.Handler.
+func (h *sinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *sinkHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.rate > 0 && r.Level < slog.LevelError && ctx.Value(unsampledKey) == nil && rand.Float64() >= h.rate {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sinkHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level, rate: h.rate}
+}
+
+// WithGroup implements slog.Handler.
+func (h *sinkHandler) WithGroup(name string) slog.Handler {
+	return &sinkHandler{Handler: h.Handler.WithGroup(name), level: h.level, rate: h.rate}
+}
+
+// newSinksHandler builds a handler writing to every sink, and returns the
+// lowest sink level, which canonlog accumulates at.
+func newSinksHandler(sinks []Sink) (slog.Handler, slog.Level, error) {
+	handlers := make([]slog.Handler, 0, len(sinks))
+	lowest := slog.Level(1 << 30)
+	for i, s := range sinks {
+		if s.SampleRate < 0 || s.SampleRate > 1 {
+			return nil, 0, fmt.Errorf("canonlog: sink %d: sample rate %v is not between 0 and 1", i, s.SampleRate)
+		}
+		level, err := parseLevel(s.Level)
+		if err != nil {
+			return nil, 0, fmt.Errorf("canonlog: sink %d: %w", i, err)
+		}
+		h := s.Handler
+		if h == nil {
+			if h, err = newHandler(s.Config, level); err != nil {
+				return nil, 0, fmt.Errorf("canonlog: sink %d: %w", i, err)
+			}
+		}
+		handlers = append(handlers, &sinkHandler{Handler: h, level: level, rate: s.SampleRate})
+		lowest = min(lowest, level)
+	}
+	return NewMultiHandler(handlers...), lowest, nil
+}
+
+// multiHandler sends records to several handlers.
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler returns a handler that sends each record to every handler
+// that is enabled for its level, for example a NewLokiHandler next to
+// standard output. Errors from the handlers are joined.
+func NewMultiHandler(handlers ...slog.Handler) slog.Handler {
+	return &multiHandler{handlers: handlers}
+}
+
+// Enabled implements slog.Handler.
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, hh := range h.handlers {
+		if hh.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implements slog.Handler.
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, hh := range h.handlers {
+		if hh.Enabled(ctx, r.Level) {
+			if err := hh.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, hh := range h.handlers {
+		handlers[i] = hh.WithAttrs(attrs)
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+// WithGroup implements slog.Handler.
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, hh := range h.handlers {
+		handlers[i] = hh.WithGroup(name)
+	}
+	return &multiHandler{handlers: handlers}
+}
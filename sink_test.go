@@ -0,0 +1,136 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetupSinks(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var text, jsonBuf bytes.Buffer
+	err := Setup(Config{Sinks: []Sink{
+		{Config: Config{Level: "warn", Format: "text", Output: &text}},
+		{Config: Config{Level: "debug", Format: "json", Output: &jsonBuf}},
+	}})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if getLogLevel() != slog.LevelDebug {
+		t.Errorf("Expected accumulation level DEBUG, got %v", getLogLevel())
+	}
+
+	l := New()
+	l.DebugAdd("cache", "miss")
+	l.Flush(context.Background())
+	l.WarnAdd("slow", true)
+	l.Flush(context.Background())
+
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %d: %q", len(lines), jsonBuf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil || entry["cache"] != "miss" {
+		t.Errorf("Expected debug entry in JSON sink, got %v (%v)", entry, err)
+	}
+	if got := text.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "slow=true") {
+		t.Errorf("Expected only the warn entry in text sink, got %q", got)
+	}
+}
+
+func TestSetupSinksHandlerAndSampling(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var all, sampled bytes.Buffer
+	err := Setup(Config{Sinks: []Sink{
+		{Handler: slog.NewJSONHandler(&all, nil)},
+		{Config: Config{Format: "json", Output: &sampled}, SampleRate: 0.000001},
+	}})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for range 20 {
+		l := New()
+		l.InfoAdd("k", "v")
+		l.Flush(context.Background())
+	}
+	l := New()
+	l.InfoAdd("failed", true)
+	l.ErrorAdd(errors.New("boom"))
+	l.Flush(context.Background())
+
+	if n := strings.Count(all.String(), "\n"); n != 21 {
+		t.Errorf("Expected 21 lines in handler sink, got %d", n)
+	}
+	if got := sampled.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"failed":true`) {
+		t.Errorf("Expected only the error entry in sampled sink, got %q", got)
+	}
+}
+
+func TestSetupSinksSamplingExemptions(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+
+	var sampled bytes.Buffer
+	err := Setup(Config{Sinks: []Sink{
+		{Config: Config{Format: "json", Output: &sampled}, SampleRate: 0.000001},
+	}})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for range 20 {
+		New().SecurityEvent("login_failed", map[string]any{"user": "u_1"}).Flush(context.Background())
+	}
+	if n := strings.Count(sampled.String(), "\n"); n != 20 {
+		t.Errorf("Expected every security event in sampled sink, got %d lines", n)
+	}
+}
+
+func TestSetupSinksInvalid(t *testing.T) {
+	defer setTestLogLevel(slog.LevelWarn)()
+	captureOutput(t)
+
+	for _, sinks := range [][]Sink{
+		{{Config: Config{Level: "loud"}}},
+		{{Config: Config{Format: "xml"}}},
+		{{SampleRate: 2}},
+	} {
+		if err := Setup(Config{Sinks: sinks}); err == nil {
+			t.Errorf("Expected error for %+v", sinks)
+		}
+	}
+	if getLogLevel() != slog.LevelWarn {
+		t.Errorf("Expected level unchanged after invalid Setup, got %v", getLogLevel())
+	}
+}
+
+func TestMultiHandler(t *testing.T) {
+	var a, b bytes.Buffer
+	h := NewMultiHandler(
+		slog.NewJSONHandler(&a, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)
+	logger := slog.New(h).With("service", "api").WithGroup("req")
+	logger.Debug("", "id", 1)
+	logger.Warn("", "id", 2)
+
+	if n := strings.Count(a.String(), "\n"); n != 2 {
+		t.Errorf("Expected 2 lines in debug handler, got %d", n)
+	}
+	if got := b.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"service":"api","req":{"id":2}`) {
+		t.Errorf("Expected warn line with attrs and group, got %q", got)
+	}
+	if h.Enabled(context.Background(), slog.Level(-8)) {
+		t.Error("Expected multi handler disabled below every handler's level")
+	}
+}
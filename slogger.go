@@ -0,0 +1,27 @@
+package canonlog
+
+import "log/slog"
+
+// WithSlogLogger emits the logger's lines through logger instead of
+// slog.Default, so attributes and groups added with its With and WithGroup
+// methods appear on every canonical line. Use it to keep the static attributes
+// of an established per-component slog setup.
+//
+// Example:
+//
+//	base := slog.Default().With("component", "billing").WithGroup("app")
+//	ctx = canonlog.NewContext(ctx, canonlog.WithSlogLogger(base))
+//	// fields are emitted as app.<key>, alongside component=billing
+func WithSlogLogger(logger *slog.Logger) Option {
+	return func(l *Logger) {
+		l.slogger = logger
+	}
+}
+
+// output returns the slog logger that l emits through.
+func (l *Logger) output() *slog.Logger {
+	if l.slogger != nil {
+		return l.slogger
+	}
+	return slog.Default()
+}
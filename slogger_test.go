@@ -0,0 +1,41 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestWithSlogLogger(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	defaultBuf := captureOutput(t)
+
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil)).With("component", "billing").WithGroup("app")
+
+	l := New(WithSlogLogger(base))
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+
+	if defaultBuf.Len() != 0 {
+		t.Errorf("Expected nothing on the default logger, got %s", defaultBuf.String())
+	}
+	entry := decodeEntry(t, &buf)
+	if entry["component"] != "billing" {
+		t.Errorf("Expected inherited component=billing, got %v", entry["component"])
+	}
+	app, _ := entry["app"].(map[string]any)
+	if app["k"] != "v" {
+		t.Errorf("Expected fields in the app group, got %v", entry)
+	}
+
+	// Forked loggers emit through the same slog logger
+	buf.Reset()
+	child := l.Fork()
+	child.InfoAdd("k", "child")
+	child.Flush(context.Background())
+	if entry := decodeEntry(t, &buf); entry["component"] != "billing" {
+		t.Errorf("Expected fork to inherit the slog logger, got %v", entry)
+	}
+}
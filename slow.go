@@ -0,0 +1,90 @@
+package canonlog
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Fields added to slow entries.
+const (
+	slowRequestKey   = "slow_request"
+	slowElapsedKey   = "slow_elapsed_ms"
+	slowThresholdKey = "slow_threshold_ms"
+)
+
+// SlowRequestConfig configures slow request detection.
+type SlowRequestConfig struct {
+	// Threshold is the elapsed time above which an entry is slow. Zero
+	// disables detection for entries whose route has no entry in Routes.
+	Threshold time.Duration
+
+	// RouteField names the string field that identifies the route, for example
+	// "route". Its value selects a threshold from Routes.
+	RouteField string
+
+	// Routes overrides Threshold for individual routes.
+	Routes map[string]time.Duration
+
+	// Diagnostics attaches a runtime snapshot to slow entries, with the same
+	// fields as SetErrorRuntimeSnapshot.
+	Diagnostics bool
+}
+
+// slowRequestConfig holds the configuration set by SetSlowRequestConfig.
+var slowRequestConfig atomic.Pointer[SlowRequestConfig]
+
+// SetSlowRequestConfig enables slow request detection. Loggers created after
+// the call are timed from New, or from their previous Flush. On Flush, an entry
+// that took longer than its threshold is escalated to at least Warn and tagged
+// slow_request=true with slow_elapsed_ms and slow_threshold_ms.
+// Checkpoints are never marked slow. Passing a zero config disables detection.
+//
+// Example:
+//
+//	canonlog.SetSlowRequestConfig(canonlog.SlowRequestConfig{
+//		Threshold:  500 * time.Millisecond,
+//		RouteField: "route",
+//		Routes:     map[string]time.Duration{"/reports": 5 * time.Second},
+//	})
+func SetSlowRequestConfig(cfg SlowRequestConfig) {
+	if cfg.Threshold <= 0 && len(cfg.Routes) == 0 {
+		slowRequestConfig.Store(nil)
+		return
+	}
+	slowRequestConfig.Store(&cfg)
+}
+
+// slowTimingEnabled reports whether new loggers should record their start time.
+func slowTimingEnabled() bool {
+	return slowRequestConfig.Load() != nil
+}
+
+// markSlow escalates and tags snap if elapsed exceeds the configured threshold.
+func markSlow(snap *snapshot, elapsed time.Duration) {
+	cfg := slowRequestConfig.Load()
+	if cfg == nil {
+		return
+	}
+	threshold := cfg.Threshold
+	if cfg.RouteField != "" {
+		if route, ok := snap.fields[cfg.RouteField].(string); ok {
+			if t, ok := cfg.Routes[route]; ok {
+				threshold = t
+			}
+		}
+	}
+	if threshold <= 0 || elapsed <= threshold {
+		return
+	}
+
+	snap.fields[slowRequestKey] = true
+	snap.fields[slowElapsedKey] = elapsed.Milliseconds()
+	snap.fields[slowThresholdKey] = threshold.Milliseconds()
+	if cfg.Diagnostics {
+		addRuntimeDiagnostics(snap.fields)
+	}
+	if snap.level < slog.LevelWarn {
+		snap.level = slog.LevelWarn
+	}
+}
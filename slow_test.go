@@ -0,0 +1,87 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func setSlowRequestConfig(t *testing.T, cfg SlowRequestConfig) {
+	t.Helper()
+	SetSlowRequestConfig(cfg)
+	t.Cleanup(func() { SetSlowRequestConfig(SlowRequestConfig{}) })
+}
+
+func TestSlowRequest(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setSlowRequestConfig(t, SlowRequestConfig{Threshold: 100 * time.Millisecond, Diagnostics: true})
+
+	l := New()
+	l.start = time.Now().Add(-time.Second)
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+	if entry["level"] != "WARN" {
+		t.Errorf("Expected slow entry at WARN, got %v", entry["level"])
+	}
+	if entry["slow_request"] != true {
+		t.Errorf("Expected slow_request=true, got %v", entry["slow_request"])
+	}
+	if entry["slow_threshold_ms"] != float64(100) {
+		t.Errorf("Expected slow_threshold_ms=100, got %v", entry["slow_threshold_ms"])
+	}
+	if ms, _ := entry["slow_elapsed_ms"].(float64); ms < 1000 {
+		t.Errorf("Expected slow_elapsed_ms >= 1000, got %v", entry["slow_elapsed_ms"])
+	}
+	for _, k := range []string{"runtime_goroutines", "runtime_gc_cycles", "runtime_heap_bytes"} {
+		if _, ok := entry[k]; !ok {
+			t.Errorf("Expected diagnostic field %s", k)
+		}
+	}
+
+	// The next unit of work is timed from the Flush
+	buf.Reset()
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	entry = decodeEntry(t, buf)
+	if entry["level"] != "INFO" || entry["slow_request"] != nil {
+		t.Errorf("Expected fast entry after Flush, got %v", entry)
+	}
+}
+
+func TestSlowRequestRoutes(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	setSlowRequestConfig(t, SlowRequestConfig{
+		Threshold:  100 * time.Millisecond,
+		RouteField: "route",
+		Routes:     map[string]time.Duration{"/reports": 5 * time.Second},
+	})
+
+	for route, slow := range map[string]bool{"/reports": false, "/users": true} {
+		buf.Reset()
+		l := New()
+		l.start = time.Now().Add(-time.Second)
+		l.InfoAdd("route", route)
+		l.Flush(context.Background())
+
+		entry := decodeEntry(t, buf)
+		if got := entry["slow_request"] == true; got != slow {
+			t.Errorf("%s: expected slow=%v, got %v", route, slow, got)
+		}
+		if _, ok := entry["runtime_goroutines"]; ok {
+			t.Errorf("%s: expected no diagnostics without Diagnostics", route)
+		}
+	}
+}
+
+func TestSlowRequestDisabled(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	SetSlowRequestConfig(SlowRequestConfig{})
+	if l := New(); !l.start.IsZero() {
+		t.Error("Expected loggers not to be timed when detection is disabled")
+	}
+}
@@ -0,0 +1,98 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Keys emitted on the lines of an entry split by WithSplitEntrySize.
+const (
+	entryIDKey    = "canonlog_entry_id"
+	entryPartKey  = "canonlog_part"
+	entryPartsKey = "canonlog_parts"
+)
+
+// WithSplitEntrySize splits entries whose approximate size exceeds n bytes,
+// measured as with WithMaxEntrySize, into a primary line and continuation
+// lines, instead of writing one line that a log transport may truncate or
+// reject. Fields are kept whole and in order, filling each line up to n; a
+// field larger than n gets a line of its own. Every line carries the same
+// canonlog_entry_id, a UUIDv7, and its canonlog_part index, counting from 0
+// for the primary line, which also carries canonlog_parts, the number of
+// lines. All lines are written at the entry's level. Flush hooks still see
+// the entry whole. Entries within the limit are written unchanged.
+// A value of zero or less disables splitting.
+//
+// Example:
+//
+//	log := canonlog.New(canonlog.WithSplitEntrySize(200 << 10)) // under a 256 KiB line limit
+func WithSplitEntrySize(n int) Option {
+	return func(l *Logger) {
+		l.splitSize = n
+	}
+}
+
+// writeLines writes attrs with msg as one line, or as linked lines if they
+// exceed the logger's split size, and counts the lines written.
+func (l *Logger) writeLines(ctx context.Context, level slog.Level, msg string, attrs []slog.Attr) {
+	parts := splitAttrs(attrs, l.splitSize)
+	if len(parts) <= 1 {
+		l.output().LogAttrs(ctx, level, msg, attrs...)
+		countLine(level)
+		return
+	}
+
+	id := NewUUIDv7()
+	for i, part := range parts {
+		line := make([]slog.Attr, 0, len(part)+3)
+		line = append(line, slog.String(entryIDKey, id), slog.Int(entryPartKey, i))
+		if i == 0 {
+			line = append(line, slog.Int(entryPartsKey, len(parts)))
+		}
+		line = append(line, part...)
+		l.output().LogAttrs(ctx, level, msg, line...)
+		countLine(level)
+	}
+}
+
+// splitAttrs divides attrs into consecutive runs of at most limit bytes,
+// measured by attrSize. It returns nil if attrs fit within limit or limit is
+// not positive.
+func splitAttrs(attrs []slog.Attr, limit int) [][]slog.Attr {
+	if limit <= 0 {
+		return nil
+	}
+	var (
+		parts        [][]slog.Attr
+		start, total int
+	)
+	for i, a := range attrs {
+		size := attrSize(a)
+		if i > start && total+size > limit {
+			parts = append(parts, attrs[start:i])
+			start, total = i, 0
+		}
+		total += size
+	}
+	if parts == nil {
+		return nil
+	}
+	return append(parts, attrs[start:])
+}
+
+// attrSize approximates the encoded size of a, as fieldSize does for fields.
+func attrSize(a slog.Attr) int {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		size := len(a.Key)
+		for _, ga := range v.Group() {
+			size += attrSize(ga)
+		}
+		return size
+	case slog.KindString:
+		return len(a.Key) + len(v.String())
+	default:
+		return fieldSize(a.Key, v.Any())
+	}
+}
@@ -0,0 +1,125 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithSplitEntrySize(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+	resetFlushHooks(t)
+	var hooked Entry
+	AddFlushHook(func(ctx context.Context, e Entry) { hooked = e })
+
+	ctx := context.Background()
+	l := New(WithSplitEntrySize(100), WithFieldOrder(OrderInsertion))
+	l.InfoAdd("route", "/export").
+		InfoAdd("query", strings.Repeat("q", 90)).
+		InfoAdd("body", strings.Repeat("b", 150)).
+		InfoAdd("status", 500).
+		ErrorAdd(errors.New("export failed"))
+	l.Flush(ctx)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d: %s", len(lines), buf.String())
+	}
+	merged := make(map[string]any)
+	var id any
+	for i, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry[entryPartKey] != float64(i) {
+			t.Errorf("Expected line %d to have %s=%d, got %v", i, entryPartKey, i, entry[entryPartKey])
+		}
+		if i == 0 {
+			id = entry[entryIDKey]
+			if entry[entryPartsKey] != float64(4) {
+				t.Errorf("Expected the primary line to have %s=4, got %v", entryPartsKey, entry[entryPartsKey])
+			}
+		} else if _, ok := entry[entryPartsKey]; ok {
+			t.Errorf("Expected %s only on the primary line, got it on line %d", entryPartsKey, i)
+		}
+		if entry[entryIDKey] != id || id == nil {
+			t.Errorf("Expected every line to share %s, got %v and %v", entryIDKey, id, entry[entryIDKey])
+		}
+		if entry["level"] != "ERROR" {
+			t.Errorf("Expected every line at the entry's level, got %v", entry["level"])
+		}
+		for k, v := range entry {
+			switch k {
+			case "time", "level", "msg", entryIDKey, entryPartKey, entryPartsKey:
+				continue
+			}
+			if _, dup := merged[k]; dup {
+				t.Errorf("Expected %s on one line only", k)
+			}
+			merged[k] = v
+		}
+	}
+	if merged["route"] != "/export" || merged["status"] != float64(500) || len(merged["body"].(string)) != 150 {
+		t.Errorf("Expected every field whole across the lines, got %v", merged)
+	}
+	if _, ok := merged["errors"]; !ok {
+		t.Errorf("Expected errors on one of the lines, got %v", merged)
+	}
+	if len(hooked.Fields["body"].(string)) != 150 || hooked.Fields["route"] != "/export" {
+		t.Errorf("Expected flush hooks to see the whole entry, got %v", hooked.Fields)
+	}
+}
+
+func TestWithSplitEntrySizeWithinLimit(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	New(WithSplitEntrySize(1000)).InfoAdd("route", "/users").Flush(context.Background())
+	entry := decodeEntry(t, buf)
+	if _, ok := entry[entryIDKey]; ok {
+		t.Errorf("Expected no %s on an entry within the limit, got %v", entryIDKey, entry)
+	}
+	if entry["route"] != "/users" {
+		t.Errorf("Expected route=/users, got %v", entry["route"])
+	}
+}
+
+func TestSplitAttrs(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("a", strings.Repeat("x", 9)),  // 10
+		slog.String("b", strings.Repeat("x", 9)),  // 10
+		slog.String("c", strings.Repeat("x", 49)), // 50, over the limit alone
+		slog.Int("d", 12345),                      // 6
+		slog.Group("e", slog.String("f", "xxxx")), // 6
+	}
+	parts := splitAttrs(attrs, 20)
+	var keys [][]string
+	for _, p := range parts {
+		var ks []string
+		for _, a := range p {
+			ks = append(ks, a.Key)
+		}
+		keys = append(keys, ks)
+	}
+	want := [][]string{{"a", "b"}, {"c"}, {"d", "e"}}
+	if len(keys) != len(want) {
+		t.Fatalf("Expected parts %v, got %v", want, keys)
+	}
+	for i := range want {
+		if strings.Join(keys[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("Expected parts %v, got %v", want, keys)
+		}
+	}
+
+	if parts := splitAttrs(attrs, 1000); parts != nil {
+		t.Errorf("Expected nil for attrs within the limit, got %d parts", len(parts))
+	}
+	if parts := splitAttrs(attrs, 0); parts != nil {
+		t.Errorf("Expected nil with splitting disabled, got %d parts", len(parts))
+	}
+}
@@ -0,0 +1,110 @@
+package canonlog
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// Counters reported by Stats.
+var (
+	statLinesDebug    atomic.Uint64
+	statLinesInfo     atomic.Uint64
+	statLinesWarn     atomic.Uint64
+	statLinesError    atomic.Uint64
+	statSuppressed    atomic.Uint64
+	statAggregated    atomic.Uint64
+	statSampledOut    atomic.Uint64
+	statDebugCaptured atomic.Uint64
+	statDebugSkipped  atomic.Uint64
+	statLoggers       atomic.Uint64
+	statPoolMisses    atomic.Uint64
+)
+
+// StatsSnapshot reports counters of canonlog's own activity since the process
+// started, for monitoring the logging subsystem itself.
+type StatsSnapshot struct {
+	// Lines counts emitted lines by level name (DEBUG, INFO, WARN, ERROR),
+	// including checkpoints. Lines at custom levels are counted under the
+	// nearest standard level below them.
+	Lines map[string]uint64 `json:"lines"`
+
+	// Suppressed counts entries dropped by the Limiter.
+	Suppressed uint64 `json:"suppressed"`
+
+	// Aggregated counts entries folded into Aggregator rollups instead of
+	// being emitted.
+	Aggregated uint64 `json:"aggregated"`
+
+	// SampledOut counts entries dropped by the sample rate of their sampling
+	// class: BotConfig.SampleRate for bot traffic, or an identity policy's
+	// Override.SampleRate. Lines a Sink samples out are not counted.
+	SampledOut uint64 `json:"sampled_out"`
+
+	// DebugCaptured counts loggers created with debug capture, either forced
+	// with ForceDebug or chosen by the debug sampler. DebugSkipped counts
+	// loggers the sampler declined.
+	DebugCaptured uint64 `json:"debug_captured"`
+	DebugSkipped  uint64 `json:"debug_skipped"`
+
+	// Loggers counts loggers created by New; LoggersReused counts those
+	// whose fields map was taken from the pool of released loggers.
+	Loggers       uint64 `json:"loggers"`
+	LoggersReused uint64 `json:"loggers_reused"`
+}
+
+// Stats returns a snapshot of canonlog's counters. Publish it with expvar to
+// expose it alongside other process metrics:
+//
+//	expvar.Publish("canonlog", expvar.Func(func() any { return canonlog.Stats() }))
+func Stats() StatsSnapshot {
+	n := statLoggers.Load()
+	misses := statPoolMisses.Load()
+	reused := uint64(0)
+	if n > misses {
+		reused = n - misses
+	}
+	return StatsSnapshot{
+		Lines: map[string]uint64{
+			slog.LevelDebug.String(): statLinesDebug.Load(),
+			slog.LevelInfo.String():  statLinesInfo.Load(),
+			slog.LevelWarn.String():  statLinesWarn.Load(),
+			slog.LevelError.String(): statLinesError.Load(),
+		},
+		Suppressed:    statSuppressed.Load(),
+		Aggregated:    statAggregated.Load(),
+		SampledOut:    statSampledOut.Load(),
+		DebugCaptured: statDebugCaptured.Load(),
+		DebugSkipped:  statDebugSkipped.Load(),
+		Loggers:       n,
+		LoggersReused: reused,
+	}
+}
+
+// StatsHandler returns an http.Handler that serves Stats as JSON, for mounting
+// on an internal debug endpoint.
+//
+// Example:
+//
+//	mux.Handle("/debug/canonlog", canonlog.StatsHandler())
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Stats())
+	})
+}
+
+// countLine records an emitted line at level.
+func countLine(level slog.Level) {
+	switch {
+	case level >= slog.LevelError:
+		statLinesError.Add(1)
+	case level >= slog.LevelWarn:
+		statLinesWarn.Add(1)
+	case level >= slog.LevelInfo:
+		statLinesInfo.Add(1)
+	default:
+		statLinesDebug.Add(1)
+	}
+}
@@ -0,0 +1,82 @@
+package canonlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	captureOutput(t)
+	before := Stats()
+
+	l := New()
+	l.InfoAdd("k", "v")
+	l.Flush(context.Background())
+	l.ErrorAdd(errors.New("boom"))
+	l.Flush(context.Background())
+
+	SetLimiter(NewLimiter(time.Hour, "route"))
+	t.Cleanup(func() { SetLimiter(nil) })
+	for range 3 {
+		New().InfoAdd("route", "/x").Flush(context.Background())
+	}
+
+	after := Stats()
+	if d := after.Lines["INFO"] - before.Lines["INFO"]; d != 2 {
+		t.Errorf("Expected 2 INFO lines, got %d", d)
+	}
+	if d := after.Lines["ERROR"] - before.Lines["ERROR"]; d != 1 {
+		t.Errorf("Expected 1 ERROR line, got %d", d)
+	}
+	if d := after.Suppressed - before.Suppressed; d != 2 {
+		t.Errorf("Expected 2 suppressed entries, got %d", d)
+	}
+	if d := after.Loggers - before.Loggers; d != 4 {
+		t.Errorf("Expected 4 loggers created, got %d", d)
+	}
+	if after.LoggersReused > after.Loggers {
+		t.Errorf("Reused loggers %d exceed created %d", after.LoggersReused, after.Loggers)
+	}
+}
+
+func TestStatsDebugSampler(t *testing.T) {
+	t.Cleanup(func() { SetDebugSampler(nil) })
+	before := Stats()
+
+	sample := true
+	SetDebugSampler(func(context.Context) bool { return sample })
+	NewContext(context.Background())
+	sample = false
+	NewContext(context.Background())
+	NewContext(ForceDebug(context.Background()))
+
+	after := Stats()
+	if d := after.DebugCaptured - before.DebugCaptured; d != 2 {
+		t.Errorf("Expected 2 captured, got %d", d)
+	}
+	if d := after.DebugSkipped - before.DebugSkipped; d != 1 {
+		t.Errorf("Expected 1 skipped, got %d", d)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/canonlog", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var got StatsSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON body: %v", err)
+	}
+	if _, ok := got.Lines["INFO"]; !ok {
+		t.Errorf("Expected line counts by level, got %v", got.Lines)
+	}
+}
@@ -0,0 +1,84 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// stdLogBindings maps goroutine IDs to their bound loggers.
+var stdLogBindings sync.Map
+
+// stdLogWriter is the log package output installed by CaptureStdLog.
+type stdLogWriter struct {
+	key      string
+	fallback io.Writer
+}
+
+// Write records p on the logger bound to the calling goroutine, or writes it
+// to the previous log output if none is bound.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	if l, ok := stdLogBindings.Load(goroutineID()); ok {
+		return (&logWriter{l: l.(*Logger), key: w.key}).Write(p)
+	}
+	return w.fallback.Write(p)
+}
+
+// CaptureStdLog redirects the standard library log package's output so that
+// lines written on a goroutine bound with BindStdLog are recorded on that
+// goroutine's logger under key, like Writer, instead of printed. Output from
+// other goroutines goes to the previous log output unchanged. It returns a
+// function that restores the previous output. Use it while migrating code that
+// still calls log.Printf; consider log.SetFlags(0), since timestamps and
+// prefixes are recorded as part of each line.
+//
+// CaptureStdLog is experimental. It identifies goroutines by parsing
+// runtime.Stack, and only the bound goroutine is captured, not goroutines it
+// starts.
+//
+// Example:
+//
+//	restore := canonlog.CaptureStdLog("legacy_log")
+//	defer restore()
+//
+//	// in the request handler:
+//	ctx := canonlog.NewContext(r.Context())
+//	defer canonlog.BindStdLog(ctx)()
+//	log.Printf("cache miss for %s", key) // legacy_log=["cache miss for user:42"]
+func CaptureStdLog(key string) (restore func()) {
+	prev := log.Writer()
+	log.SetOutput(&stdLogWriter{key: key, fallback: prev})
+	return func() {
+		log.SetOutput(prev)
+	}
+}
+
+// BindStdLog binds the calling goroutine to the logger in ctx, so output from
+// the log package on this goroutine is recorded on it while CaptureStdLog is
+// active. It returns a function that removes the binding; call it before the
+// request ends, typically with defer. Panics if no logger exists in context.
+func BindStdLog(ctx context.Context) (unbind func()) {
+	id := goroutineID()
+	l := GetLogger(ctx)
+	stdLogBindings.Store(id, l)
+	return func() {
+		stdLogBindings.CompareAndDelete(id, l)
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// "goroutine N [...]" header of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
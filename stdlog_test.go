@@ -0,0 +1,66 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"testing"
+)
+
+func TestCaptureStdLog(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	var fallback bytes.Buffer
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(&fallback)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	})
+
+	restore := CaptureStdLog("legacy_log")
+
+	ctx := NewContext(context.Background())
+	unbind := BindStdLog(ctx)
+	log.Printf("cache miss for %s", "user:42")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Print("from another goroutine")
+	}()
+	<-done
+
+	unbind()
+	log.Print("after unbind")
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	lines, _ := entry["legacy_log"].([]any)
+	if len(lines) != 1 || lines[0] != "cache miss for user:42" {
+		t.Errorf("Expected only the bound goroutine's line, got %v", entry["legacy_log"])
+	}
+	if got := fallback.String(); got != "from another goroutine\nafter unbind\n" {
+		t.Errorf("Expected unbound output on the previous writer, got %q", got)
+	}
+
+	restore()
+	if log.Writer() != &fallback {
+		t.Error("Expected restore to reinstate the previous output")
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	if id == 0 {
+		t.Fatal("Expected a non-zero goroutine ID")
+	}
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	if id == <-other {
+		t.Error("Expected goroutines to have distinct IDs")
+	}
+}
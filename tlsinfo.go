@@ -0,0 +1,45 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+)
+
+// Fields recorded by ExtractTLS.
+const (
+	tlsVersionKey       = "tls_version"
+	tlsCipherKey        = "tls_cipher"
+	tlsSNIKey           = "tls_sni"
+	tlsALPNKey          = "tls_alpn"
+	tlsResumedKey       = "tls_resumed"
+	tlsClientSubjectKey = "tls_client_subject"
+)
+
+// ExtractTLS records the TLS connection metadata of r on the logger in ctx:
+// tls_version, tls_cipher, tls_sni, tls_alpn (the negotiated protocol, such as
+// "h2"), tls_resumed, and, when the client presented a certificate,
+// tls_client_subject. It helps debug mTLS failures and protocol downgrades.
+// It does nothing for plaintext requests or if ctx has no logger.
+func ExtractTLS(ctx context.Context, r *http.Request) {
+	l, ok := TryGetLogger(ctx)
+	if !ok || r.TLS == nil {
+		return
+	}
+	cs := r.TLS
+	fields := map[string]any{
+		tlsVersionKey: tls.VersionName(cs.Version),
+		tlsCipherKey:  tls.CipherSuiteName(cs.CipherSuite),
+		tlsResumedKey: cs.DidResume,
+	}
+	if cs.ServerName != "" {
+		fields[tlsSNIKey] = cs.ServerName
+	}
+	if cs.NegotiatedProtocol != "" {
+		fields[tlsALPNKey] = cs.NegotiatedProtocol
+	}
+	if len(cs.PeerCertificates) > 0 {
+		fields[tlsClientSubjectKey] = cs.PeerCertificates[0].Subject.String()
+	}
+	l.InfoAddMany(fields)
+}
@@ -0,0 +1,52 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractTLS(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	r := httptest.NewRequest("GET", "https://api.example.com/", nil)
+	r.TLS = &tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+		ServerName:         "api.example.com",
+		NegotiatedProtocol: "h2",
+		PeerCertificates:   []*x509.Certificate{{Subject: pkix.Name{CommonName: "billing-svc"}}},
+	}
+
+	ctx := NewContext(context.Background())
+	ExtractTLS(ctx, r)
+
+	f := GetLogger(ctx).Fields()
+	want := map[string]any{
+		"tls_version":        "TLS 1.3",
+		"tls_cipher":         "TLS_AES_128_GCM_SHA256",
+		"tls_sni":            "api.example.com",
+		"tls_alpn":           "h2",
+		"tls_resumed":        false,
+		"tls_client_subject": "CN=billing-svc",
+	}
+	for k, v := range want {
+		if f[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, f[k])
+		}
+	}
+}
+
+func TestExtractTLSPlaintext(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	ctx := NewContext(context.Background())
+	ExtractTLS(ctx, httptest.NewRequest("GET", "/", nil))
+	if n := GetLogger(ctx).Len(); n != 0 {
+		t.Errorf("Expected no fields for plaintext request, got %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+package canonlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context headers.
+const (
+	traceparentHeader = "Traceparent"
+	tracestateHeader  = "Tracestate"
+)
+
+// Fields recorded from the trace context.
+const (
+	traceIDKey      = "trace_id"
+	spanIDKey       = "span_id"
+	parentSpanIDKey = "parent_span_id"
+)
+
+// traceContext is the W3C trace context of the unit of work a logger covers.
+type traceContext struct {
+	traceID string
+	spanID  string
+	flags   string
+	state   string
+}
+
+// traceparent formats tc as a traceparent header value.
+func (tc *traceContext) traceparent() string {
+	return "00-" + tc.traceID + "-" + tc.spanID + "-" + tc.flags
+}
+
+// ExtractTraceContext reads the W3C traceparent and tracestate headers from h
+// and records trace_id, span_id, and parent_span_id as persistent fields on the
+// logger in ctx. A new span ID is generated for the current unit of work. If the
+// traceparent header is absent or invalid, a new trace is started, so every
+// request is correlatable even in services that don't run a tracing SDK.
+//
+// Outbound requests made through NewTransport carry the trace context, with the
+// current span as parent. It does nothing if ctx has no logger.
+//
+// Example:
+//
+//	ctx := canonlog.NewContext(r.Context())
+//	canonlog.ExtractTraceContext(ctx, r.Header)
+func ExtractTraceContext(ctx context.Context, h http.Header) {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return
+	}
+
+	tc := &traceContext{spanID: randomHex(8), flags: "01"}
+	traceID, parentID, flags, valid := parseTraceparent(h.Get(traceparentHeader))
+	if valid {
+		tc.traceID = traceID
+		tc.flags = flags
+		tc.state = strings.Join(h.Values(tracestateHeader), ",")
+	} else {
+		tc.traceID = randomHex(16)
+	}
+
+	l.mu.Lock()
+	l.trace = tc
+	l.mu.Unlock()
+
+	l.Persist(traceIDKey, tc.traceID)
+	l.Persist(spanIDKey, tc.spanID)
+	if valid {
+		l.Persist(parentSpanIDKey, parentID)
+	}
+}
+
+// InjectTraceContext writes the trace context of the logger in ctx to the
+// traceparent and tracestate headers of h, with the logger's span as parent.
+// Headers that are already set, for example by a tracing SDK, are left as is.
+func InjectTraceContext(ctx context.Context, h http.Header) {
+	tc := traceContextFrom(ctx)
+	if tc == nil || h.Get(traceparentHeader) != "" {
+		return
+	}
+	h.Set(traceparentHeader, tc.traceparent())
+	if tc.state != "" {
+		h.Set(tracestateHeader, tc.state)
+	}
+}
+
+// traceContextFrom returns the trace context of the logger in ctx, if any.
+func traceContextFrom(ctx context.Context) *traceContext {
+	l, ok := TryGetLogger(ctx)
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.trace
+}
+
+// parseTraceparent validates a version 00 traceparent header value.
+func parseTraceparent(v string) (traceID, parentID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !isHex(parts[0], 2) {
+		return "", "", "", false
+	}
+	// Version 00 has exactly four parts; later versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", "", false
+	}
+	traceID, parentID, flags = parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return "", "", "", false
+	}
+	if traceID == strings.Repeat("0", 32) || parentID == strings.Repeat("0", 16) {
+		return "", "", "", false
+	}
+	return traceID, parentID, flags, true
+}
+
+// isHex reports whether s is n lowercase hex characters.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,112 @@
+package canonlog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestExtractTraceContext(t *testing.T) {
+	h := http.Header{}
+	h.Set("Traceparent", testTraceparent)
+	h.Set("Tracestate", "vendor=value")
+
+	ctx := NewContext(context.Background())
+	ExtractTraceContext(ctx, h)
+
+	fields := GetLogger(ctx).Fields()
+	if fields[traceIDKey] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace_id from header, got %v", fields[traceIDKey])
+	}
+	if fields[parentSpanIDKey] != "00f067aa0ba902b7" {
+		t.Errorf("Expected parent_span_id from header, got %v", fields[parentSpanIDKey])
+	}
+	spanID, _ := fields[spanIDKey].(string)
+	if !isHex(spanID, 16) || spanID == "00f067aa0ba902b7" {
+		t.Errorf("Expected a new span_id, got %v", fields[spanIDKey])
+	}
+
+	out := http.Header{}
+	InjectTraceContext(ctx, out)
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + spanID + "-01"; out.Get("Traceparent") != want {
+		t.Errorf("Expected outbound traceparent %q, got %q", want, out.Get("Traceparent"))
+	}
+	if out.Get("Tracestate") != "vendor=value" {
+		t.Errorf("Expected tracestate to be forwarded, got %q", out.Get("Tracestate"))
+	}
+}
+
+func TestExtractTraceContextGenerates(t *testing.T) {
+	ctx := NewContext(context.Background())
+	ExtractTraceContext(ctx, http.Header{})
+
+	fields := GetLogger(ctx).Fields()
+	if traceID, _ := fields[traceIDKey].(string); !isHex(traceID, 32) {
+		t.Errorf("Expected generated trace_id, got %v", fields[traceIDKey])
+	}
+	if _, exists := fields[parentSpanIDKey]; exists {
+		t.Error("Expected no parent_span_id for a new trace")
+	}
+}
+
+func TestInjectTraceContextKeepsExisting(t *testing.T) {
+	ctx := NewContext(context.Background())
+	ExtractTraceContext(ctx, http.Header{})
+
+	h := http.Header{}
+	h.Set("Traceparent", testTraceparent)
+	InjectTraceContext(ctx, h)
+
+	if h.Get("Traceparent") != testTraceparent {
+		t.Error("InjectTraceContext should not overwrite an existing traceparent")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{testTraceparent, true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-short-00f067aa0ba902b7-01", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, _, _, ok := parseTraceparent(tt.value); ok != tt.valid {
+			t.Errorf("parseTraceparent(%q) valid = %v, want %v", tt.value, ok, tt.valid)
+		}
+	}
+}
+
+func TestTransportTraceContext(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Traceparent")
+	}))
+	defer srv.Close()
+
+	ctx := NewContext(context.Background())
+	ExtractTraceContext(ctx, http.Header{})
+	traceID := GetLogger(ctx).Fields()[traceIDKey].(string)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if !strings.HasPrefix(received, "00-"+traceID+"-") {
+		t.Errorf("Expected downstream traceparent for trace %s, got %q", traceID, received)
+	}
+}
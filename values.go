@@ -0,0 +1,93 @@
+package canonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+)
+
+// WithStructuredValues renders map and struct field values as nested slog groups
+// instead of their fmt representation. Structs and non-string-keyed maps are
+// converted through encoding/json, so json struct tags and json.Marshaler are
+// honored. Values implementing slog.LogValuer, error, or fmt.Stringer are left
+// for the handler to render, as are all scalar types.
+//
+// Example:
+//
+//	l := canonlog.New(canonlog.WithStructuredValues())
+//	l.InfoAdd("user", map[string]any{"id": "123", "role": "admin"})
+//	// text output: user.id=123 user.role=admin
+func WithStructuredValues() Option {
+	return func(l *Logger) {
+		l.structured = true
+	}
+}
+
+// structuredValue converts v into a group value when it is a map or struct.
+// Values that slog already knows how to render are returned unchanged.
+func structuredValue(v any) slog.Value {
+	val := slog.AnyValue(v)
+	if val.Kind() != slog.KindAny {
+		return val
+	}
+
+	switch v := v.(type) {
+	case slog.LogValuer, error, fmt.Stringer:
+		return val
+	case map[string]any:
+		return mapValue(v, structuredValue)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return val
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Map && rv.Kind() != reflect.Struct {
+		if _, ok := v.(json.Marshaler); !ok {
+			return val
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return val
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		return val
+	}
+	return jsonValue(decoded)
+}
+
+// jsonValue converts a decoded JSON value into a slog value, turning objects
+// into groups.
+func jsonValue(v any) slog.Value {
+	if m, ok := v.(map[string]any); ok {
+		return mapValue(m, jsonValue)
+	}
+	return slog.AnyValue(v)
+}
+
+// mapValue builds a group from m with keys in sorted order, converting each
+// value with conv.
+func mapValue(m map[string]any, conv func(any) slog.Value) slog.Value {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.Attr{Key: k, Value: conv(m[k])}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,75 @@
+package canonlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type testUser struct {
+	ID    string `json:"id"`
+	Email string `json:"-"`
+	Admin bool   `json:"admin"`
+}
+
+type testValuer struct{}
+
+func (testValuer) LogValue() slog.Value { return slog.StringValue("resolved") }
+
+func TestStructuredValues(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	l := New(WithStructuredValues())
+	l.InfoAdd("map", map[string]any{"foo": "bar", "nested": map[string]any{"n": 1}})
+	l.InfoAdd("struct", testUser{ID: "123", Email: "secret@example.com", Admin: true})
+	l.InfoAdd("ptr", &testUser{ID: "456"})
+	l.InfoAdd("valuer", testValuer{})
+	l.InfoAdd("scalar", 42)
+	l.Flush(context.Background())
+
+	entry := decodeEntry(t, buf)
+
+	m, ok := entry["map"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected map to be a group, got %T", entry["map"])
+	}
+	if m["foo"] != "bar" {
+		t.Errorf("Expected map.foo=bar, got %v", m["foo"])
+	}
+	if nested, ok := m["nested"].(map[string]any); !ok || nested["n"] != float64(1) {
+		t.Errorf("Expected map.nested.n=1, got %v", m["nested"])
+	}
+
+	st, ok := entry["struct"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected struct to be a group, got %T", entry["struct"])
+	}
+	if st["id"] != "123" || st["admin"] != true {
+		t.Errorf("Expected struct fields id=123 admin=true, got %v", st)
+	}
+	if _, exists := st["Email"]; exists {
+		t.Error("json:\"-\" field should not be emitted")
+	}
+
+	if ptr, ok := entry["ptr"].(map[string]any); !ok || ptr["id"] != "456" {
+		t.Errorf("Expected ptr.id=456, got %v", entry["ptr"])
+	}
+	if entry["valuer"] != "resolved" {
+		t.Errorf("Expected LogValuer to be resolved, got %v", entry["valuer"])
+	}
+	if entry["scalar"] != float64(42) {
+		t.Errorf("Expected scalar=42, got %v", entry["scalar"])
+	}
+}
+
+func TestStructuredValuesText(t *testing.T) {
+	val := structuredValue(map[string]any{"foo": "bar"})
+
+	if val.Kind() != slog.KindGroup {
+		t.Fatalf("Expected group value, got %v", val.Kind())
+	}
+	if got := val.Group()[0].String(); got != "foo=bar" {
+		t.Errorf("Expected foo=bar, got %s", got)
+	}
+}
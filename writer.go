@@ -0,0 +1,60 @@
+package canonlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+)
+
+// maxWriterLine is the length in bytes at which lines written to a Writer
+// are cut.
+const maxWriterLine = 1024
+
+// Writer returns an io.Writer that records each line written to it under key
+// on the logger in ctx, so output from legacy code and third-party libraries
+// ends up on the request's canonical line instead of in unrelated log lines.
+// Lines accumulate as an array like Append, up to 100 per entry; lines longer
+// than 1024 bytes are cut. Both mark the entry with canonlog_truncated=true.
+// Each Write is treated as complete, so text without a trailing newline is
+// recorded as its own line. Panics if no logger exists in context.
+//
+// Example:
+//
+//	legacy := log.New(canonlog.Writer(ctx, "legacy_log"), "", 0)
+//	legacy.Printf("cache miss for %s", key)
+//	// legacy_log=["cache miss for user:42"]
+func Writer(ctx context.Context, key string) io.Writer {
+	return &logWriter{l: GetLogger(ctx), key: key}
+}
+
+// logWriter is the io.Writer returned by Writer.
+type logWriter struct {
+	l   *Logger
+	key string
+}
+
+// Write records each line of p. It always reports len(p) bytes written, so
+// writers such as log.Logger never see an error.
+func (w *logWriter) Write(p []byte) (int, error) {
+	if w.l.gateLevel.Load() > slog.LevelInfo {
+		return len(p), nil
+	}
+	for line := range bytes.Lines(p) {
+		s := string(bytes.TrimRight(line, "\r\n"))
+		if s == "" {
+			continue
+		}
+		cut := len(s) > maxWriterLine
+		if cut {
+			s = truncateString(s, maxWriterLine)
+		}
+		w.l.Append(w.key, s)
+		if cut {
+			w.l.mu.Lock()
+			w.l.truncated = true
+			w.l.mu.Unlock()
+		}
+	}
+	return len(p), nil
+}
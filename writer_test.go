@@ -0,0 +1,66 @@
+package canonlog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+	buf := captureOutput(t)
+
+	ctx := NewContext(context.Background())
+	legacy := log.New(Writer(ctx, "legacy_log"), "", 0)
+	legacy.Printf("cache miss for %s", "user:42")
+	legacy.Print("retrying")
+	fmt.Fprint(Writer(ctx, "legacy_log"), "multi\r\nline\n\n")
+	Flush(ctx)
+
+	entry := decodeEntry(t, buf)
+	lines, _ := entry["legacy_log"].([]any)
+	want := []string{"cache miss for user:42", "retrying", "multi", "line"}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected lines %q, got %v", want, entry["legacy_log"])
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("Expected line %d %q, got %q", i, w, lines[i])
+		}
+	}
+	if entry["canonlog_truncated"] != nil {
+		t.Error("Expected no truncation")
+	}
+}
+
+func TestWriterTruncates(t *testing.T) {
+	defer setTestLogLevel(slog.LevelInfo)()
+
+	l := New()
+	w := &logWriter{l: l, key: "out"}
+	n, err := w.Write([]byte(strings.Repeat("x", 2000) + "\n"))
+	if n != 2001 || err != nil {
+		t.Errorf("Expected Write to report 2001 bytes, got %d, %v", n, err)
+	}
+
+	lines, _ := l.Fields()["out"].([]any)
+	if len(lines) != 1 || len(lines[0].(string)) != maxWriterLine {
+		t.Errorf("Expected one line cut to %d bytes, got %v", maxWriterLine, lines)
+	}
+	if !l.truncated {
+		t.Error("Expected the entry to be marked truncated")
+	}
+}
+
+func TestWriterGated(t *testing.T) {
+	defer setTestLogLevel(slog.LevelWarn)()
+
+	l := New()
+	fmt.Fprintln(&logWriter{l: l, key: "out"}, strings.Repeat("x", 2000))
+	if l.Len() != 0 || l.truncated {
+		t.Error("Expected nothing recorded below the gate level")
+	}
+}